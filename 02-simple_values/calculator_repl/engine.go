@@ -0,0 +1,2000 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"math/cmplx"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ----------------------------------------------------------
+// THE CALCULATOR'S EXPRESSION ENGINE
+// ----------------------------------------------------------
+// This is the engine from ../expression_language (lexer, parser, type
+// checker, evaluator), grown for the calculator. Every lesson is its own
+// program and there is no go.mod, so one lesson cannot import another;
+// the engine is copied here instead. The additions:
+//   - big integers (KindBig), ** and the conditional cond ? a : b;
+//   - more builtins: sqrt, floor, ceil, ln, big, int and float;
+//   - for scripts only (CompileScript): variables (x := 1, x = 2),
+//     blocks ({ a := 1; a + 1 }), functions with closures (fn(n) => n * n)
+//     and several expressions separated by ;.
+// Compile still rejects the script syntax, so rules stay sandboxed.
+// ----------------------------------------------------------
+
+// ----------------------------------------------------------
+// POSITIONS AND ERRORS
+// ----------------------------------------------------------
+
+// Pos is a location in the source text (1-based line and column).
+type Pos struct {
+	Offset int
+	Line   int
+	Col    int
+}
+
+// Error is any lexing, parsing, type or runtime error. It remembers the
+// source so it can point at the exact character with a caret (^).
+type Error struct {
+	Pos Pos
+	Msg string
+	Src string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Pos.Line, e.Pos.Col, e.Msg)
+}
+
+// Caret returns the offending source line with a ^ under the error position.
+func (e *Error) Caret() string {
+	lines := strings.Split(e.Src, "\n")
+	if e.Pos.Line < 1 || e.Pos.Line > len(lines) {
+		return e.Error()
+	}
+	line := lines[e.Pos.Line-1]
+	// Col counts runes, so pad by the runes before the error, not the bytes.
+	pad := strings.Repeat(" ", min(utf8.RuneCountInString(line), e.Pos.Col-1))
+	return e.Error() + "\n    " + line + "\n    " + pad + "^"
+}
+
+// ----------------------------------------------------------
+// 1. LEXER
+// ----------------------------------------------------------
+
+// TokenKind classifies tokens.
+type TokenKind int
+
+const (
+	TokEOF TokenKind = iota
+	TokInt
+	TokFloat
+	TokImag
+	TokString
+	TokIdent
+	TokOp // operators and punctuation: + - * / % ** == != < <= > >= && || ! ( ) , . ? : and, for scripts, = := => ; { }
+)
+
+// Token is one lexical unit.
+type Token struct {
+	Kind TokenKind
+	Text string
+	Pos  Pos
+}
+
+// wordOps are operators written as words.
+var wordOps = map[string]bool{"startsWith": true, "endsWith": true, "contains": true}
+
+type lexer struct {
+	src    string
+	offset int
+	line   int
+	col    int
+}
+
+func (l *lexer) pos() Pos { return Pos{l.offset, l.line, l.col} }
+
+func (l *lexer) errorf(p Pos, format string, args ...any) *Error {
+	return &Error{Pos: p, Msg: fmt.Sprintf(format, args...), Src: l.src}
+}
+
+func (l *lexer) peek() rune {
+	r, _ := utf8.DecodeRuneInString(l.src[l.offset:])
+	return r
+}
+
+func (l *lexer) next() rune {
+	r, size := utf8.DecodeRuneInString(l.src[l.offset:])
+	l.offset += size
+	if r == '\n' {
+		l.line++
+		l.col = 1
+	} else {
+		l.col++
+	}
+	return r
+}
+
+// lex turns the whole source into tokens, ending with TokEOF.
+func lex(src string) ([]Token, error) {
+	l := &lexer{src: src, line: 1, col: 1}
+	var toks []Token
+	for {
+		for l.offset < len(src) && unicode.IsSpace(l.peek()) {
+			l.next()
+		}
+		start := l.pos()
+		if l.offset >= len(src) {
+			return append(toks, Token{Kind: TokEOF, Pos: start}), nil
+		}
+		r := l.peek()
+
+		switch {
+		case unicode.IsDigit(r):
+			tok, err := l.number(start)
+			if err != nil {
+				return nil, err
+			}
+			toks = append(toks, tok)
+
+		case r == '"':
+			tok, err := l.str(start)
+			if err != nil {
+				return nil, err
+			}
+			toks = append(toks, tok)
+
+		case unicode.IsLetter(r) || r == '_':
+			for l.offset < len(src) && (unicode.IsLetter(l.peek()) || unicode.IsDigit(l.peek()) || l.peek() == '_') {
+				l.next()
+			}
+			text := src[start.Offset:l.offset]
+			kind := TokIdent
+			if wordOps[text] {
+				kind = TokOp
+			}
+			toks = append(toks, Token{Kind: kind, Text: text, Pos: start})
+
+		default:
+			two := ""
+			if l.offset+2 <= len(src) {
+				two = src[l.offset : l.offset+2]
+			}
+			switch two {
+			case "==", "!=", "<=", ">=", "&&", "||", "**", ":=", "=>":
+				l.next()
+				l.next()
+				toks = append(toks, Token{Kind: TokOp, Text: two, Pos: start})
+				continue
+			}
+			if strings.ContainsRune("+-*/%<>!(),.?:=;{}", r) {
+				l.next()
+				toks = append(toks, Token{Kind: TokOp, Text: string(r), Pos: start})
+				continue
+			}
+			return nil, l.errorf(start, "unexpected character %q", r)
+		}
+	}
+}
+
+// number reads 42, 10.5, 1e3 or an imaginary literal like 2i or 1.5i (as in Go).
+func (l *lexer) number(start Pos) (Token, error) {
+	kind := TokInt
+	digits := func() {
+		for l.offset < len(l.src) && (unicode.IsDigit(l.peek()) || l.peek() == '_') {
+			l.next()
+		}
+	}
+	digits()
+	if l.peek() == '.' && l.offset+1 < len(l.src) && unicode.IsDigit(rune(l.src[l.offset+1])) {
+		kind = TokFloat
+		l.next()
+		digits()
+	}
+	if l.peek() == 'e' || l.peek() == 'E' {
+		kind = TokFloat
+		l.next()
+		if l.peek() == '+' || l.peek() == '-' {
+			l.next()
+		}
+		if !unicode.IsDigit(l.peek()) {
+			return Token{}, l.errorf(l.pos(), "exponent has no digits")
+		}
+		digits()
+	}
+	if l.peek() == 'i' {
+		kind = TokImag
+		l.next()
+	}
+	if unicode.IsLetter(l.peek()) {
+		return Token{}, l.errorf(l.pos(), "unexpected %q after number", l.peek())
+	}
+	return Token{Kind: kind, Text: l.src[start.Offset:l.offset], Pos: start}, nil
+}
+
+// str reads a double-quoted string with Go escape sequences.
+func (l *lexer) str(start Pos) (Token, error) {
+	l.next() // opening quote
+	for {
+		if l.offset >= len(l.src) || l.peek() == '\n' {
+			return Token{}, l.errorf(start, "string not terminated")
+		}
+		r := l.next()
+		if r == '\\' && l.offset < len(l.src) {
+			l.next()
+			continue
+		}
+		if r == '"' {
+			break
+		}
+	}
+	raw := l.src[start.Offset:l.offset]
+	text, err := strconv.Unquote(raw)
+	if err != nil {
+		return Token{}, l.errorf(start, "invalid string %s", raw)
+	}
+	return Token{Kind: TokString, Text: text, Pos: start}, nil
+}
+
+// ----------------------------------------------------------
+// 2. PARSER (AST)
+// ----------------------------------------------------------
+
+// Kind is the static type of an expression.
+type Kind int
+
+const (
+	KindInvalid Kind = iota
+	KindBool
+	KindInt // int64
+	KindBig // *big.Int, an integer of any size
+	KindFloat
+	KindComplex
+	KindString
+	KindObject // a struct or map we can read fields from
+	KindFunc   // a calculator function (*Closure)
+	KindAny    // only known at run time: calculator parameters and captured variables
+)
+
+func (k Kind) String() string {
+	return [...]string{"invalid", "bool", "int", "big", "float", "complex", "string", "object", "func", "any"}[k]
+}
+
+// Type is a Kind plus, for objects, the Go type we read fields from.
+type Type struct {
+	Kind Kind
+	Go   reflect.Type
+}
+
+func (t Type) String() string {
+	if t.Kind == KindObject && t.Go != nil {
+		return t.Go.String()
+	}
+	return t.Kind.String()
+}
+
+func (t Type) numeric() bool {
+	return t.Kind >= KindInt && t.Kind <= KindComplex
+}
+
+// Node is any expression in the tree. The checker fills in each node's type.
+type Node interface {
+	Position() Pos
+	Type() Type
+}
+
+type base struct {
+	pos Pos
+	typ Type
+}
+
+func (b *base) Position() Pos { return b.pos }
+func (b *base) Type() Type    { return b.typ }
+
+type (
+	Literal struct {
+		base
+		Value any // int64, *big.Int, float64, complex128, string or bool
+	}
+	Ident struct {
+		base
+		Name  string
+		local bool // a calculator variable rather than a Go value from env
+	}
+	Field struct {
+		base
+		X     Node
+		Name  string
+		index []int // resolved by the checker for struct fields
+	}
+	Unary struct {
+		base
+		Op string
+		X  Node
+	}
+	Binary struct {
+		base
+		Op   string
+		X, Y Node
+	}
+	Ternary struct {
+		base
+		Cond, Then, Else Node
+	}
+	Call struct {
+		base
+		Fn   Node
+		Name string // set by the checker when Fn names a builtin
+		Args []Node
+	}
+
+	// The nodes below only appear in calculator scripts (CompileScript).
+
+	Assign struct {
+		base
+		Name    string
+		Declare bool // := rather than =
+		X       Node
+		want    Type // the variable's type, for =
+	}
+	Block struct {
+		base
+		Exprs []Node
+		top   bool // the whole script: runs in the global scope, not a new one
+	}
+	FnLit struct {
+		base
+		Params []string
+		Body   Node
+	}
+)
+
+// precedence of binary operators; higher binds tighter. ** is parsed
+// separately (see power) because it groups to the right.
+var precedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3, "<": 3, "<=": 3, ">": 3, ">=": 3,
+	"startsWith": 3, "endsWith": 3, "contains": 3,
+	"+": 4, "-": 4,
+	"*": 5, "/": 5, "%": 5,
+}
+
+// maxDepth limits nesting like ((((...)))) so a hostile expression cannot
+// exhaust the stack while parsing.
+const maxDepth = 100
+
+type parser struct {
+	src    string
+	toks   []Token
+	i      int
+	depth  int
+	script bool // accept assignments, blocks, fn literals and ; (the calculator)
+}
+
+func (p *parser) peek() Token { return p.toks[p.i] }
+
+func (p *parser) next() Token {
+	t := p.toks[p.i]
+	if t.Kind != TokEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *parser) is(op string) bool {
+	t := p.peek()
+	return t.Kind == TokOp && t.Text == op
+}
+
+func (p *parser) errorf(pos Pos, format string, args ...any) *Error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, args...), Src: p.src}
+}
+
+func (p *parser) expect(op string) (Token, error) {
+	t := p.next()
+	if t.Kind != TokOp || t.Text != op {
+		return t, p.errorf(t.Pos, "expected %q, found %s", op, describe(t))
+	}
+	return t, nil
+}
+
+// nest counts one level of nesting; the caller must defer p.unnest().
+func (p *parser) nest(at Pos) error {
+	p.depth++
+	if p.depth > maxDepth {
+		return p.errorf(at, "expression nested too deeply (limit %d)", maxDepth)
+	}
+	return nil
+}
+
+func (p *parser) unnest() { p.depth-- }
+
+func describe(t Token) string {
+	if t.Kind == TokEOF {
+		return "end of expression"
+	}
+	return strconv.Quote(t.Text)
+}
+
+// Parse builds the AST for src.
+func Parse(src string) (Node, error) { return parse(src, false) }
+
+func parse(src string, script bool) (Node, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{src: src, toks: toks, script: script}
+	var n Node
+	if script {
+		var b *Block
+		b, err = p.sequence(toks[0].Pos, "")
+		if b != nil {
+			b.top = true
+		}
+		n = b
+	} else {
+		n, err = p.expr()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.Kind != TokEOF {
+		return nil, p.errorf(t.Pos, "unexpected %s", describe(t))
+	}
+	return n, nil
+}
+
+// sequence parses  e1; e2; ...  up to the closing end token (or the end of
+// the script), without consuming it.
+func (p *parser) sequence(start Pos, end string) (*Block, error) {
+	b := &Block{base: base{pos: start}}
+	for {
+		for p.is(";") {
+			p.next()
+		}
+		if p.peek().Kind == TokEOF || (end != "" && p.is(end)) {
+			return b, nil
+		}
+		x, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		b.Exprs = append(b.Exprs, x)
+		if !p.is(";") {
+			return b, nil
+		}
+	}
+}
+
+// expr is the lowest precedence level: assignment (scripts only) and the
+// conditional  cond ? a : b.
+func (p *parser) expr() (Node, error) {
+	if t := p.peek(); p.script && t.Kind == TokIdent && p.i+1 < len(p.toks) {
+		if op := p.toks[p.i+1]; op.Kind == TokOp && (op.Text == "=" || op.Text == ":=") {
+			if err := p.nest(t.Pos); err != nil {
+				return nil, err
+			}
+			defer p.unnest()
+			p.next()
+			p.next()
+			x, err := p.expr() // right-associative: a = b = 1
+			if err != nil {
+				return nil, err
+			}
+			return &Assign{base: base{pos: t.Pos}, Name: t.Text, Declare: op.Text == ":=", X: x}, nil
+		}
+	}
+
+	cond, err := p.binary(1)
+	if err != nil || !p.is("?") {
+		return cond, err
+	}
+	q := p.next()
+	then, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	els, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	return &Ternary{base: base{pos: q.Pos}, Cond: cond, Then: then, Else: els}, nil
+}
+
+// binary is a "precedence climbing" parser: it parses operators whose
+// precedence is at least minPrec, so 1 + 2 * 3 groups as 1 + (2 * 3).
+func (p *parser) binary(minPrec int) (Node, error) {
+	if err := p.nest(p.peek().Pos); err != nil {
+		return nil, err
+	}
+	defer p.unnest()
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		prec, ok := precedence[t.Text]
+		if t.Kind != TokOp || !ok || prec < minPrec {
+			return left, nil
+		}
+		p.next()
+		right, err := p.binary(prec + 1) // +1 makes operators left-associative
+		if err != nil {
+			return nil, err
+		}
+		left = &Binary{base: base{pos: t.Pos}, Op: t.Text, X: left, Y: right}
+	}
+}
+
+func (p *parser) unary() (Node, error) {
+	t := p.peek()
+	if t.Kind == TokOp && (t.Text == "!" || t.Text == "-" || t.Text == "+") {
+		// Each prefix operator nests one level, like a parenthesis does.
+		if err := p.nest(t.Pos); err != nil {
+			return nil, err
+		}
+		defer p.unnest()
+		p.next()
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &Unary{base: base{pos: t.Pos}, Op: t.Text, X: x}, nil
+	}
+	return p.power()
+}
+
+// power parses  x ** y. It binds tighter than unary minus (-2 ** 2 is -4,
+// as in maths) and groups to the right (2 ** 3 ** 2 is 2 ** 9).
+func (p *parser) power() (Node, error) {
+	x, err := p.postfix()
+	if err != nil || !p.is("**") {
+		return x, err
+	}
+	t := p.next()
+	if err := p.nest(t.Pos); err != nil {
+		return nil, err
+	}
+	defer p.unnest()
+	y, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	return &Binary{base: base{pos: t.Pos}, Op: "**", X: x, Y: y}, nil
+}
+
+// postfix parses a primary expression followed by any number of .field
+// and (arguments), so both order.customer.name and add(2)(3) work.
+func (p *parser) postfix() (Node, error) {
+	x, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		// a.b.c and f()()() nest like parentheses, so they count too.
+		if p.is(".") || p.is("(") {
+			if err := p.nest(p.peek().Pos); err != nil {
+				return nil, err
+			}
+			defer p.unnest()
+		}
+		switch {
+		case p.is("."):
+			dot := p.next()
+			name := p.next()
+			if name.Kind != TokIdent {
+				return nil, p.errorf(name.Pos, "expected field name after '.', found %s", describe(name))
+			}
+			x = &Field{base: base{pos: dot.Pos}, X: x, Name: name.Text}
+		case p.is("("):
+			if x, err = p.call(x); err != nil {
+				return nil, err
+			}
+		default:
+			return x, nil
+		}
+	}
+}
+
+func (p *parser) primary() (Node, error) {
+	t := p.next()
+	switch t.Kind {
+	case TokInt:
+		text := strings.ReplaceAll(t.Text, "_", "")
+		if v, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return &Literal{base: base{pos: t.Pos, typ: Type{Kind: KindInt}}, Value: v}, nil
+		}
+		// Too large for int64: the literal is a big integer instead.
+		v, _ := new(big.Int).SetString(text, 10)
+		return &Literal{base: base{pos: t.Pos, typ: Type{Kind: KindBig}}, Value: v}, nil
+	case TokFloat:
+		v, _ := strconv.ParseFloat(strings.ReplaceAll(t.Text, "_", ""), 64)
+		return &Literal{base: base{pos: t.Pos, typ: Type{Kind: KindFloat}}, Value: v}, nil
+	case TokImag:
+		v, _ := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSuffix(t.Text, "i"), "_", ""), 64)
+		return &Literal{base: base{pos: t.Pos, typ: Type{Kind: KindComplex}}, Value: complex(0, v)}, nil
+	case TokString:
+		return &Literal{base: base{pos: t.Pos, typ: Type{Kind: KindString}}, Value: t.Text}, nil
+	case TokIdent:
+		switch {
+		case t.Text == "true" || t.Text == "false":
+			return &Literal{base: base{pos: t.Pos, typ: Type{Kind: KindBool}}, Value: t.Text == "true"}, nil
+		case t.Text == "fn" && p.script:
+			return p.fnLit(t)
+		}
+		return &Ident{base: base{pos: t.Pos}, Name: t.Text}, nil
+	case TokOp:
+		switch {
+		case t.Text == "(":
+			x, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return x, nil
+		case t.Text == "{" && p.script:
+			b, err := p.sequence(t.Pos, "}")
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.expect("}"); err != nil {
+				return nil, err
+			}
+			return b, nil
+		}
+	}
+	return nil, p.errorf(t.Pos, "unexpected %s", describe(t))
+}
+
+func (p *parser) call(fn Node) (Node, error) {
+	p.next() // (
+	c := &Call{base: base{pos: fn.Position()}, Fn: fn}
+	if p.is(")") {
+		p.next()
+		return c, nil
+	}
+	name := "function"
+	if id, ok := fn.(*Ident); ok {
+		name = id.Name
+	}
+	for {
+		arg, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		c.Args = append(c.Args, arg)
+		t := p.next()
+		if t.Kind == TokOp && t.Text == ")" {
+			return c, nil
+		}
+		if t.Kind != TokOp || t.Text != "," {
+			return nil, p.errorf(t.Pos, "expected ',' or ')' in call to %s, found %s", name, describe(t))
+		}
+	}
+}
+
+// fnLit parses  fn(a, b) => body.
+func (p *parser) fnLit(kw Token) (Node, error) {
+	if _, err := p.expect("("); err != nil {
+		return nil, err
+	}
+	f := &FnLit{base: base{pos: kw.Pos}}
+	for !p.is(")") {
+		t := p.next()
+		if t.Kind != TokIdent {
+			return nil, p.errorf(t.Pos, "expected parameter name, found %s", describe(t))
+		}
+		f.Params = append(f.Params, t.Text)
+		if !p.is(",") {
+			break
+		}
+		p.next()
+	}
+	if _, err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	if _, err := p.expect("=>"); err != nil {
+		return nil, err
+	}
+	body, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	f.Body = body
+	return f, nil
+}
+
+// ----------------------------------------------------------
+// 3. TYPE CHECKER
+// ----------------------------------------------------------
+
+// typeOf maps a Go type to our small type system.
+func typeOf(t reflect.Type) Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return Type{Kind: KindBool}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return Type{Kind: KindInt}
+	case reflect.Float32, reflect.Float64:
+		return Type{Kind: KindFloat}
+	case reflect.Complex64, reflect.Complex128:
+		return Type{Kind: KindComplex}
+	case reflect.String:
+		return Type{Kind: KindString}
+	case reflect.Struct:
+		return Type{Kind: KindObject, Go: t}
+	case reflect.Map:
+		if t.Key().Kind() == reflect.String {
+			return Type{Kind: KindObject, Go: t}
+		}
+	}
+	return Type{Kind: KindInvalid, Go: t}
+}
+
+// kindOf returns the Kind of a value at run time.
+func kindOf(v any) Kind {
+	switch v.(type) {
+	case bool:
+		return KindBool
+	case int64:
+		return KindInt
+	case *big.Int:
+		return KindBig
+	case float64:
+		return KindFloat
+	case complex128:
+		return KindComplex
+	case string:
+		return KindString
+	case reflect.Value:
+		return KindObject
+	case *Closure:
+		return KindFunc
+	}
+	return KindInvalid
+}
+
+// typeOfValue is the Type of a value at run time.
+func typeOfValue(v any) Type {
+	if rv, ok := v.(reflect.Value); ok {
+		return typeOf(rv.Type())
+	}
+	return Type{Kind: kindOf(v)}
+}
+
+// unaryType and binaryType are the typing rules for operators. The checker
+// uses them before running; the evaluator uses them again for values whose
+// type is only known at run time (calculator variables).
+func unaryType(op string, x Type) (Type, bool) {
+	switch {
+	case x.Kind == KindAny:
+		return x, true
+	case op == "!":
+		return x, x.Kind == KindBool
+	default:
+		return x, x.numeric()
+	}
+}
+
+func binaryType(op string, x, y Type) (Type, bool) {
+	if x.Kind == KindAny || y.Kind == KindAny {
+		switch op {
+		case "&&", "||", "==", "!=", "<", "<=", ">", ">=", "startsWith", "endsWith", "contains":
+			return Type{Kind: KindBool}, true
+		}
+		return Type{Kind: KindAny}, true
+	}
+	boolean := Type{Kind: KindBool}
+	switch op {
+	case "&&", "||":
+		return boolean, x.Kind == KindBool && y.Kind == KindBool
+	case "+", "-", "*", "/", "**":
+		if op == "+" && x.Kind == KindString && y.Kind == KindString {
+			return x, true // string concatenation
+		}
+		return Type{Kind: promote(x.Kind, y.Kind)}, x.numeric() && y.numeric()
+	case "%":
+		integer := func(t Type) bool { return t.Kind == KindInt || t.Kind == KindBig }
+		return Type{Kind: promote(x.Kind, y.Kind)}, integer(x) && integer(y)
+	case "==", "!=":
+		same := x.Kind == y.Kind && x.Kind != KindObject && x.Kind != KindFunc && x.Kind != KindInvalid
+		return boolean, same || (x.numeric() && y.numeric())
+	case "<", "<=", ">", ">=":
+		ordered := func(t Type) bool { return t.Kind == KindInt || t.Kind == KindBig || t.Kind == KindFloat }
+		bothStrings := x.Kind == KindString && y.Kind == KindString
+		return boolean, bothStrings || (ordered(x) && ordered(y)) // complex numbers have no order
+	case "startsWith", "endsWith", "contains":
+		return boolean, x.Kind == KindString && y.Kind == KindString
+	}
+	return Type{}, false
+}
+
+// builtin describes one function callable from expressions.
+type builtin struct {
+	check func(args []Type) (Type, error)
+	call  func(args []any) (any, error)
+}
+
+// wantArgs makes a checker for functions with fixed argument kinds.
+func wantArgs(result Kind, params ...Kind) func([]Type) (Type, error) {
+	return func(args []Type) (Type, error) {
+		if len(args) != len(params) {
+			return Type{}, fmt.Errorf("takes %d argument(s), got %d", len(params), len(args))
+		}
+		for i, want := range params {
+			got := args[i].Kind
+			// Integers are accepted wherever floats are expected, and values
+			// only known at run time are checked again when called.
+			if got != want && got != KindAny && !(want == KindFloat && (got == KindInt || got == KindBig)) {
+				return Type{}, fmt.Errorf("argument %d must be %s, got %s", i+1, want, args[i])
+			}
+		}
+		return Type{Kind: result}, nil
+	}
+}
+
+// realArg makes a checker for conversions that take one non-complex number.
+func realArg(result Kind) func([]Type) (Type, error) {
+	return func(args []Type) (Type, error) {
+		if len(args) != 1 || !(args[0].Kind == KindAny || args[0].numeric() && args[0].Kind != KindComplex) {
+			return Type{}, errors.New("takes one real number")
+		}
+		return Type{Kind: result}, nil
+	}
+}
+
+// builtins is the complete list of functions an expression may call.
+// Nothing else in the program is reachable — that is the sandbox.
+var builtins = map[string]builtin{
+	"len": {wantArgs(KindInt, KindString), func(a []any) (any, error) {
+		return int64(utf8.RuneCountInString(a[0].(string))), nil
+	}},
+	"upper": {wantArgs(KindString, KindString), func(a []any) (any, error) { return strings.ToUpper(a[0].(string)), nil }},
+	"lower": {wantArgs(KindString, KindString), func(a []any) (any, error) { return strings.ToLower(a[0].(string)), nil }},
+	"trim":  {wantArgs(KindString, KindString), func(a []any) (any, error) { return strings.TrimSpace(a[0].(string)), nil }},
+	"startsWith": {wantArgs(KindBool, KindString, KindString), func(a []any) (any, error) {
+		return strings.HasPrefix(a[0].(string), a[1].(string)), nil
+	}},
+	"endsWith": {wantArgs(KindBool, KindString, KindString), func(a []any) (any, error) {
+		return strings.HasSuffix(a[0].(string), a[1].(string)), nil
+	}},
+	"contains": {wantArgs(KindBool, KindString, KindString), func(a []any) (any, error) {
+		return strings.Contains(a[0].(string), a[1].(string)), nil
+	}},
+	"substr": {wantArgs(KindString, KindString, KindInt, KindInt), func(a []any) (any, error) {
+		runes := []rune(a[0].(string)) // index by characters, not bytes
+		from, to := a[1].(int64), a[2].(int64)
+		if from < 0 || to < from || to > int64(len(runes)) {
+			return nil, fmt.Errorf("substr range [%d:%d] out of bounds for length %d", from, to, len(runes))
+		}
+		return string(runes[from:to]), nil
+	}},
+	"complex": {wantArgs(KindComplex, KindFloat, KindFloat), func(a []any) (any, error) {
+		return complex(toFloat(a[0]), toFloat(a[1])), nil
+	}},
+	"real":  {wantArgs(KindFloat, KindComplex), func(a []any) (any, error) { return real(a[0].(complex128)), nil }},
+	"imag":  {wantArgs(KindFloat, KindComplex), func(a []any) (any, error) { return imag(a[0].(complex128)), nil }},
+	"round": {wantArgs(KindFloat, KindFloat), func(a []any) (any, error) { return math.Round(toFloat(a[0])), nil }},
+	"floor": {wantArgs(KindFloat, KindFloat), func(a []any) (any, error) { return math.Floor(toFloat(a[0])), nil }},
+	"ceil":  {wantArgs(KindFloat, KindFloat), func(a []any) (any, error) { return math.Ceil(toFloat(a[0])), nil }},
+	"ln": {wantArgs(KindFloat, KindFloat), func(a []any) (any, error) {
+		f := toFloat(a[0])
+		if !(f > 0) || math.IsInf(f, 1) {
+			return nil, fmt.Errorf("only defined for finite positive numbers, got %v", f)
+		}
+		return math.Log(f), nil
+	}},
+	"sqrt": {
+		func(args []Type) (Type, error) {
+			if len(args) != 1 || !(args[0].numeric() || args[0].Kind == KindAny) {
+				return Type{}, errors.New("takes one number")
+			}
+			if args[0].Kind == KindComplex || args[0].Kind == KindAny {
+				return args[0], nil
+			}
+			return Type{Kind: KindFloat}, nil
+		},
+		func(a []any) (any, error) {
+			if c, ok := a[0].(complex128); ok {
+				return cmplx.Sqrt(c), nil
+			}
+			f := toFloat(a[0])
+			if f < 0 {
+				// NaN in Go; here an error that shows the way to the answer.
+				return nil, fmt.Errorf("negative number %v (use sqrt(complex(%v, 0)) for %vi)", f, f, math.Sqrt(-f))
+			}
+			if math.IsInf(f, 1) {
+				return nil, errors.New("float overflow")
+			}
+			return math.Sqrt(f), nil
+		},
+	},
+	"int": {realArg(KindInt), func(a []any) (any, error) {
+		if b, ok := a[0].(*big.Int); ok {
+			if !b.IsInt64() {
+				return nil, fmt.Errorf("%v does not fit in an int", b)
+			}
+			return b.Int64(), nil
+		}
+		if i, ok := a[0].(int64); ok {
+			return i, nil
+		}
+		f := toFloat(a[0])
+		if math.IsNaN(f) || f >= math.MaxInt64 || f < math.MinInt64 {
+			return nil, fmt.Errorf("%v does not fit in an int", f)
+		}
+		return int64(f), nil
+	}},
+	"big": {realArg(KindBig), func(a []any) (any, error) {
+		switch v := a[0].(type) {
+		case int64, *big.Int:
+			return toBig(v), nil
+		}
+		f := toFloat(a[0])
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return nil, fmt.Errorf("%v is not a finite number", f)
+		}
+		b, _ := big.NewFloat(f).Int(nil)
+		return b, nil
+	}},
+	"float": {realArg(KindFloat), func(a []any) (any, error) {
+		f := toFloat(a[0])
+		if math.IsInf(f, 0) {
+			return nil, errors.New("float overflow")
+		}
+		return f, nil
+	}},
+	"abs": {
+		func(args []Type) (Type, error) {
+			if len(args) != 1 || !(args[0].numeric() || args[0].Kind == KindAny) {
+				return Type{}, errors.New("takes one number")
+			}
+			if args[0].Kind == KindComplex {
+				return Type{Kind: KindFloat}, nil // |3+4i| = 5
+			}
+			return args[0], nil
+		},
+		func(a []any) (any, error) {
+			switch v := a[0].(type) {
+			case int64:
+				if v == math.MinInt64 {
+					return nil, errors.New("integer overflow in abs")
+				}
+				if v < 0 {
+					return -v, nil
+				}
+				return v, nil
+			case *big.Int:
+				return new(big.Int).Abs(v), nil
+			case float64:
+				return math.Abs(v), nil
+			}
+			return cmplx.Abs(a[0].(complex128)), nil
+		},
+	},
+}
+
+type checker struct {
+	src   string
+	vars  map[string]reflect.Type // Go values passed to Compile
+	scope *typeScope              // calculator variables (CompileScript)
+}
+
+// typeScope is the checker's view of a Scope: variable names and types.
+type typeScope struct {
+	vars   map[string]Type
+	parent *typeScope
+	frozen bool // constants such as pi
+	fn     bool // the parameters of a function: its body starts here
+}
+
+func (c *checker) errorf(n Node, format string, args ...any) *Error {
+	return &Error{Pos: n.Position(), Msg: fmt.Sprintf(format, args...), Src: c.src}
+}
+
+// lookup finds a calculator variable. A variable from outside the function
+// being checked is typed any: by the time the function runs, a later line
+// may have given it a value of another type.
+func (c *checker) lookup(name string) (t Type, frozen, ok bool) {
+	crossed := false
+	for s := c.scope; s != nil; s = s.parent {
+		if t, ok := s.vars[name]; ok {
+			if crossed && !s.frozen {
+				t = Type{Kind: KindAny}
+			}
+			return t, s.frozen, true
+		}
+		if s.fn {
+			crossed = true
+		}
+	}
+	return Type{}, false, false
+}
+
+func (c *checker) push(fn bool) {
+	c.scope = &typeScope{vars: map[string]Type{}, parent: c.scope, fn: fn}
+}
+
+func (c *checker) pop() { c.scope = c.scope.parent }
+
+// promote returns the wider of two numeric kinds: int < big < float < complex.
+func promote(a, b Kind) Kind { return max(a, b) }
+
+// assignable reports whether a value of type v may be stored in a variable
+// of type t. Numbers may widen (an int into a float variable), never narrow.
+func assignable(v, t Type) bool {
+	return t.Kind == KindAny || v.Kind == KindAny || v == t || (v.numeric() && t.numeric() && v.Kind < t.Kind)
+}
+
+// check works out (and stores) the type of every node.
+func (c *checker) check(n Node) (Type, error) {
+	switch n := n.(type) {
+	case *Literal:
+		return n.typ, nil
+
+	case *Ident:
+		if t, _, ok := c.lookup(n.Name); ok {
+			n.typ, n.local = t, true
+			return t, nil
+		}
+		t, ok := c.vars[n.Name]
+		if !ok {
+			return Type{}, c.errorf(n, "unknown name %q", n.Name)
+		}
+		n.typ = typeOf(t)
+		if n.typ.Kind == KindInvalid {
+			return Type{}, c.errorf(n, "%s has unsupported type %s", n.Name, t)
+		}
+		return n.typ, nil
+
+	case *Field:
+		xt, err := c.check(n.X)
+		if err != nil {
+			return Type{}, err
+		}
+		if xt.Kind != KindObject {
+			return Type{}, c.errorf(n, "cannot read field %q of %s", n.Name, xt)
+		}
+		var ft reflect.Type
+		if xt.Go.Kind() == reflect.Map {
+			ft = xt.Go.Elem()
+		} else {
+			// Case-insensitive, so `order.amount` finds both `amount` and `Amount`.
+			sf, ok := xt.Go.FieldByNameFunc(func(name string) bool { return strings.EqualFold(name, n.Name) })
+			if !ok {
+				return Type{}, c.errorf(n, "%s has no field %q", xt, n.Name)
+			}
+			ft, n.index = sf.Type, sf.Index
+		}
+		n.typ = typeOf(ft)
+		if n.typ.Kind == KindInvalid {
+			return Type{}, c.errorf(n, "field %q has unsupported type %s", n.Name, ft)
+		}
+		return n.typ, nil
+
+	case *Unary:
+		xt, err := c.check(n.X)
+		if err != nil {
+			return Type{}, err
+		}
+		t, ok := unaryType(n.Op, xt)
+		if !ok {
+			return Type{}, c.errorf(n, "operator %s not defined on %s", n.Op, xt)
+		}
+		n.typ = t
+		return t, nil
+
+	case *Binary:
+		xt, err := c.check(n.X)
+		if err != nil {
+			return Type{}, err
+		}
+		yt, err := c.check(n.Y)
+		if err != nil {
+			return Type{}, err
+		}
+		t, ok := binaryType(n.Op, xt, yt)
+		if !ok {
+			return Type{}, c.errorf(n, "operator %s not defined on %s and %s", n.Op, xt, yt)
+		}
+		n.typ = t
+		return t, nil
+
+	case *Ternary:
+		ct, err := c.check(n.Cond)
+		if err != nil {
+			return Type{}, err
+		}
+		if ct.Kind != KindBool && ct.Kind != KindAny {
+			return Type{}, c.errorf(n, "condition is %s, not bool", ct)
+		}
+		tt, err := c.check(n.Then)
+		if err != nil {
+			return Type{}, err
+		}
+		et, err := c.check(n.Else)
+		if err != nil {
+			return Type{}, err
+		}
+		switch {
+		case tt.Kind == KindAny || et.Kind == KindAny:
+			n.typ = Type{Kind: KindAny}
+		case tt == et:
+			n.typ = tt
+		case tt.numeric() && et.numeric():
+			n.typ = Type{Kind: promote(tt.Kind, et.Kind)}
+		default:
+			return Type{}, c.errorf(n, "branches have different types %s and %s", tt, et)
+		}
+		return n.typ, nil
+
+	case *Call:
+		if id, ok := n.Fn.(*Ident); ok {
+			if _, _, local := c.lookup(id.Name); !local {
+				fn, ok := builtins[id.Name]
+				if !ok {
+					return Type{}, c.errorf(n, "unknown function %q", id.Name)
+				}
+				n.Name = id.Name
+				args, err := c.checkAll(n.Args)
+				if err != nil {
+					return Type{}, err
+				}
+				t, err := fn.check(args)
+				if err != nil {
+					return Type{}, c.errorf(n, "%s: %v", n.Name, err)
+				}
+				n.typ = t
+				return t, nil
+			}
+		}
+		ft, err := c.check(n.Fn)
+		if err != nil {
+			return Type{}, err
+		}
+		if ft.Kind != KindFunc && ft.Kind != KindAny {
+			return Type{}, c.errorf(n, "cannot call %s", ft)
+		}
+		if _, err := c.checkAll(n.Args); err != nil {
+			return Type{}, err
+		}
+		n.typ = Type{Kind: KindAny} // a function's result is only known when it runs
+		return n.typ, nil
+
+	case *Assign:
+		if _, ok := c.scope.vars[n.Name]; ok && n.Declare {
+			// As in Go: := needs a new name; = updates an existing one.
+			return Type{}, c.errorf(n, "%s is already declared in this scope (use = to assign)", n.Name)
+		}
+		target, frozen, found := c.lookup(n.Name)
+		if !n.Declare && frozen {
+			return Type{}, c.errorf(n, "cannot assign to constant %s", n.Name)
+		}
+		if n.Declare || !found {
+			// = on a new name declares it, like :=.
+			n.Declare = true
+			if _, ok := n.X.(*FnLit); ok {
+				// Declared before the body is checked, so a function can call itself.
+				c.scope.vars[n.Name] = Type{Kind: KindFunc}
+			}
+			t, err := c.check(n.X)
+			if err != nil {
+				return Type{}, err
+			}
+			c.scope.vars[n.Name] = t
+			n.typ = t
+			return t, nil
+		}
+		t, err := c.check(n.X)
+		if err != nil {
+			return Type{}, err
+		}
+		if !assignable(t, target) {
+			return Type{}, c.errorf(n, "cannot assign %s to %s (%s)", t, n.Name, target)
+		}
+		n.want, n.typ = target, target
+		if target.Kind == KindAny {
+			n.typ = t
+		}
+		return n.typ, nil
+
+	case *Block:
+		if !n.top {
+			c.push(false)
+			defer c.pop()
+		}
+		for _, x := range n.Exprs {
+			t, err := c.check(x)
+			if err != nil {
+				return Type{}, err
+			}
+			n.typ = t
+		}
+		return n.typ, nil
+
+	case *FnLit:
+		c.push(true)
+		defer c.pop()
+		for _, p := range n.Params {
+			if _, dup := c.scope.vars[p]; dup {
+				return Type{}, c.errorf(n, "duplicate parameter %s", p)
+			}
+			c.scope.vars[p] = Type{Kind: KindAny}
+		}
+		if _, err := c.check(n.Body); err != nil {
+			return Type{}, err
+		}
+		n.typ = Type{Kind: KindFunc}
+		return n.typ, nil
+	}
+	return Type{}, fmt.Errorf("unknown node %T", n)
+}
+
+func (c *checker) checkAll(nodes []Node) ([]Type, error) {
+	types := make([]Type, len(nodes))
+	for i, a := range nodes {
+		t, err := c.check(a)
+		if err != nil {
+			return nil, err
+		}
+		types[i] = t
+	}
+	return types, nil
+}
+
+// ----------------------------------------------------------
+// 4. EVALUATOR
+// ----------------------------------------------------------
+
+// DefaultMaxSteps bounds the work one evaluation may do.
+const DefaultMaxSteps = 10_000
+
+// maxCallDepth bounds calculator function calls, so fn(n) => f(n + 1)
+// reports an error instead of exhausting the stack.
+const maxCallDepth = 500
+
+// maxBigBits caps big integers (about 315 000 decimal digits), so one
+// expression cannot use all the memory or run for minutes.
+const maxBigBits = 1 << 20
+
+// Program is a parsed and type-checked expression, ready to run many times.
+type Program struct {
+	Src      string
+	Root     Node
+	vars     map[string]reflect.Type
+	MaxSteps int
+	script   bool // built by CompileScript: types are checked again at run time
+}
+
+// Compile parses and type-checks src. vars gives the type of every name the
+// expression may use; TypesOf builds it from example values.
+func Compile(src string, vars map[string]reflect.Type) (*Program, error) {
+	root, err := Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	c := &checker{src: src, vars: vars}
+	if _, err := c.check(root); err != nil {
+		return nil, err
+	}
+	return &Program{Src: src, Root: root, vars: vars, MaxSteps: DefaultMaxSteps}, nil
+}
+
+// CompileScript is Compile for the calculator: src may also declare and
+// assign variables, define functions and hold several expressions
+// separated by semicolons. Names are looked up in globals, which Run updates.
+func CompileScript(src string, globals *Scope) (*Program, error) {
+	root, err := parse(src, true)
+	if err != nil {
+		return nil, err
+	}
+	c := &checker{src: src, scope: globals.types()}
+	if _, err := c.check(root); err != nil {
+		return nil, err
+	}
+	return &Program{Src: src, Root: root, MaxSteps: DefaultMaxSteps, script: true}, nil
+}
+
+// TypesOf returns the types of the values in env, for Compile.
+func TypesOf(env map[string]any) map[string]reflect.Type {
+	types := make(map[string]reflect.Type, len(env))
+	for name, v := range env {
+		types[name] = reflect.TypeOf(v)
+	}
+	return types
+}
+
+// ResultType is the static type of the whole expression.
+func (p *Program) ResultType() Type { return p.Root.Type() }
+
+// Scope holds calculator variables. Closures keep a pointer to the Scope
+// they were created in, so its variables live on after the function that
+// created them returns — the counter() pattern from 14-closures.
+type Scope struct {
+	vars   map[string]any
+	parent *Scope
+	frozen bool // constants such as pi cannot be assigned to
+}
+
+// NewScope returns an empty scope inside parent (nil for the outermost).
+func NewScope(parent *Scope) *Scope {
+	return &Scope{vars: map[string]any{}, parent: parent}
+}
+
+// Get finds name in s or its parents.
+func (s *Scope) Get(name string) (any, bool) {
+	for ; s != nil; s = s.parent {
+		if v, ok := s.vars[name]; ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// Set declares (or replaces) name in s itself.
+func (s *Scope) Set(name string, v any) { s.vars[name] = v }
+
+// Names returns the variables declared in s itself, sorted.
+func (s *Scope) Names() []string {
+	names := make([]string, 0, len(s.vars))
+	for name := range s.vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// assign updates the nearest existing name, or declares it in s.
+func (s *Scope) assign(name string, v any) {
+	for cur := s; cur != nil; cur = cur.parent {
+		if _, ok := cur.vars[name]; ok {
+			cur.vars[name] = v
+			return
+		}
+	}
+	s.vars[name] = v
+}
+
+// types is the checker's view of s: each variable with its value's type.
+func (s *Scope) types() *typeScope {
+	if s == nil {
+		return nil
+	}
+	ts := &typeScope{vars: make(map[string]Type, len(s.vars)), parent: s.parent.types(), frozen: s.frozen}
+	for name, v := range s.vars {
+		ts.vars[name] = typeOfValue(v)
+	}
+	return ts
+}
+
+// Closure is a calculator function value together with the scope it was
+// created in — exactly what Go builds for func literals in 14-closures.
+type Closure struct {
+	Params []string
+	Body   Node
+	Env    *Scope
+	Src    string // the line that defined it, for error messages
+}
+
+func (f *Closure) String() string { return "fn(" + strings.Join(f.Params, ", ") + ")" }
+
+type evaluator struct {
+	p     *Program
+	src   string // the source of the code running now: a closure's own line
+	env   map[string]any
+	scope *Scope
+	steps int
+	depth int // calculator function calls in progress
+}
+
+func (e *evaluator) errorf(n Node, format string, args ...any) *Error {
+	return &Error{Pos: n.Position(), Msg: fmt.Sprintf(format, args...), Src: e.src}
+}
+
+// Eval runs the program against env. env must match the types used in Compile.
+func (p *Program) Eval(env map[string]any) (any, error) {
+	for name, t := range p.vars {
+		if v, ok := env[name]; !ok || reflect.TypeOf(v) != t {
+			return nil, fmt.Errorf("variable %q missing or not a %s", name, t)
+		}
+	}
+	e := &evaluator{p: p, src: p.Src, env: env}
+	return e.eval(p.Root)
+}
+
+// Run runs a CompileScript program. Top-level declarations land in globals.
+func (p *Program) Run(globals *Scope) (any, error) {
+	e := &evaluator{p: p, src: p.Src, scope: globals}
+	return e.eval(p.Root)
+}
+
+func (e *evaluator) eval(n Node) (any, error) {
+	e.steps++
+	if e.steps > e.p.MaxSteps {
+		return nil, e.errorf(n, "evaluation step limit (%d) exceeded", e.p.MaxSteps)
+	}
+
+	switch n := n.(type) {
+	case *Literal:
+		return n.Value, nil
+
+	case *Ident:
+		if n.local {
+			v, ok := e.scope.Get(n.Name)
+			if !ok {
+				return nil, e.errorf(n, "unknown name %q", n.Name)
+			}
+			return v, nil
+		}
+		return primitive(reflect.ValueOf(e.env[n.Name]), n.typ), nil
+
+	case *Field:
+		x, err := e.eval(n.X)
+		if err != nil {
+			return nil, err
+		}
+		v := x.(reflect.Value)
+		for v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return nil, e.errorf(n, "cannot read %q of nil", n.Name)
+			}
+			v = v.Elem()
+		}
+		if v.Kind() == reflect.Map {
+			f := v.MapIndex(reflect.ValueOf(n.Name).Convert(v.Type().Key()))
+			if !f.IsValid() {
+				return nil, e.errorf(n, "key %q not found", n.Name)
+			}
+			return primitive(f, n.typ), nil
+		}
+		f, err := v.FieldByIndexErr(n.index)
+		if err != nil {
+			return nil, e.errorf(n, "cannot read %q: %v", n.Name, err)
+		}
+		return primitive(f, n.typ), nil
+
+	case *Unary:
+		x, err := e.eval(n.X)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := unaryType(n.Op, typeOfValue(x)); e.p.script && !ok {
+			return nil, e.errorf(n, "operator %s not defined on %s", n.Op, typeOfValue(x))
+		}
+		switch v := x.(type) {
+		case bool:
+			return !v, nil
+		case int64:
+			if n.Op == "-" {
+				if v == math.MinInt64 {
+					return nil, e.errorf(n, "integer overflow")
+				}
+				return -v, nil
+			}
+			return v, nil
+		case *big.Int:
+			if n.Op == "-" {
+				return new(big.Int).Neg(v), nil
+			}
+			return v, nil
+		case float64:
+			if n.Op == "-" {
+				return -v, nil
+			}
+			return v, nil
+		case complex128:
+			if n.Op == "-" {
+				return -v, nil
+			}
+			return v, nil
+		}
+
+	case *Binary:
+		return e.binary(n)
+
+	case *Ternary:
+		c, err := e.eval(n.Cond)
+		if err != nil {
+			return nil, err
+		}
+		b, ok := c.(bool)
+		if !ok {
+			return nil, e.errorf(n, "condition is %s, not bool", typeOfValue(c))
+		}
+		branch := n.Else
+		if b {
+			branch = n.Then
+		}
+		v, err := e.eval(branch)
+		if err != nil {
+			return nil, err
+		}
+		// int ? 1 : 2.5 is a float either way.
+		if k := kindOf(v); n.typ.numeric() && k >= KindInt && k < n.typ.Kind {
+			v = convert(v, n.typ.Kind)
+		}
+		return v, nil
+
+	case *Call:
+		return e.call(n)
+
+	case *Assign:
+		v, err := e.eval(n.X)
+		if err != nil {
+			return nil, err
+		}
+		if n.Declare {
+			e.scope.Set(n.Name, v)
+			return v, nil
+		}
+		if got := typeOfValue(v); n.want.Kind != KindAny && got != n.want {
+			if !assignable(got, n.want) {
+				return nil, e.errorf(n, "cannot assign %s to %s (%s)", got, n.Name, n.want)
+			}
+			v = convert(v, n.want.Kind)
+		}
+		e.scope.assign(n.Name, v)
+		return v, nil
+
+	case *Block:
+		if !n.top {
+			// Braces open a new scope, like in Go.
+			saved := e.scope
+			e.scope = NewScope(saved)
+			defer func() { e.scope = saved }()
+		}
+		var last any
+		for _, x := range n.Exprs {
+			v, err := e.eval(x)
+			if err != nil {
+				return nil, err
+			}
+			last = v
+		}
+		return last, nil
+
+	case *FnLit:
+		return &Closure{Params: n.Params, Body: n.Body, Env: e.scope, Src: e.src}, nil
+	}
+	return nil, e.errorf(n, "cannot evaluate %T", n)
+}
+
+func (e *evaluator) call(n *Call) (any, error) {
+	var fn any
+	if n.Name == "" {
+		var err error
+		if fn, err = e.eval(n.Fn); err != nil {
+			return nil, err
+		}
+	}
+	args := make([]any, len(n.Args))
+	for i, a := range n.Args {
+		v, err := e.eval(a)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+
+	if n.Name != "" {
+		b := builtins[n.Name]
+		if e.p.script {
+			types := make([]Type, len(args))
+			for i, a := range args {
+				types[i] = typeOfValue(a)
+			}
+			if _, err := b.check(types); err != nil {
+				return nil, e.errorf(n, "%s: %v", n.Name, err)
+			}
+		}
+		v, err := b.call(args)
+		if err != nil {
+			return nil, e.errorf(n, "%s: %v", n.Name, err)
+		}
+		return v, nil
+	}
+
+	f, ok := fn.(*Closure)
+	if !ok {
+		return nil, e.errorf(n, "cannot call %s", typeOfValue(fn))
+	}
+	if len(args) != len(f.Params) {
+		return nil, e.errorf(n, "function takes %d argument(s), got %d", len(f.Params), len(args))
+	}
+	e.depth++
+	defer func() { e.depth-- }()
+	if e.depth > maxCallDepth {
+		return nil, e.errorf(n, "maximum call depth (%d) exceeded", maxCallDepth)
+	}
+	// A new scope whose parent is the scope the function was CREATED in
+	// (not called from): that is what makes it a closure.
+	local := NewScope(f.Env)
+	for i, p := range f.Params {
+		local.vars[p] = args[i]
+	}
+	saved, savedSrc := e.scope, e.src
+	e.scope, e.src = local, f.Src
+	defer func() { e.scope, e.src = saved, savedSrc }()
+	return e.eval(f.Body)
+}
+
+// primitive converts a reflected value to our runtime representation.
+// Objects stay as reflect.Value so fields can be read from them later.
+// v.Int(), v.String() etc. work even on unexported fields.
+func primitive(v reflect.Value, t Type) any {
+	for v.Kind() == reflect.Pointer && t.Kind != KindObject && !v.IsNil() {
+		v = v.Elem()
+	}
+	switch t.Kind {
+	case KindBool:
+		return v.Bool()
+	case KindInt:
+		if v.CanUint() {
+			return int64(v.Uint())
+		}
+		return v.Int()
+	case KindFloat:
+		return v.Float()
+	case KindComplex:
+		return v.Complex()
+	case KindString:
+		return v.String()
+	}
+	return v
+}
+
+func toBig(v any) *big.Int {
+	if i, ok := v.(int64); ok {
+		return big.NewInt(i)
+	}
+	return v.(*big.Int)
+}
+
+func toFloat(v any) float64 {
+	switch n := v.(type) {
+	case int64:
+		return float64(n)
+	case *big.Int:
+		f, _ := new(big.Float).SetInt(n).Float64()
+		return f
+	case float64:
+		return n
+	}
+	return math.NaN()
+}
+
+func toComplex(v any) complex128 {
+	if c, ok := v.(complex128); ok {
+		return c
+	}
+	return complex(toFloat(v), 0)
+}
+
+// convert widens a number to kind k.
+func convert(v any, k Kind) any {
+	switch k {
+	case KindBig:
+		return toBig(v)
+	case KindFloat:
+		return toFloat(v)
+	case KindComplex:
+		return toComplex(v)
+	}
+	return v
+}
+
+// recheck applies the typing rule to the actual values. In scripts the
+// static types are only a first filter: variables can change type.
+func (e *evaluator) recheck(n *Binary, x, y any) error {
+	if !e.p.script {
+		return nil
+	}
+	if _, ok := binaryType(n.Op, typeOfValue(x), typeOfValue(y)); !ok {
+		return e.errorf(n, "operator %s not defined on %s and %s", n.Op, typeOfValue(x), typeOfValue(y))
+	}
+	return nil
+}
+
+func (e *evaluator) binary(n *Binary) (any, error) {
+	x, err := e.eval(n.X)
+	if err != nil {
+		return nil, err
+	}
+	// && and || short-circuit: the right side is not evaluated if not needed.
+	if n.Op == "&&" || n.Op == "||" {
+		if err := e.recheck(n, x, true); err != nil {
+			return nil, err
+		}
+		if x.(bool) == (n.Op == "||") {
+			return x, nil
+		}
+		y, err := e.eval(n.Y)
+		if err != nil {
+			return nil, err
+		}
+		return y, e.recheck(n, x, y)
+	}
+	y, err := e.eval(n.Y)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.recheck(n, x, y); err != nil {
+		return nil, err
+	}
+
+	switch n.Op {
+	case "startsWith":
+		return strings.HasPrefix(x.(string), y.(string)), nil
+	case "endsWith":
+		return strings.HasSuffix(x.(string), y.(string)), nil
+	case "contains":
+		return strings.Contains(x.(string), y.(string)), nil
+	}
+
+	if xs, ok := x.(string); ok {
+		ys := y.(string)
+		switch n.Op {
+		case "+":
+			return xs + ys, nil
+		case "==":
+			return xs == ys, nil
+		case "!=":
+			return xs != ys, nil
+		case "<":
+			return xs < ys, nil
+		case "<=":
+			return xs <= ys, nil
+		case ">":
+			return xs > ys, nil
+		}
+		return xs >= ys, nil
+	}
+	if xb, ok := x.(bool); ok {
+		if n.Op == "==" {
+			return xb == y.(bool), nil
+		}
+		return xb != y.(bool), nil
+	}
+
+	// Numbers: compute in the widest kind of the two operands.
+	switch promote(kindOf(x), kindOf(y)) {
+	case KindInt:
+		return e.intOp(n, x.(int64), y.(int64))
+	case KindBig:
+		return e.bigOp(n, toBig(x), toBig(y))
+	case KindFloat:
+		return e.floatOp(n, toFloat(x), toFloat(y))
+	}
+	return e.complexOp(n, toComplex(x), toComplex(y))
+}
+
+// mulInt multiplies two ints, reporting false on overflow.
+func mulInt(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	p := a * b
+	if p/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, false
+	}
+	return p, true
+}
+
+func (e *evaluator) intOp(n *Binary, a, b int64) (any, error) {
+	switch n.Op {
+	case "+":
+		s := a + b
+		if (a > 0 && b > 0 && s < 0) || (a < 0 && b < 0 && s >= 0) {
+			return nil, e.errorf(n, "integer overflow")
+		}
+		return s, nil
+	case "-":
+		d := a - b
+		if (a >= 0 && b < 0 && d < 0) || (a < 0 && b > 0 && d >= 0) {
+			return nil, e.errorf(n, "integer overflow")
+		}
+		return d, nil
+	case "*":
+		p, ok := mulInt(a, b)
+		if !ok {
+			return nil, e.errorf(n, "integer overflow")
+		}
+		return p, nil
+	case "**":
+		if b < 0 {
+			return nil, e.errorf(n, "negative exponent on an integer (use a float, like 2.0 ** -1)")
+		}
+		// Exponentiation by squaring, checking every multiplication.
+		result, ok := int64(1), true
+		for ; b > 0; b >>= 1 {
+			if b&1 == 1 {
+				if result, ok = mulInt(result, a); !ok {
+					return nil, e.errorf(n, "integer overflow (use big(x) for arbitrary precision)")
+				}
+			}
+			if b > 1 {
+				if a, ok = mulInt(a, a); !ok {
+					return nil, e.errorf(n, "integer overflow (use big(x) for arbitrary precision)")
+				}
+			}
+		}
+		return result, nil
+	case "/", "%":
+		if b == 0 {
+			return nil, e.errorf(n, "division by zero")
+		}
+		if a == math.MinInt64 && b == -1 {
+			return nil, e.errorf(n, "integer overflow")
+		}
+		if n.Op == "/" {
+			return a / b, nil
+		}
+		return a % b, nil
+	case "==":
+		return a == b, nil
+	case "!=":
+		return a != b, nil
+	case "<":
+		return a < b, nil
+	case "<=":
+		return a <= b, nil
+	case ">":
+		return a > b, nil
+	}
+	return a >= b, nil
+}
+
+func (e *evaluator) bigOp(n *Binary, a, b *big.Int) (any, error) {
+	r := new(big.Int)
+	switch n.Op {
+	case "+":
+		r.Add(a, b)
+	case "-":
+		r.Sub(a, b)
+	case "*":
+		r.Mul(a, b)
+	case "/", "%":
+		if b.Sign() == 0 {
+			return nil, e.errorf(n, "division by zero")
+		}
+		if n.Op == "/" {
+			r.Quo(a, b) // truncated, like int division
+		} else {
+			r.Rem(a, b)
+		}
+	case "**":
+		if b.Sign() < 0 {
+			return nil, e.errorf(n, "negative exponent on an integer (use a float, like 2.0 ** -1)")
+		}
+		var ok bool
+		if r, ok = bigPow(a, b); !ok {
+			return nil, e.errorf(n, "result too large (limit %d bits)", maxBigBits)
+		}
+	default:
+		c := a.Cmp(b)
+		switch n.Op {
+		case "==":
+			return c == 0, nil
+		case "!=":
+			return c != 0, nil
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		}
+		return c >= 0, nil
+	}
+	if r.BitLen() > maxBigBits {
+		return nil, e.errorf(n, "result too large (limit %d bits)", maxBigBits)
+	}
+	return r, nil
+}
+
+// bigPow returns a ** b (b ≥ 0), or false if the result would be larger
+// than maxBigBits. The size is checked BEFORE the work: |a| ≤ 1 is trivial,
+// otherwise the result has about a.BitLen() * b bits. The comparison
+// divides instead of multiplying, so it cannot overflow itself.
+func bigPow(a, b *big.Int) (*big.Int, bool) {
+	switch {
+	case b.Sign() == 0:
+		return big.NewInt(1), true
+	case a.Sign() == 0:
+		return big.NewInt(0), true
+	case a.IsInt64() && a.Int64() == 1:
+		return big.NewInt(1), true
+	case a.IsInt64() && a.Int64() == -1:
+		return big.NewInt(1 - 2*int64(b.Bit(0))), true // 1 for even b, -1 for odd
+	}
+	if !b.IsInt64() || b.Int64() > maxBigBits/int64(a.BitLen()) {
+		return nil, false
+	}
+	return new(big.Int).Exp(a, b, nil), true
+}
+
+func (e *evaluator) floatOp(n *Binary, a, b float64) (any, error) {
+	var r float64
+	switch n.Op {
+	case "+":
+		r = a + b
+	case "-":
+		r = a - b
+	case "*":
+		r = a * b
+	case "**":
+		r = math.Pow(a, b)
+	case "/":
+		if b == 0 {
+			return nil, e.errorf(n, "division by zero")
+		}
+		r = a / b
+	case "==":
+		return a == b, nil
+	case "!=":
+		return a != b, nil
+	case "<":
+		return a < b, nil
+	case "<=":
+		return a <= b, nil
+	case ">":
+		return a > b, nil
+	case ">=":
+		return a >= b, nil
+	}
+	if math.IsInf(r, 0) {
+		return nil, e.errorf(n, "float overflow")
+	}
+	if math.IsNaN(r) {
+		return nil, e.errorf(n, "result is not a number (NaN)")
+	}
+	return r, nil
+}
+
+func (e *evaluator) complexOp(n *Binary, a, b complex128) (any, error) {
+	var r complex128
+	switch n.Op {
+	case "+":
+		r = a + b
+	case "-":
+		r = a - b
+	case "*":
+		r = a * b
+	case "**":
+		r = cmplx.Pow(a, b)
+	case "/":
+		if b == 0 {
+			return nil, e.errorf(n, "division by zero")
+		}
+		r = a / b
+	case "==":
+		return a == b, nil
+	default:
+		return a != b, nil
+	}
+	if cmplx.IsInf(r) {
+		return nil, e.errorf(n, "complex overflow")
+	}
+	if cmplx.IsNaN(r) {
+		return nil, e.errorf(n, "result is not a number (NaN)")
+	}
+	return r, nil
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ----------------------------------------------------------
+// AN INTERACTIVE CALCULATOR (REPL)
+// ----------------------------------------------------------
+// 02-simple_values prints fixed arithmetic: 1 + 1, 10.5 + 5.5, 7.0 / 3.0,
+// complex(1.0, 2.0). The calculator lets you type such expressions
+// yourself. A REPL is a Read-Eval-Print Loop: read a line, evaluate it,
+// print the result, repeat.
+//
+//	> x := 10.5 + 5.5
+//	_1 = 16 (float)
+//	> x * 2i
+//	_2 = (0+32i) (complex)
+//
+// It runs on the expression language from ../expression_language, copied
+// into engine.go with three additions, all switched on by CompileScript:
+//   - Variables: x := 1 declares in the current scope, x = 2 updates the
+//     nearest existing x (or declares it if there is none).
+//   - Functions and closures: fn(a, b) => a + b is a value. Like counter()
+//     in 14-closures, a function remembers the variables around it.
+//   - Several expressions per line, and blocks: { a := 1; b := 2; a + b }.
+//
+// Every result is saved as _1, _2, ... (and _ is the last one), and
+// :history lists what you typed.
+//
+//	go run main.go engine.go
+//	go run main.go engine.go -demo
+//	echo "2 ** 10" | go run main.go engine.go
+// ----------------------------------------------------------
+
+// replMaxSteps is higher than DefaultMaxSteps: a calculator user may
+// well compute fact(300), but a loop that never ends must still stop.
+const replMaxSteps = 1_000_000
+
+// maxLine is the longest input line the REPL accepts.
+const maxLine = 1 << 20
+
+// REPL reads lines, evaluates them and keeps the history.
+type REPL struct {
+	globals *Scope
+	out     io.Writer
+	history []string
+	results int
+}
+
+// NewREPL creates a calculator whose output goes to out. The constants pi
+// and e live in a frozen scope around the globals, so pi = 3 is an error
+// but pi := 3 may shadow it, as a local variable would in Go.
+func NewREPL(out io.Writer) *REPL {
+	universe := NewScope(nil)
+	universe.frozen = true
+	universe.Set("pi", math.Pi)
+	universe.Set("e", math.E)
+	return &REPL{globals: NewScope(universe), out: out}
+}
+
+const help = `Expressions:  1 + 2 * 3    2 ** 100    big(2) ** 100    sqrt(2)    7.0 / 3
+Variables:    x := 5       x = x + 1   (results are saved as _1, _2, ... and _)
+Functions:    sq := fn(n) => n * n      add := fn(a) => fn(b) => a + b
+Blocks:       { a := 1; b := 2; a + b }     Ternary: n > 0 ? n : -n
+Builtins:     sqrt abs floor ceil round ln complex real imag big int float len upper lower, pi, e
+Commands:     :help  :vars  :history  :quit`
+
+// Run processes input until EOF or :quit. With echo, each input line is
+// printed after the prompt (useful when input is not a terminal).
+func (r *REPL) Run(in io.Reader, echo bool) error {
+	scanner := bufio.NewScanner(in)
+	// The default limit is 64KB; a longer line would end the session.
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLine)
+	for {
+		fmt.Fprint(r.out, "> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(r.out)
+			if err := scanner.Err(); err != nil {
+				return fmt.Errorf("reading input: %w", err)
+			}
+			return nil
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if echo {
+			fmt.Fprintln(r.out, line)
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !r.handle(line) {
+			return nil
+		}
+	}
+}
+
+// handle processes one line and reports whether to keep going.
+func (r *REPL) handle(line string) bool {
+	switch line {
+	case ":quit", ":q", ":exit":
+		return false
+	case ":help":
+		fmt.Fprintln(r.out, help)
+		return true
+	case ":history":
+		for i, h := range r.history {
+			fmt.Fprintf(r.out, "%3d  %s\n", i+1, h)
+		}
+		return true
+	case ":vars":
+		for _, name := range r.globals.Names() {
+			if !strings.HasPrefix(name, "_") {
+				v, _ := r.globals.Get(name)
+				fmt.Fprintf(r.out, "%s = %s (%s)\n", name, format(v), typeOfValue(v))
+			}
+		}
+		return true
+	}
+
+	r.history = append(r.history, line)
+	prog, err := CompileScript(line, r.globals)
+	var v any
+	if err == nil {
+		prog.MaxSteps = replMaxSteps
+		v, err = prog.Run(r.globals)
+	}
+	if err != nil {
+		var e *Error
+		switch {
+		case errors.As(err, &e) && e.Src == line:
+			// Point at the problem: 2 spaces for "> " plus the column.
+			fmt.Fprintf(r.out, "  %s^\nerror: %s\n", strings.Repeat(" ", e.Pos.Col-1), e.Msg)
+		case errors.As(err, &e):
+			// The error is inside a function defined on an earlier line.
+			fmt.Fprintln(r.out, "error in", e.Caret())
+		default:
+			fmt.Fprintln(r.out, "error:", err)
+		}
+		return true
+	}
+	if v == nil {
+		return true
+	}
+	r.results++
+	name := "_" + strconv.Itoa(r.results)
+	r.globals.Set(name, v)
+	r.globals.Set("_", v)
+	fmt.Fprintf(r.out, "%s = %s (%s)\n", name, format(v), typeOfValue(v))
+	return true
+}
+
+// format prints a value the way a calculator user expects.
+func format(v any) string {
+	switch v := v.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case complex128:
+		return strconv.FormatComplex(v, 'g', -1, 128)
+	case *big.Int:
+		return v.String()
+	}
+	return fmt.Sprint(v)
+}
+
+// replDemo is a scripted session that shows every feature.
+const replDemo = `# 02-simple_values, interactively
+1 + 1
+10.5 + 5.5
+7.0 / 3.0
+7 / 2
+1 + 2 * 3 ** 2
+-2 ** 2
+complex(1.0, 2.0) * 2
+sqrt(complex(-4, 0))
+sqrt(-4)
+# Big numbers
+2 ** 62
+2 ** 64
+big(2) ** 64
+12345678901234567890 * 10
+big(3) ** 4611686018427387904
+# Errors instead of Inf / NaN / wrap-around
+9223372036854775807 + 1
+1 / 0
+1.0 / 0
+1e308 * 10
+(1 + 2
+# Variables and history
+x := 5; y := x * 2 + 1
+_ * 2
+_1 + _2
+x = 2.5
+x := 1
+# Functions and closures
+square := fn(n) => n * n
+square(y)
+fact := fn(n) => n <= 1 ? big(1) : n * fact(n - 1)
+fact(30)
+counter := fn() => { count := 0; fn() => count = count + 1 }
+increment := counter()
+increment(); increment(); increment()
+other := counter()
+other()
+add := fn(a) => fn(b) => a + b
+add(2)(3)
+square("x")
+forever := fn(n) => forever(n + 1)
+forever(0)
+pi = 3
+:vars
+:history`
+
+// ----------------------------------------------------------
+// MAIN
+// ----------------------------------------------------------
+
+func main() {
+	demo := flag.Bool("demo", false, "run a scripted calculator session")
+	flag.Parse()
+
+	r := NewREPL(os.Stdout)
+	var err error
+	if *demo {
+		err = r.Run(strings.NewReader(replDemo), true)
+	} else {
+		fmt.Println("Calculator — type :help for help, :quit to exit")
+		err = r.Run(os.Stdin, false)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+// ----------------------------------------------------------
+// SUMMARY
+// ----------------------------------------------------------
+// 1. A REPL is a loop: read a line, compile it, run it, print the result.
+// 2. Variables live in a Scope; a closure keeps a pointer to the Scope it
+//    was created in, so counter() keeps counting between calls.
+// 3. int → big → float → complex: 2 ** 64 is an overflow error for int,
+//    but big(2) ** 64 is exact. Division by zero, Inf and NaN are errors.
+// 4. Errors point at the column that caused them; the session goes on.
+// ----------------------------------------------------------
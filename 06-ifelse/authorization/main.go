@@ -0,0 +1,526 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ----------------------------------------------------------
+// AUTHORIZATION WITHOUT HARD-CODED IF-ELSE
+// ----------------------------------------------------------
+// 06-ifelse decides access like this:
+//
+//   if role == "admin" && hasPermissions { ... }
+//
+// That works for one check, but real services need many: support staff
+// may refund small orders, managers any order, customers may only read
+// their OWN orders, and nobody may delete a delivered order. Writing all
+// of that as if-else chains scattered through the code is how bugs and
+// security holes appear.
+//
+// Instead we describe the rules as DATA (policy.json) and ask one function:
+//
+//   Authorize(subject, action, resource) → Decision
+//
+// The engine combines two classic models:
+//   - RBAC (role-based): roles hold permission sets, roles inherit roles.
+//   - ABAC (attribute-based): grants and denies with conditions on the
+//     resource and subject, e.g. "amount < 10000" or "owner == subject.id".
+//
+// Every Decision explains which rule matched, so "why was I denied?"
+// always has an answer.
+// ----------------------------------------------------------
+
+// ------------------------- PERMISSIONS AS BITFLAGS -------------------------
+
+// Permission is one action. Each permission is a single bit, so a whole
+// set of permissions fits in one integer (like file modes rwx in Unix).
+type Permission uint32
+
+const (
+	PermRead    Permission = 1 << iota // 1
+	PermCreate                         // 2
+	PermUpdate                         // 4
+	PermDelete                         // 8
+	PermRefund                         // 16
+	PermApprove                        // 32
+)
+
+// permissionNames maps the names used in policy files to bits.
+var permissionNames = map[string]Permission{
+	"read":    PermRead,
+	"create":  PermCreate,
+	"update":  PermUpdate,
+	"delete":  PermDelete,
+	"refund":  PermRefund,
+	"approve": PermApprove,
+}
+
+// PermissionSet is a combination of permissions (bitwise OR of bits).
+type PermissionSet uint32
+
+// Has checks a bit with AND: set & p is non-zero only if the bit is on.
+func (s PermissionSet) Has(p Permission) bool { return s&PermissionSet(p) != 0 }
+
+// With returns a copy of the set with p switched on (bitwise OR).
+func (s PermissionSet) With(p Permission) PermissionSet { return s | PermissionSet(p) }
+
+func (s PermissionSet) String() string {
+	var names []string
+	for name, p := range permissionNames {
+		if s.Has(p) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return "{" + strings.Join(names, ",") + "}"
+}
+
+// ------------------------- SUBJECTS AND RESOURCES -------------------------
+
+// Subject is who is asking (a user or a service).
+type Subject struct {
+	ID    string
+	Roles []string
+	Attrs map[string]any
+}
+
+// Resource is what is being accessed.
+type Resource struct {
+	Type  string // e.g. "order"
+	ID    string
+	Attrs map[string]any // e.g. amount, owner, status
+}
+
+// Decision is the answer, with an explanation.
+type Decision struct {
+	Allowed bool
+	Rule    string // the rule that decided, e.g. "role manager: order.refund"
+	Reason  string
+}
+
+func (d Decision) String() string {
+	verdict := "DENY "
+	if d.Allowed {
+		verdict = "ALLOW"
+	}
+	return fmt.Sprintf("%s %s (%s)", verdict, d.Rule, d.Reason)
+}
+
+// ------------------------- POLICY FILE FORMAT -------------------------
+
+// Condition compares one resource attribute with either a fixed value or
+// an attribute of the subject.
+type Condition struct {
+	Attr        string `json:"attr"`
+	Op          string `json:"op"` // eq, ne, lt, le, gt, ge, in
+	Value       any    `json:"value,omitempty"`
+	SubjectAttr string `json:"subject_attr,omitempty"`
+}
+
+// Rule is a resource-scoped grant (in a role) or a global deny.
+type Rule struct {
+	ID       string      `json:"id"`
+	Action   string      `json:"action"`
+	Resource string      `json:"resource"` // resource type, or "*" for any
+	When     []Condition `json:"when"`
+}
+
+// roleSpec is a role as written in the policy file.
+type roleSpec struct {
+	Inherits    []string            `json:"inherits"`
+	Permissions map[string][]string `json:"permissions"` // resource type → actions
+	Grants      []Rule              `json:"grants"`
+}
+
+type policyFile struct {
+	Roles map[string]roleSpec `json:"roles"`
+	Deny  []Rule              `json:"deny"`
+}
+
+// role is the compiled form: permission names turned into bitflags.
+type role struct {
+	name     string
+	inherits []string
+	perms    map[string]PermissionSet // resource type → set
+	grants   []Rule
+}
+
+// Policy is a validated, ready-to-use set of roles and deny rules.
+type Policy struct {
+	roles map[string]*role
+	deny  []Rule
+}
+
+// LoadPolicy reads and validates a policy file.
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file policyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	p, err := compile(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+var validOps = map[string]bool{"eq": true, "ne": true, "lt": true, "le": true, "gt": true, "ge": true, "in": true}
+
+// compile validates the file and converts it into a Policy. All problems
+// are reported together, not just the first one.
+func compile(file policyFile) (*Policy, error) {
+	p := &Policy{roles: map[string]*role{}, deny: file.Deny}
+	var errs []error
+
+	checkRule := func(where string, r Rule) {
+		if _, ok := permissionNames[r.Action]; !ok {
+			errs = append(errs, fmt.Errorf("%s: unknown action %q", where, r.Action))
+		}
+		if r.Resource == "" {
+			errs = append(errs, fmt.Errorf("%s: resource is required", where))
+		}
+		for _, c := range r.When {
+			if !validOps[c.Op] {
+				errs = append(errs, fmt.Errorf("%s: unknown operator %q", where, c.Op))
+			}
+			if (c.Value == nil) == (c.SubjectAttr == "") {
+				errs = append(errs, fmt.Errorf("%s: condition on %q needs exactly one of value or subject_attr", where, c.Attr))
+			}
+		}
+	}
+
+	for name, spec := range file.Roles {
+		r := &role{name: name, inherits: spec.Inherits, perms: map[string]PermissionSet{}, grants: spec.Grants}
+		for resource, actions := range spec.Permissions {
+			for _, a := range actions {
+				bit, ok := permissionNames[a]
+				if !ok {
+					errs = append(errs, fmt.Errorf("role %s: unknown permission %q", name, a))
+					continue
+				}
+				r.perms[resource] = r.perms[resource].With(bit)
+			}
+		}
+		for i, g := range spec.Grants {
+			if g.ID == "" {
+				r.grants[i].ID = fmt.Sprintf("%s-grant-%d", name, i+1)
+			}
+			checkRule("role "+name+" grant "+r.grants[i].ID, g)
+		}
+		p.roles[name] = r
+	}
+	for _, d := range file.Deny {
+		checkRule("deny "+d.ID, d)
+	}
+
+	// Inherited roles must exist, and inheritance must not loop.
+	for name, r := range p.roles {
+		for _, parent := range r.inherits {
+			if _, ok := p.roles[parent]; !ok {
+				errs = append(errs, fmt.Errorf("role %s: inherits unknown role %q", name, parent))
+			}
+		}
+	}
+	if len(errs) == 0 {
+		for name := range p.roles {
+			if _, err := p.expand([]string{name}); err != nil {
+				errs = append(errs, err)
+				break
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return p, nil
+}
+
+// expand returns the given roles plus everything they inherit, in a
+// stable order (a role before the roles it inherits from).
+func (p *Policy) expand(names []string) ([]*role, error) {
+	var out []*role
+	done := map[string]bool{}
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		for _, seen := range path {
+			if seen == name {
+				return fmt.Errorf("role inheritance cycle: %s", strings.Join(append(path, name), " → "))
+			}
+		}
+		if done[name] {
+			return nil
+		}
+		r, ok := p.roles[name]
+		if !ok {
+			return nil // unknown roles on a subject simply grant nothing
+		}
+		done[name] = true
+		out = append(out, r)
+		for _, parent := range r.inherits {
+			if err := visit(parent, append(path, name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, n := range names {
+		if err := visit(n, nil); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// ------------------------- EVALUATION -------------------------
+
+// Authorize decides whether subject may perform action on resource.
+//
+// Order of evaluation (the same order a careful if-else chain would use):
+//  1. Unknown action            → deny.
+//  2. Any matching deny rule    → deny (deny always wins).
+//  3. Role permission bitflags  → allow.
+//  4. Matching scoped grant     → allow.
+//  5. Nothing matched           → deny (default deny).
+func (p *Policy) Authorize(subject Subject, action string, resource Resource) Decision {
+	bit, ok := permissionNames[action]
+	if !ok {
+		return Decision{Rule: "-", Reason: fmt.Sprintf("unknown action %q", action)}
+	}
+
+	for _, d := range p.deny {
+		if matched, why := d.matches(action, subject, resource, true); matched {
+			return Decision{Rule: "deny " + d.ID, Reason: why}
+		}
+	}
+
+	roles, err := p.expand(subject.Roles)
+	if err != nil {
+		return Decision{Rule: "-", Reason: err.Error()}
+	}
+
+	for _, r := range roles {
+		for _, scope := range []string{resource.Type, "*"} {
+			if set := r.perms[scope]; set.Has(bit) {
+				return Decision{
+					Allowed: true,
+					Rule:    fmt.Sprintf("role %s: %s.%s", r.name, scope, action),
+					Reason:  fmt.Sprintf("role %s has %s on %s", r.name, set, scope),
+				}
+			}
+		}
+	}
+
+	var nearMiss string
+	for _, r := range roles {
+		for _, g := range r.grants {
+			matched, why := g.matches(action, subject, resource, false)
+			if matched {
+				return Decision{Allowed: true, Rule: fmt.Sprintf("role %s grant %s", r.name, g.ID), Reason: why}
+			}
+			if why != "" && nearMiss == "" {
+				nearMiss = fmt.Sprintf("grant %s did not apply: %s", g.ID, why)
+			}
+		}
+	}
+
+	reason := "no role or grant allows " + action + " on " + resource.Type
+	if nearMiss != "" {
+		reason = nearMiss
+	}
+	return Decision{Rule: "default deny", Reason: reason}
+}
+
+// matches checks the action, resource type and all conditions of a rule.
+// The returned text explains the (first failing or all passing) conditions;
+// it is empty when the rule is about a different action or resource.
+//
+// A condition on an attribute the resource lacks cannot be checked. For a
+// grant that means no match; for a deny rule (deny is true) it means a
+// match, so a missing attribute fails closed instead of skipping the deny.
+func (r Rule) matches(action string, s Subject, res Resource, deny bool) (bool, string) {
+	if r.Action != action || (r.Resource != "*" && r.Resource != res.Type) {
+		return false, ""
+	}
+	var passed []string
+	for _, c := range r.When {
+		if _, ok := res.Attrs[c.Attr]; !ok && deny {
+			passed = append(passed, fmt.Sprintf("resource has no %q, so it cannot be ruled out", c.Attr))
+			continue
+		}
+		ok, text := c.eval(s, res)
+		if !ok {
+			return false, text
+		}
+		passed = append(passed, text)
+	}
+	if len(passed) == 0 {
+		return true, "unconditional"
+	}
+	return true, strings.Join(passed, " and ")
+}
+
+// eval checks one condition and describes it, e.g. `amount 5000 < 10000`.
+func (c Condition) eval(s Subject, res Resource) (bool, string) {
+	left, ok := res.Attrs[c.Attr]
+	if !ok {
+		return false, fmt.Sprintf("resource has no %q", c.Attr)
+	}
+	right := c.Value
+	rightName := fmt.Sprint(c.Value)
+	if c.SubjectAttr != "" {
+		right = subjectAttr(s, c.SubjectAttr)
+		rightName = fmt.Sprintf("subject.%s (%v)", c.SubjectAttr, right)
+	}
+
+	symbols := map[string]string{"eq": "==", "ne": "!=", "lt": "<", "le": "<=", "gt": ">", "ge": ">=", "in": "in"}
+	text := fmt.Sprintf("%s %v %s %s", c.Attr, left, symbols[c.Op], rightName)
+	if !compare(left, c.Op, right) {
+		return false, text + " is false"
+	}
+	return true, text
+}
+
+// subjectAttr reads an attribute of the subject; "id" is always available.
+func subjectAttr(s Subject, name string) any {
+	if name == "id" {
+		return s.ID
+	}
+	return s.Attrs[name]
+}
+
+// compare applies an operator. Numbers are compared as float64 because JSON
+// numbers arrive as float64 while Go code often uses int or float32.
+func compare(left any, op string, right any) bool {
+	if op == "in" {
+		list, ok := right.([]any)
+		if !ok {
+			return false
+		}
+		for _, item := range list {
+			if compare(left, "eq", item) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if l, lok := toFloat(left); lok {
+		r, rok := toFloat(right)
+		if !rok {
+			return false
+		}
+		switch op {
+		case "eq":
+			return l == r
+		case "ne":
+			return l != r
+		case "lt":
+			return l < r
+		case "le":
+			return l <= r
+		case "gt":
+			return l > r
+		case "ge":
+			return l >= r
+		}
+		return false
+	}
+
+	switch op {
+	case "eq":
+		return fmt.Sprint(left) == fmt.Sprint(right)
+	case "ne":
+		return fmt.Sprint(left) != fmt.Sprint(right)
+	}
+	return false // ordering is only defined for numbers
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// ----------------------------- MAIN -----------------------------------
+func main() {
+	policyPath := flag.String("policy", "policy.json", "path to the policy file")
+	flag.Parse()
+
+	policy, err := LoadPolicy(*policyPath)
+	if err != nil {
+		fmt.Println("Policy error:", err)
+		os.Exit(1)
+	}
+
+	// The old check from 06-ifelse, for comparison:
+	role, hasPermissions := "admin", true
+	if role == "admin" && hasPermissions {
+		fmt.Println("Old style: yes, this person is an admin")
+	}
+	fmt.Println()
+
+	ravi := Subject{ID: "u1", Roles: []string{"support"}}
+	meena := Subject{ID: "u2", Roles: []string{"manager"}}
+	jhon := Subject{ID: "c7", Roles: []string{"customer"}}
+	root := Subject{ID: "u0", Roles: []string{"admin"}}
+
+	smallOrder := Resource{Type: "order", ID: "1", Attrs: map[string]any{"amount": 5000, "owner": "c7", "status": "Recieved"}}
+	bigOrder := Resource{Type: "order", ID: "2", Attrs: map[string]any{"amount": 500000, "owner": "c9", "status": "Delivered"}}
+	meenasOrder := Resource{Type: "order", ID: "3", Attrs: map[string]any{"amount": 800, "owner": "u2", "status": "Confirmed"}}
+	invoice := Resource{Type: "invoice", ID: "9", Attrs: map[string]any{}}
+	unknownStatus := Resource{Type: "order", ID: "4", Attrs: map[string]any{"amount": 1200, "owner": "c7"}}
+
+	checks := []struct {
+		who      string
+		subject  Subject
+		action   string
+		resource Resource
+	}{
+		{"support", ravi, "read", smallOrder},     // inherited from viewer
+		{"support", ravi, "refund", smallOrder},   // scoped grant: amount < 10000
+		{"support", ravi, "refund", bigOrder},     // grant condition fails
+		{"manager", meena, "refund", bigOrder},    // role permission
+		{"manager", meena, "refund", meenasOrder}, // global deny: no self-refund
+		{"customer", jhon, "read", smallOrder},    // own order
+		{"customer", jhon, "read", bigOrder},      // someone else's order
+		{"admin", root, "create", invoice},        // "*" permissions
+		{"admin", root, "delete", bigOrder},       // deny wins even for admin
+		{"admin", root, "delete", unknownStatus},  // no status: the deny still applies
+		{"support", ravi, "teleport", smallOrder}, // unknown action
+	}
+	for _, c := range checks {
+		d := policy.Authorize(c.subject, c.action, c.resource)
+		fmt.Printf("%-8s %-8s %-7s #%s → %s\n", c.who, c.action, c.resource.Type, c.resource.ID, d)
+	}
+}
+
+// ---------------------------- SUMMARY ---------------------------------
+// 1. Permissions are bitflags: a role's permission set is one integer and
+//    checking a permission is a single AND.
+// 2. Roles inherit roles; inheritance is validated (unknown roles, cycles)
+//    when the policy file is loaded, not when a request arrives.
+// 3. Grants and denies add conditions on resource/subject attributes,
+//    e.g. "refund orders under 10000" or "read only your own orders".
+// 4. Deny rules always win, and anything not explicitly allowed is denied.
+//    A deny condition on a missing attribute counts as matched (fail closed).
+// 5. Every Decision names the rule that decided it and why.
+// -----------------------------------------------------------------------
@@ -0,0 +1,821 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ----------------------------------------------------------
+// A DECLARATIVE RULES ENGINE
+// ----------------------------------------------------------
+// 06-ifelse classifies a person with an if-else ladder:
+//
+//   if age >= 18      { adult }
+//   else if age >= 12 { teenager }
+//   else              { child }
+//
+// Business rules like this change often (new thresholds, new labels),
+// and every change means editing code and redeploying. A rules engine
+// moves the ladder into a config file:
+//
+//   rules:
+//     - name: adult     when: [age >= 18]  outcome: Person is an adult
+//     - name: teenager  when: [age >= 12]  outcome: Person is a teenager
+//     - name: child     when: []           outcome: Person is a child
+//
+// and evaluates it against ANY Go value, reading struct fields with
+// reflection. Features:
+//   - JSON files, or a small YAML-like format (.yaml / .yml).
+//   - mode "first" (like if-else: first match wins) or "all" (every match).
+//   - Validation when the file is loaded, never half-way through a request.
+//   - Hot reload: the file is watched; a broken edit keeps the old rules.
+//   - Dry run: Explain shows every condition, its actual value and result.
+// ----------------------------------------------------------
+
+// ------------------------- RULE MODEL -------------------------
+
+// Mode decides how many outcomes Evaluate returns.
+type Mode string
+
+const (
+	ModeFirst Mode = "first" // stop at the first matching rule (if-else ladder)
+	ModeAll   Mode = "all"   // return every matching rule
+)
+
+// Condition is one comparison like `age >= 18`. A rule matches when all
+// of its conditions are true; a rule with no conditions always matches
+// (it plays the role of the final `else`).
+type Condition struct {
+	Field string // dotted path, e.g. customer.mobile
+	Op    string
+	Value any // float64, string, bool or []any
+	Text  string
+}
+
+// Rule is one rung of the ladder.
+type Rule struct {
+	Name    string
+	When    []Condition
+	Outcome string
+}
+
+// RuleSet is a validated, ordered list of rules.
+type RuleSet struct {
+	Name  string
+	Mode  Mode
+	Rules []Rule
+}
+
+// ------------------------- PARSING CONDITIONS -------------------------
+
+// operators, longest first so that ">=" is not read as ">". Word
+// operators must be followed by a space.
+var operators = []string{">=", "<=", "==", "!=", ">", "<", "startsWith", "endsWith", "contains", "in"}
+
+// parseCondition turns `customer.mobile startsWith "+91"` into a Condition.
+// The field name is read first and the operator must come right after it,
+// so operators inside the value (`note == "x >= y"`) are never matched.
+func parseCondition(text string) (Condition, error) {
+	rest := strings.TrimLeft(text, " \t")
+	end := strings.IndexFunc(rest, func(r rune) bool {
+		return r != '.' && r != '_' && !(r >= 'a' && r <= 'z') && !(r >= 'A' && r <= 'Z') && !(r >= '0' && r <= '9')
+	})
+	if end < 0 {
+		return Condition{}, fmt.Errorf("condition %q: no operator found", text)
+	}
+	field := rest[:end]
+	if !validPath(field) {
+		return Condition{}, fmt.Errorf("condition %q: %q is not a field name", text, field)
+	}
+	rest = strings.TrimLeft(rest[end:], " \t")
+	for _, op := range operators {
+		if !strings.HasPrefix(rest, op) {
+			continue
+		}
+		after := rest[len(op):]
+		if op[0] >= 'a' && op[0] <= 'z' && !strings.HasPrefix(after, " ") && !strings.HasPrefix(after, "\t") {
+			continue // "income" is not "in" followed by "come"
+		}
+		value, err := parseLiteral(strings.TrimSpace(after))
+		if err != nil {
+			return Condition{}, fmt.Errorf("condition %q: %w", text, err)
+		}
+		c := Condition{Field: field, Op: op, Value: value, Text: text}
+		return c, checkOperand(c)
+	}
+	return Condition{}, fmt.Errorf("condition %q: expected an operator after %q", text, field)
+}
+
+// validPath accepts identifiers separated by dots, like customer.mobile.
+func validPath(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, part := range strings.Split(s, ".") {
+		if part == "" {
+			return false
+		}
+		for i, r := range part {
+			letter := r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+			if !letter && (i == 0 || r < '0' || r > '9') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// parseLiteral reads a number, "string", true/false or a [list].
+func parseLiteral(s string) (any, error) {
+	switch {
+	case s == "":
+		return nil, errors.New("missing value")
+	case s == "true" || s == "false":
+		return s == "true", nil
+	case strings.HasPrefix(s, `"`):
+		return strconv.Unquote(s)
+	case strings.HasPrefix(s, "["):
+		if !strings.HasSuffix(s, "]") {
+			return nil, fmt.Errorf("unterminated list %s", s)
+		}
+		var items []any
+		for _, part := range splitList(s[1 : len(s)-1]) {
+			v, err := parseLiteral(strings.TrimSpace(part))
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		}
+		return items, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read value %s (strings need quotes)", s)
+	}
+	return f, nil
+}
+
+// splitList splits on commas that are not inside quotes.
+func splitList(s string) []string {
+	var parts []string
+	inQuotes, start := false, 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && inQuotes:
+			i++ // skip the escaped character
+		case s[i] == '"':
+			inQuotes = !inQuotes
+		case s[i] == ',' && !inQuotes:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	if strings.TrimSpace(s[start:]) != "" {
+		parts = append(parts, s[start:])
+	}
+	return parts
+}
+
+// checkOperand rejects combinations that can never be true, such as
+// `age >= "eighteen"`, when the file is loaded.
+func checkOperand(c Condition) error {
+	switch c.Op {
+	case ">", ">=", "<", "<=":
+		if _, ok := c.Value.(float64); !ok {
+			return fmt.Errorf("condition %q: %s needs a number", c.Text, c.Op)
+		}
+	case "startsWith", "endsWith", "contains":
+		if _, ok := c.Value.(string); !ok {
+			return fmt.Errorf("condition %q: %s needs a string", c.Text, c.Op)
+		}
+	case "in":
+		if _, ok := c.Value.([]any); !ok {
+			return fmt.Errorf("condition %q: in needs a [list]", c.Text)
+		}
+	}
+	return nil
+}
+
+// ------------------------- LOADING FILES -------------------------
+
+// ruleFile is the on-disk shape shared by the JSON and YAML-like formats.
+type ruleFile struct {
+	Name  string `json:"name"`
+	Mode  string `json:"mode"`
+	Rules []struct {
+		Name    string   `json:"name"`
+		When    []string `json:"when"`
+		Outcome string   `json:"outcome"`
+	} `json:"rules"`
+}
+
+// Parse reads rules in the given format ("json" or "yaml") and validates them.
+func Parse(data []byte, format string) (*RuleSet, error) {
+	if format == "yaml" {
+		tree, err := parseYAMLish(data)
+		if err != nil {
+			return nil, err
+		}
+		// Reuse the JSON decoder: the YAML-like tree is just maps and lists.
+		if data, err = json.Marshal(tree); err != nil {
+			return nil, err
+		}
+	}
+
+	var file ruleFile
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&file); err != nil {
+		return nil, err
+	}
+
+	rs := &RuleSet{Name: file.Name, Mode: Mode(file.Mode)}
+	if rs.Mode == "" {
+		rs.Mode = ModeFirst
+	}
+	var errs []error
+	if rs.Mode != ModeFirst && rs.Mode != ModeAll {
+		errs = append(errs, fmt.Errorf("mode must be %q or %q, got %q", ModeFirst, ModeAll, file.Mode))
+	}
+	if len(file.Rules) == 0 {
+		errs = append(errs, errors.New("no rules defined"))
+	}
+	seen := map[string]bool{}
+	for i, fr := range file.Rules {
+		where := fmt.Sprintf("rule %d (%s)", i+1, fr.Name)
+		if fr.Name == "" {
+			errs = append(errs, fmt.Errorf("rule %d: name is required", i+1))
+		} else if seen[fr.Name] {
+			errs = append(errs, fmt.Errorf("%s: duplicate rule name", where))
+		}
+		seen[fr.Name] = true
+		if fr.Outcome == "" {
+			errs = append(errs, fmt.Errorf("%s: outcome is required", where))
+		}
+		rule := Rule{Name: fr.Name, Outcome: fr.Outcome}
+		for _, text := range fr.When {
+			c, err := parseCondition(text)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", where, err))
+				continue
+			}
+			rule.When = append(rule.When, c)
+		}
+		rs.Rules = append(rs.Rules, rule)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return rs, nil
+}
+
+// LoadFile picks the format from the file extension.
+func LoadFile(path string) (*RuleSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseFile(path, data)
+}
+
+// parseFile parses data read from path, picking the format from the
+// file extension.
+func parseFile(path string, data []byte) (*RuleSet, error) {
+	format := "json"
+	if ext := filepath.Ext(path); ext == ".yaml" || ext == ".yml" {
+		format = "yaml"
+	}
+	rs, err := Parse(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rs, nil
+}
+
+// ------------------------- A TINY YAML-LIKE READER -------------------------
+// Real YAML is a big specification. Rule files only need a small part of
+// it: "key: value" maps, "- item" lists, nesting by indentation and
+// # comments. parseYAMLish builds a tree of map[string]any / []any /
+// string from exactly that subset, and reports anything else as an error.
+
+type yamlLine struct {
+	indent int
+	text   string
+	num    int
+}
+
+func parseYAMLish(data []byte) (any, error) {
+	var lines []yamlLine
+	for i, raw := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(raw)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if strings.Contains(raw, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", i+1)
+		}
+		lines = append(lines, yamlLine{indent: len(raw) - len(strings.TrimLeft(raw, " ")), text: trimmed, num: i + 1})
+	}
+	value, rest, err := yamlBlock(lines)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("line %d: unexpected indentation", rest[0].num)
+	}
+	return value, nil
+}
+
+// yamlBlock reads lines at the indentation of the first line and returns
+// the value plus the lines that belong to an outer block.
+func yamlBlock(lines []yamlLine) (any, []yamlLine, error) {
+	if len(lines) == 0 {
+		return nil, nil, nil
+	}
+	indent := lines[0].indent
+
+	if strings.HasPrefix(lines[0].text, "- ") || lines[0].text == "-" {
+		var list []any
+		for len(lines) > 0 && lines[0].indent == indent && strings.HasPrefix(lines[0].text, "-") {
+			item := strings.TrimSpace(strings.TrimPrefix(lines[0].text, "-"))
+			lines = lines[1:]
+			children := childLines(lines, indent)
+			lines = lines[len(children):]
+
+			var sub []yamlLine
+			switch {
+			case item == "":
+				// "-" on its own line: the item is the nested block below it.
+				sub = children
+			case strings.HasSuffix(item, ":") || strings.Contains(item, ": "):
+				// "- key: value" starts a map; its other keys are indented further.
+				first := yamlLine{indent: indent + 2, text: item, num: 0}
+				if len(children) > 0 {
+					first.indent = children[0].indent
+				}
+				sub = append([]yamlLine{first}, children...)
+			default:
+				if len(children) > 0 {
+					return nil, nil, fmt.Errorf("line %d: unexpected indentation", children[0].num)
+				}
+				list = append(list, unquoteScalar(item))
+				continue
+			}
+			v, rest, err := yamlBlock(sub)
+			if err != nil {
+				return nil, nil, err
+			}
+			if len(rest) > 0 {
+				return nil, nil, fmt.Errorf("line %d: unexpected indentation", rest[0].num)
+			}
+			list = append(list, v)
+		}
+		return list, lines, nil
+	}
+
+	m := map[string]any{}
+	for len(lines) > 0 && lines[0].indent == indent {
+		line := lines[0]
+		key, value, ok := strings.Cut(line.text, ":")
+		if !ok || strings.HasPrefix(line.text, "-") {
+			return nil, nil, fmt.Errorf("line %d: expected \"key: value\"", line.num)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		lines = lines[1:]
+		if value != "" {
+			m[key] = unquoteScalar(value)
+			continue
+		}
+		children := childLines(lines, indent)
+		v, _, err := yamlBlock(children)
+		if err != nil {
+			return nil, nil, err
+		}
+		m[key] = v
+		lines = lines[len(children):]
+	}
+	if len(lines) > 0 && lines[0].indent > indent {
+		return nil, nil, fmt.Errorf("line %d: unexpected indentation", lines[0].num)
+	}
+	return m, lines, nil
+}
+
+// childLines returns the lines indented deeper than indent.
+func childLines(lines []yamlLine, indent int) []yamlLine {
+	n := 0
+	for n < len(lines) && lines[n].indent > indent {
+		n++
+	}
+	return lines[:n]
+}
+
+// unquoteScalar removes surrounding quotes from `name: "adult"`; an empty
+// list written as [] becomes an empty list.
+func unquoteScalar(s string) any {
+	if s == "[]" {
+		return []any{}
+	}
+	if u, err := strconv.Unquote(s); err == nil && strings.HasPrefix(s, `"`) {
+		return u
+	}
+	return s
+}
+
+// ------------------------- EVALUATION -------------------------
+
+// Trace records how one condition was evaluated (used by Explain).
+type Trace struct {
+	Condition string
+	Actual    any
+	Result    bool
+	Err       error
+}
+
+// RuleTrace records one rule during a dry run.
+type RuleTrace struct {
+	Rule     string
+	Matched  bool
+	Selected bool // the rule's outcome is part of the result
+	Checks   []Trace
+}
+
+// Explanation is the full dry-run report.
+type Explanation struct {
+	RuleSet  string
+	Mode     Mode
+	Rules    []RuleTrace
+	Outcomes []string
+}
+
+func (e Explanation) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "rule set %q (mode %s)\n", e.RuleSet, e.Mode)
+	for _, r := range e.Rules {
+		mark := "  "
+		if r.Selected {
+			mark = "→ "
+		}
+		fmt.Fprintf(&b, "%s%-16s matched=%v\n", mark, r.Rule, r.Matched)
+		for _, c := range r.Checks {
+			if c.Err != nil {
+				fmt.Fprintf(&b, "      %-40s error: %v\n", c.Condition, c.Err)
+				continue
+			}
+			fmt.Fprintf(&b, "      %-40s actual=%v → %v\n", c.Condition, c.Actual, c.Result)
+		}
+	}
+	fmt.Fprintf(&b, "outcomes: %q", e.Outcomes)
+	return b.String()
+}
+
+// Evaluate returns the outcome(s) of the rules for v.
+func (rs *RuleSet) Evaluate(v any) ([]string, error) {
+	e := rs.run(v, false)
+	for _, r := range e.Rules {
+		for _, c := range r.Checks {
+			if c.Err != nil {
+				return nil, fmt.Errorf("rule %s: %w", r.Rule, c.Err)
+			}
+		}
+	}
+	return e.Outcomes, nil
+}
+
+// Explain is a dry run: it evaluates every rule (even after the first
+// match) and records why each one did or did not match.
+func (rs *RuleSet) Explain(v any) Explanation {
+	return rs.run(v, true)
+}
+
+func (rs *RuleSet) run(v any, explain bool) Explanation {
+	e := Explanation{RuleSet: rs.Name, Mode: rs.Mode}
+	root := reflect.ValueOf(v)
+	done := false
+	for _, rule := range rs.Rules {
+		if done && !explain {
+			break
+		}
+		rt := RuleTrace{Rule: rule.Name, Matched: true}
+		for _, c := range rule.When {
+			actual, err := lookup(root, c.Field)
+			t := Trace{Condition: c.Text, Actual: actual, Err: err}
+			if err == nil {
+				t.Result = compare(actual, c.Op, c.Value)
+			}
+			rt.Checks = append(rt.Checks, t)
+			if !t.Result {
+				rt.Matched = false
+				if !explain {
+					break // && short-circuits, just like in Go
+				}
+			}
+		}
+		if rt.Matched && !done {
+			rt.Selected = true
+			e.Outcomes = append(e.Outcomes, rule.Outcome)
+			done = rs.Mode == ModeFirst
+		}
+		e.Rules = append(e.Rules, rt)
+	}
+	return e
+}
+
+// lookup follows a dotted path through structs, pointers and maps.
+// Field names are matched case-insensitively so `age` finds `Age` or `age`.
+// Unexported fields are fine: we only READ their values with reflect.
+func lookup(v reflect.Value, path string) (any, error) {
+	for _, part := range strings.Split(path, ".") {
+		for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return nil, fmt.Errorf("%s: nil value", path)
+			}
+			v = v.Elem()
+		}
+		switch v.Kind() {
+		case reflect.Struct:
+			f := v.FieldByNameFunc(func(name string) bool { return strings.EqualFold(name, part) })
+			if !f.IsValid() {
+				return nil, fmt.Errorf("%s: %s has no field %q", path, v.Type(), part)
+			}
+			v = f
+		case reflect.Map:
+			if v.Type().Key().Kind() != reflect.String {
+				return nil, fmt.Errorf("%s: map keys must be strings", path)
+			}
+			f := v.MapIndex(reflect.ValueOf(part).Convert(v.Type().Key()))
+			if !f.IsValid() {
+				return nil, fmt.Errorf("%s: key %q not found", path, part)
+			}
+			v = f
+		default:
+			return nil, fmt.Errorf("%s: cannot read %q from %s", path, part, v.Kind())
+		}
+	}
+	return primitive(v, path)
+}
+
+// primitive converts the final value to float64, string or bool.
+// v.Int(), v.String() etc. work even on unexported fields (Interface() would not).
+func primitive(v reflect.Value, path string) (any, error) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return v.Bool(), nil
+	}
+	return nil, fmt.Errorf("%s: cannot compare a %s", path, v.Kind())
+}
+
+// compare applies one operator. Values of different kinds are never equal.
+func compare(actual any, op string, want any) bool {
+	switch op {
+	case "==":
+		return actual == want
+	case "!=":
+		return actual != want
+	case "in":
+		for _, item := range want.([]any) {
+			if actual == item {
+				return true
+			}
+		}
+		return false
+	case ">", ">=", "<", "<=":
+		a, ok := actual.(float64)
+		if !ok {
+			return false
+		}
+		w := want.(float64)
+		switch op {
+		case ">":
+			return a > w
+		case ">=":
+			return a >= w
+		case "<":
+			return a < w
+		}
+		return a <= w
+	}
+	a, ok := actual.(string)
+	if !ok {
+		return false
+	}
+	switch op {
+	case "startsWith":
+		return strings.HasPrefix(a, want.(string))
+	case "endsWith":
+		return strings.HasSuffix(a, want.(string))
+	}
+	return strings.Contains(a, want.(string))
+}
+
+// ------------------------- HOT RELOAD -------------------------
+
+// Engine owns a rule file and keeps the latest VALID version in memory.
+// atomic.Pointer lets Evaluate run concurrently with a reload without locks:
+// readers always see either the old or the new RuleSet, never a mix.
+type Engine struct {
+	path     string
+	current  atomic.Pointer[RuleSet]
+	mu       sync.Mutex
+	last     []byte                       // file content of the last reload attempt
+	OnReload func(rs *RuleSet, err error) // called after every reload attempt
+}
+
+// NewEngine loads the file once; a broken file at startup is an error.
+func NewEngine(path string) (*Engine, error) {
+	e := &Engine{path: path}
+	if err := e.Reload(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Rules returns the rules currently in use.
+func (e *Engine) Rules() *RuleSet { return e.current.Load() }
+
+// Evaluate evaluates v against the current rules.
+func (e *Engine) Evaluate(v any) ([]string, error) { return e.Rules().Evaluate(v) }
+
+// Reload reads the file again. Invalid files are rejected and the
+// previous rules stay active.
+func (e *Engine) Reload() error {
+	data, err := os.ReadFile(e.path)
+	if err != nil {
+		return err
+	}
+	e.mu.Lock()
+	e.last = data
+	e.mu.Unlock()
+
+	// Parse the bytes already read: reading the file a second time could
+	// see a newer version than the one recorded in e.last.
+	rs, err := parseFile(e.path, data)
+	if err == nil {
+		e.current.Store(rs)
+	}
+	if e.OnReload != nil {
+		e.OnReload(rs, err)
+	}
+	return err
+}
+
+// changed reports whether the file differs from what was last loaded.
+func (e *Engine) changed() bool {
+	data, err := os.ReadFile(e.path)
+	if err != nil {
+		return false
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return !bytes.Equal(data, e.last)
+}
+
+// Watch polls the file every interval and reloads it when its content changes.
+func (e *Engine) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if e.changed() {
+			e.Reload()
+		}
+	}
+}
+
+// ----------------------------- MAIN -----------------------------------
+
+// person and order are plain structs with unexported fields, like in 16-structs.
+type person struct {
+	name string
+	age  int
+}
+
+type customer struct {
+	name   string
+	mobile string
+}
+
+type order struct {
+	id     string
+	amount float32
+	status string
+	customer
+}
+
+// writeFile replaces a file atomically (write a temp file, then rename), so
+// the watcher never reads a half-written rule file.
+func writeFile(path string, data []byte) {
+	tmp := path + ".tmp"
+	os.WriteFile(tmp, data, 0o644)
+	os.Rename(tmp, path)
+}
+
+func must(rs *RuleSet, err error) *RuleSet {
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	return rs
+}
+
+func main() {
+	// 1. The age ladder from 06-ifelse, now loaded from age_rules.json.
+	ages := must(LoadFile("age_rules.json"))
+	for _, p := range []person{{"Asha", 25}, {"Ravi", 15}, {"Kiran", 8}} {
+		outcome, _ := ages.Evaluate(p)
+		fmt.Printf("%-6s (%2d): %s\n", p.name, p.age, outcome[0])
+	}
+	fmt.Println()
+
+	// 2. Mode "all" from a YAML-like file, over an order with an embedded customer.
+	labels := must(LoadFile("order_rules.yaml"))
+	o := order{id: "1", amount: 500000, status: "Recieved", customer: customer{"Jhon", "+91 7493957674"}}
+	outcomes, err := labels.Evaluate(o)
+	fmt.Println("Order labels:", outcomes, err)
+	fmt.Println()
+
+	// 3. Dry run: why did (or didn't) each rule match?
+	small := order{id: "2", amount: 40, status: "Delivered", customer: customer{"Emma", "+44 7700900123"}}
+	fmt.Println(labels.Explain(small))
+	fmt.Println()
+
+	// 4. Values may contain operator characters; only the text right
+	//    after the field name is read as the operator.
+	tricky := must(Parse([]byte(`{"rules":[{"name":"tricky","outcome":"matched",
+		"when":["status == \"x >= y\"","customer.mobile contains \"<\"","customer.name startsWith \"a>b\""]}]}`), "json"))
+	outcomes, err = tricky.Evaluate(order{status: "x >= y", customer: customer{"a>b", "<unknown>"}})
+	fmt.Println("Operators inside values:", outcomes, err)
+	fmt.Println()
+
+	// 5. Validation happens at load time, and reports every problem.
+	_, err = Parse([]byte(`{"mode":"sometimes","rules":[
+		{"name":"a","when":["age >= \"eighteen\""],"outcome":"x"},
+		{"name":"a","when":["age"],"outcome":""}]}`), "json")
+	fmt.Println("Invalid rules:\n" + err.Error())
+	fmt.Println()
+
+	// 6. Hot reload: change the file while the engine is running.
+	dir, _ := os.MkdirTemp("", "rules")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "age_rules.json")
+	original, _ := os.ReadFile("age_rules.json")
+	os.WriteFile(path, original, 0o644)
+
+	engine, _ := NewEngine(path)
+	reloaded := make(chan error, 1)
+	engine.OnReload = func(rs *RuleSet, err error) { reloaded <- err }
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go engine.Watch(ctx, 10*time.Millisecond)
+
+	nineteen := person{"Sam", 19}
+	outcome, _ := engine.Evaluate(nineteen)
+	fmt.Println("Age 19 before edit:", outcome)
+
+	writeFile(path, bytes.Replace(original, []byte("age >= 18"), []byte("age >= 21"), 1))
+	fmt.Println("Reload error:", <-reloaded)
+	outcome, _ = engine.Evaluate(nineteen)
+	fmt.Println("Age 19 after edit: ", outcome) // now a teenager
+
+	writeFile(path, []byte(`{"rules": [ broken`))
+	fmt.Println("Reload error:", <-reloaded != nil)
+	outcome, _ = engine.Evaluate(nineteen)
+	fmt.Println("Age 19 after a broken edit (old rules kept):", outcome)
+}
+
+// ---------------------------- SUMMARY ---------------------------------
+// 1. Rules are data: ordered conditions + outcome, in JSON or YAML-like files.
+// 2. Mode "first" behaves like an if-else ladder; mode "all" collects labels.
+// 3. Conditions read any struct (even unexported/embedded fields) or map
+//    through reflection, using dotted paths like customer.mobile.
+// 4. Files are validated when loaded; hot reload keeps the last good rules.
+// 5. Explain is a dry run that shows every condition and its actual value.
+// -----------------------------------------------------------------------
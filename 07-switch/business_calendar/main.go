@@ -0,0 +1,461 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"embed"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"testing/fstest"
+	"time"
+	_ "time/tzdata" // time zone data built in, so LoadLocation works everywhere
+	"unicode"
+)
+
+// ----------------------------------------------------------
+// BUSINESS CALENDAR AND DELIVERY ETA
+// ----------------------------------------------------------
+// 07-switch decides "weekend or not" with:
+//
+//	switch time.Now().Weekday() {
+//	case time.Saturday, time.Sunday:
+//
+// Order fulfilment needs more: which days a warehouse works (some work
+// Saturdays), national and state holidays, and the daily cut-off after
+// which an order ships the next working day. This file builds:
+//   - Calendar: configurable weekend + holiday sets loaded from files;
+//   - AddBusinessDays and BusinessDaysBetween;
+//   - Warehouse: a calendar, a time zone and a cut-off time;
+//   - ETA: from order.createdAt plus a shipping SLA in business days.
+// ----------------------------------------------------------
+
+// ----------------------------------------------------------
+// 1. DATES
+// ----------------------------------------------------------
+
+// date is a calendar day without a time or zone. Holidays are days, not
+// instants: Diwali is 20 October in Hyderabad whatever the UTC time is.
+type date struct {
+	year  int
+	month time.Month
+	day   int
+}
+
+// dateOf returns the day t falls on in t's own location.
+func dateOf(t time.Time) date {
+	y, m, d := t.Date()
+	return date{y, m, d}
+}
+
+func (d date) String() string { return fmt.Sprintf("%04d-%02d-%02d", d.year, d.month, d.day) }
+
+// compare orders dates: -1 if d is earlier than e, 0 if equal, +1 if later.
+func (d date) compare(e date) int {
+	return cmp.Or(cmp.Compare(d.year, e.year), cmp.Compare(d.month, e.month), cmp.Compare(d.day, e.day))
+}
+
+// annual is a date that repeats every year, like Independence Day.
+type annual struct {
+	month time.Month
+	day   int
+}
+
+// ----------------------------------------------------------
+// 2. HOLIDAY SETS
+// ----------------------------------------------------------
+
+// HolidaySet is a named list of holidays, e.g. "national" or "telangana".
+type HolidaySet struct {
+	Name   string
+	dated  map[date]string
+	annual map[annual]string
+}
+
+// LoadHolidays reads a holiday file from fsys. Each line is
+//
+//	MM-DD Name        a holiday every year
+//	YYYY-MM-DD Name   a holiday in that year only
+//
+// Blank lines and lines starting with # are ignored. The set is named
+// after the file: holidays/telangana.txt → "telangana".
+func LoadHolidays(fsys fs.FS, name string) (*HolidaySet, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	set := &HolidaySet{
+		Name:   strings.TrimSuffix(path.Base(name), path.Ext(name)),
+		dated:  map[date]string{},
+		annual: map[annual]string{},
+	}
+	sc := bufio.NewScanner(f)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		// The date ends at the first space or tab; the rest is the name,
+		// which may contain spaces of its own.
+		when, what := text, ""
+		if i := strings.IndexFunc(text, unicode.IsSpace); i >= 0 {
+			when, what = text[:i], strings.TrimSpace(text[i:])
+		}
+		if what == "" {
+			return nil, fmt.Errorf("%s:%d: holiday has no name", name, line)
+		}
+		// The shape of the date says which kind of holiday it is.
+		switch len(when) {
+		case len("01-26"):
+			t, err := time.Parse("01-02", when)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", name, line, err)
+			}
+			set.annual[annual{t.Month(), t.Day()}] = what
+		case len("2025-01-26"):
+			t, err := time.Parse(time.DateOnly, when)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", name, line, err)
+			}
+			set.dated[dateOf(t)] = what
+		default:
+			return nil, fmt.Errorf("%s:%d: bad date %q (want MM-DD or YYYY-MM-DD)", name, line, when)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return set, nil
+}
+
+// lookup returns the holiday on d, if any.
+func (s *HolidaySet) lookup(d date) (string, bool) {
+	if name, ok := s.dated[d]; ok {
+		return name, true
+	}
+	name, ok := s.annual[annual{d.month, d.day}]
+	return name, ok
+}
+
+// ----------------------------------------------------------
+// 3. CALENDAR
+// ----------------------------------------------------------
+
+// Calendar knows which days are working days.
+type Calendar struct {
+	weekend  [7]bool // indexed by time.Weekday
+	holidays []*HolidaySet
+}
+
+// ErrNoWorkingDays is returned for a calendar whose weekend is every day.
+var ErrNoWorkingDays = errors.New("calendar: every day is a weekend")
+
+// ErrNoBusinessDay is returned when holidays and weekends leave no working
+// day within maxDaysOff days: the holiday files cover a whole year.
+var ErrNoBusinessDay = errors.New("calendar: no business day found")
+
+// NewCalendar creates a calendar with the given weekend days and
+// holiday sets. An Indian warehouse may close only on Sunday; an office
+// on Saturday and Sunday.
+func NewCalendar(weekend []time.Weekday, holidays ...*HolidaySet) (*Calendar, error) {
+	c := &Calendar{holidays: holidays}
+	for _, d := range weekend {
+		if d < time.Sunday || d > time.Saturday {
+			return nil, fmt.Errorf("calendar: invalid weekday %d", d)
+		}
+		c.weekend[d] = true
+	}
+	if c.weekend == [7]bool{true, true, true, true, true, true, true} {
+		return nil, ErrNoWorkingDays
+	}
+	return c, nil
+}
+
+// DayOff explains why t's day is not a working day: "Sunday",
+// "Diwali (Deepavali) [national]", or "" for a business day.
+func (c *Calendar) DayOff(t time.Time) string {
+	for _, set := range c.holidays {
+		if name, ok := set.lookup(dateOf(t)); ok {
+			return fmt.Sprintf("%s [%s]", name, set.Name)
+		}
+	}
+	if c.weekend[t.Weekday()] {
+		return t.Weekday().String()
+	}
+	return ""
+}
+
+// IsBusinessDay reports whether t's day (in t's location) is a working day.
+func (c *Calendar) IsBusinessDay(t time.Time) bool { return c.DayOff(t) == "" }
+
+// maxDaysOff bounds the search for a working day. A calendar with a
+// working weekday always finds one within a year, unless its holiday
+// files cover every day of that year — a broken file, reported as
+// ErrNoBusinessDay.
+const maxDaysOff = 366
+
+// step moves t by dir days (+1 or -1) until a business day. AddDate
+// keeps the wall-clock time, even across daylight-saving changes.
+func (c *Calendar) step(t time.Time, dir int) (time.Time, error) {
+	start := t
+	for range maxDaysOff {
+		t = t.AddDate(0, 0, dir)
+		if c.IsBusinessDay(t) {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w within %d days of %s", ErrNoBusinessDay, maxDaysOff, dateOf(start))
+}
+
+// AddBusinessDays moves t forward n business days (backward if n < 0),
+// keeping the time of day. Adding 1 to a Friday gives Monday with a
+// Saturday-Sunday weekend; adding 0 returns t even if it is a holiday.
+func (c *Calendar) AddBusinessDays(t time.Time, n int) (time.Time, error) {
+	dir := 1
+	if n < 0 {
+		dir, n = -1, -n
+	}
+	for range n {
+		var err error
+		if t, err = c.step(t, dir); err != nil {
+			return time.Time{}, err
+		}
+	}
+	return t, nil
+}
+
+// NextBusinessDay returns t if it is a business day, otherwise the next one.
+func (c *Calendar) NextBusinessDay(t time.Time) (time.Time, error) {
+	if c.IsBusinessDay(t) {
+		return t, nil
+	}
+	return c.step(t, 1)
+}
+
+// BusinessDaysBetween counts the business days after from, up to and
+// including to (negative if to is before from). It is the inverse of
+// AddBusinessDays: when to is a business day,
+// AddBusinessDays(from, BusinessDaysBetween(from, to)) lands on to's day.
+func (c *Calendar) BusinessDaysBetween(from, to time.Time) int {
+	sign := 1
+	if dateOf(to).compare(dateOf(from)) < 0 {
+		from, to, sign = to, from, -1
+	}
+	n := 0
+	end := dateOf(to)
+	for d := from.AddDate(0, 0, 1); dateOf(d).compare(end) <= 0; d = d.AddDate(0, 0, 1) {
+		if c.IsBusinessDay(d) {
+			n++
+		}
+	}
+	return sign * n
+}
+
+// ----------------------------------------------------------
+// 4. WAREHOUSES AND ETA
+// ----------------------------------------------------------
+
+// Warehouse ships orders on its calendar, in its time zone. Orders
+// placed after Cutoff (time since local midnight) ship the next
+// business day; a zero Cutoff means there is none.
+type Warehouse struct {
+	Name     string
+	Location *time.Location
+	Cutoff   time.Duration
+	Calendar *Calendar
+}
+
+// Estimate is when an order ships and arrives, and why.
+type Estimate struct {
+	Dispatch time.Time
+	Delivery time.Time
+	Reason   string // why dispatch is not the order day; "" if it is
+}
+
+// ETA estimates dispatch and delivery for an order placed at orderedAt
+// (any zone; order.createdAt is usually UTC) with a shipping SLA in
+// business days.
+func (w Warehouse) ETA(orderedAt time.Time, slaDays int) (Estimate, error) {
+	local := orderedAt.In(w.Location) // the warehouse's clock decides
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, w.Location)
+
+	var e Estimate
+	var err error
+	switch off := w.Calendar.DayOff(local); {
+	case off != "":
+		e.Reason = "placed on a day off: " + off
+		e.Dispatch, err = w.Calendar.NextBusinessDay(midnight)
+	case w.Cutoff > 0 && local.Sub(midnight) >= w.Cutoff:
+		e.Reason = fmt.Sprintf("placed after the %s cut-off", fmtClock(w.Cutoff))
+		e.Dispatch, err = w.Calendar.AddBusinessDays(midnight, 1)
+	default:
+		e.Dispatch = midnight
+	}
+	if err != nil {
+		return Estimate{}, fmt.Errorf("%s: %w", w.Name, err)
+	}
+	if e.Delivery, err = w.Calendar.AddBusinessDays(e.Dispatch, slaDays); err != nil {
+		return Estimate{}, fmt.Errorf("%s: %w", w.Name, err)
+	}
+	return e, nil
+}
+
+// fmtClock formats a time since midnight as 15:04.
+func fmtClock(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// ----------------------------------------------------------
+// MAIN
+// ----------------------------------------------------------
+
+// The holiday files next to this program, built into the binary.
+//
+//go:embed holidays/*.txt
+var builtinHolidays embed.FS
+
+// order is the 16-structs order, reduced to what an ETA needs.
+type order struct {
+	id        string
+	createdAt time.Time // UTC, as stored
+	warehouse *Warehouse
+	slaDays   int
+}
+
+func main() {
+	dir := flag.String("holidays", "", "directory with national.txt, telangana.txt and karnataka.txt (default: built-in)")
+	flag.Parse()
+
+	var fsys fs.FS = builtinHolidays
+	prefix := "holidays/"
+	if *dir != "" {
+		fsys, prefix = os.DirFS(*dir), ""
+	}
+	sets := map[string]*HolidaySet{}
+	for _, name := range []string{"national", "telangana", "karnataka"} {
+		set, err := LoadHolidays(fsys, prefix+name+".txt")
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		sets[name] = set
+	}
+
+	ist, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	hydCal, _ := NewCalendar([]time.Weekday{time.Sunday}, sets["national"], sets["telangana"])
+	blrCal, _ := NewCalendar([]time.Weekday{time.Saturday, time.Sunday}, sets["national"], sets["karnataka"])
+	hyderabad := &Warehouse{"Hyderabad", ist, 14 * time.Hour, hydCal}
+	bengaluru := &Warehouse{"Bengaluru", ist, 16*time.Hour + 30*time.Minute, blrCal}
+	allDay := &Warehouse{Name: "24x7", Location: ist, Calendar: hydCal} // no Cutoff: same-day dispatch
+
+	// 1. The old switch vs the calendar, for Diwali week 2025.
+	fmt.Println("Diwali week 2025:")
+	for d := time.Date(2025, 10, 17, 12, 0, 0, 0, ist); d.Day() <= 23; d = d.AddDate(0, 0, 1) {
+		old := "weekday"
+		switch d.Weekday() {
+		case time.Saturday, time.Sunday:
+			old = "weekend"
+		}
+		hyd, blr := "open", "open"
+		if off := hydCal.DayOff(d); off != "" {
+			hyd = off
+		}
+		if off := blrCal.DayOff(d); off != "" {
+			blr = off
+		}
+		fmt.Printf("  %s %-9s  switch: %-7s  Hyderabad: %-32s  Bengaluru: %s\n",
+			d.Format("Jan 02"), d.Weekday(), old, hyd, blr)
+	}
+	fmt.Println()
+
+	// 2. Business-day arithmetic.
+	fri := time.Date(2025, 10, 17, 10, 0, 0, 0, ist)
+	for _, n := range []int{1, -5} {
+		d, err := blrCal.AddBusinessDays(fri, n)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		fmt.Printf("Bengaluru, Fri 17 Oct %+d business day(s): %s\n", n, d.Format("Mon 02 Jan"))
+	}
+	// BusinessDaysBetween counts (from, to]: the start day is excluded, so
+	// all of October is counted from the last day of September.
+	sep30, oct31 := time.Date(2025, 9, 30, 0, 0, 0, 0, ist), time.Date(2025, 10, 31, 0, 0, 0, 0, ist)
+	fmt.Printf("Business days in October 2025: Hyderabad %d, Bengaluru %d\n",
+		hydCal.BusinessDaysBetween(sep30, oct31), blrCal.BusinessDaysBetween(sep30, oct31))
+	fmt.Println()
+
+	// 3. Delivery ETAs. createdAt is UTC; the warehouse's clock decides.
+	orders := []order{
+		{"1", time.Date(2025, 10, 17, 8, 0, 0, 0, time.UTC), hyderabad, 3},   // Fri 13:30 IST
+		{"2", time.Date(2025, 10, 17, 10, 0, 0, 0, time.UTC), hyderabad, 3},  // Fri 15:30 IST
+		{"3", time.Date(2025, 10, 17, 10, 0, 0, 0, time.UTC), bengaluru, 3},  // Fri 15:30 IST
+		{"4", time.Date(2025, 8, 14, 20, 0, 0, 0, time.UTC), bengaluru, 2},   // Fri 15 Aug 01:30 IST
+		{"5", time.Date(2025, 12, 31, 18, 45, 0, 0, time.UTC), hyderabad, 5}, // 1 Jan 00:15 IST
+		{"6", time.Date(2025, 10, 18, 5, 0, 0, 0, time.UTC), hyderabad, 1},   // Sat 10:30 IST
+		{"7", time.Date(2025, 10, 18, 5, 0, 0, 0, time.UTC), bengaluru, 1},   // Sat 10:30 IST
+		{"8", time.Date(2025, 10, 17, 13, 0, 0, 0, time.UTC), allDay, 1},     // Fri 18:30 IST, no cut-off
+	}
+	for _, o := range orders {
+		e, err := o.warehouse.ETA(o.createdAt, o.slaDays)
+		if err != nil {
+			fmt.Println("Error:", err)
+			continue
+		}
+		fmt.Printf("Order %s  %-9s placed %s  SLA %d  → ships %s, arrives %s\n",
+			o.id, o.warehouse.Name, o.createdAt.In(ist).Format("Mon 02 Jan 15:04"), o.slaDays,
+			e.Dispatch.Format("Mon 02 Jan"), e.Delivery.Format("Mon 02 Jan"))
+		if e.Reason != "" {
+			fmt.Println("          ", e.Reason)
+		}
+	}
+	fmt.Println()
+
+	// 4. Bad data is reported with file and line.
+	bad := fstest.MapFS{"bad.txt": {Data: []byte("# typo below\n2025-13-01 Founders Day\n")}}
+	_, err = LoadHolidays(bad, "bad.txt")
+	fmt.Println("Loading a broken file:", err)
+	_, err = NewCalendar([]time.Weekday{0, 1, 2, 3, 4, 5, 6})
+	fmt.Println("All-weekend calendar: ", err)
+	_, err = NewCalendar([]time.Weekday{time.Weekday(7)})
+	fmt.Println("Weekday 7:            ", err)
+	tabs := fstest.MapFS{"tabs.txt": {Data: []byte("12-25\tChristmas\n")}}
+	set, err := LoadHolidays(tabs, "tabs.txt")
+	if err == nil {
+		name, _ := set.lookup(date{2025, time.December, 25})
+		fmt.Printf("Tab-separated file:    %q\n", name)
+	}
+	// A file that makes every day of the year a holiday: an error, not a panic.
+	var everyDay strings.Builder
+	for d := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC); d.Year() == 2024; d = d.AddDate(0, 0, 1) {
+		fmt.Fprintf(&everyDay, "%s Holiday\n", d.Format("01-02"))
+	}
+	closed, err := LoadHolidays(fstest.MapFS{"all.txt": {Data: []byte(everyDay.String())}}, "all.txt")
+	if err == nil {
+		cal, _ := NewCalendar(nil, closed)
+		_, err = Warehouse{Name: "Closed", Location: ist, Calendar: cal}.ETA(fri, 1)
+	}
+	fmt.Println("Every day a holiday:  ", err)
+}
+
+// ----------------------------------------------------------
+// SUMMARY
+// ----------------------------------------------------------
+// 1. Holidays are dates, not instants: convert to the warehouse's zone
+//    before asking "which day is it?".
+// 2. A switch on the shape of a line parses two holiday formats.
+// 3. Business-day arithmetic steps day by day with AddDate, which keeps
+//    the time of day across DST changes.
+// 4. Dispatch = order day, or the next business day if the order came
+//    on a day off or after the cut-off; delivery = dispatch + SLA.
+// ----------------------------------------------------------
@@ -0,0 +1,1075 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata" // zone data for hosts without /usr/share/zoneinfo
+)
+
+// ----------------------------------------------------------
+// CRON SCHEDULER
+// ----------------------------------------------------------
+// A shop has chores that run on a timetable: cancel orders that were never
+// paid, reconcile payments with the bank, bill subscriptions on the 1st.
+// Cron expressions are the standard way to write such timetables:
+//
+//	┌──────── second        (optional, 0-59)
+//	│ ┌────── minute        (0-59)
+//	│ │ ┌──── hour          (0-23)
+//	│ │ │ ┌── day of month  (1-31)
+//	│ │ │ │ ┌ month         (1-12 or JAN-DEC)
+//	│ │ │ │ │ ┌ day of week (0-7 or SUN-SAT, 0 and 7 are Sunday)
+//	* * * * * *
+//
+// Each field accepts *, a value, a range a-b, a step */n or a-b/n, and
+// comma-separated lists of those. @daily, @hourly and friends are shortcuts.
+//
+// This file is mostly switch statements: one picks the field syntax, one
+// walks the calendar to the next run, one applies the overlap policy.
+//
+// The hard part is time zones. A schedule says "02:30 every night" in
+// LOCAL time, and twice a year local time misbehaves:
+//   - Spring forward: 02:00-03:00 does not exist. A nightly 02:30 job runs
+//     at 03:00 instead of being skipped for the day.
+//   - Fall back: 01:00-02:00 happens twice. A nightly 01:30 job runs once;
+//     an every-30-minutes job runs in both passes, because real time passed.
+//
+// Around the parser:
+//   - Scheduler: runs jobs, with jitter and an overlap policy per job.
+//   - Stop(ctx): graceful shutdown, waiting for running jobs until ctx ends.
+//   - Clock: an interface, so the demo (and tests) control time.
+// ----------------------------------------------------------
+
+// ----------------------------------------------------------
+// 1. CLOCK
+// ----------------------------------------------------------
+
+// Clock is the source of time. Production code uses RealClock; examples
+// use a FakeClock that only moves when told to.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is the part of *time.Timer the scheduler needs. The scheduler
+// stops timers it no longer waits on, so a FakeClock does not collect them.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+// RealClock is the system clock.
+type RealClock struct{}
+
+func (RealClock) Now() time.Time                 { return time.Now() }
+func (RealClock) NewTimer(d time.Duration) Timer { return realTimer{time.NewTimer(d)} }
+
+type realTimer struct{ t *time.Timer }
+
+func (r realTimer) C() <-chan time.Time { return r.t.C }
+func (r realTimer) Stop() bool          { return r.t.Stop() }
+
+// FakeClock is a manually advanced clock.
+type FakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	clock *FakeClock
+	at    time.Time
+	ch    chan time.Time
+}
+
+// NewFakeClock starts a fake clock at t.
+func NewFakeClock(t time.Time) *FakeClock { return &FakeClock{now: t} }
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{clock: c, at: c.now.Add(d), ch: make(chan time.Time, 1)}
+	if d <= 0 {
+		t.ch <- c.now
+		return t
+	}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.ch }
+
+// Stop removes a pending timer. It reports false if the timer already fired.
+func (t *fakeTimer) Stop() bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, other := range c.timers {
+		if other == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Waiters returns how many timers have not fired yet.
+func (c *FakeClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+// NextDeadline returns when the earliest pending timer fires.
+func (c *FakeClock) NextDeadline() (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var next time.Time
+	for _, t := range c.timers {
+		if next.IsZero() || t.at.Before(next) {
+			next = t.at
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// Advance moves time forward and fires every timer that is due.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	pending := c.timers[:0]
+	for _, t := range c.timers {
+		if t.at.After(c.now) {
+			pending = append(pending, t)
+			continue
+		}
+		t.ch <- c.now
+	}
+	c.timers = pending
+}
+
+// ----------------------------------------------------------
+// 2. PARSING
+// ----------------------------------------------------------
+
+// ErrSyntax is wrapped by every parse error.
+var ErrSyntax = errors.New("cron: invalid expression")
+
+// field describes one column of a cron expression.
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	months = map[string]int{
+		"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+		"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+	}
+	weekdays = map[string]int{
+		"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+	}
+
+	seconds    = field{"second", 0, 59, nil}
+	minutes    = field{"minute", 0, 59, nil}
+	hours      = field{"hour", 0, 23, nil}
+	daysOfMon  = field{"day of month", 1, 31, nil}
+	monthsOfYr = field{"month", 1, 12, months}
+	daysOfWeek = field{"day of week", 0, 7, weekdays} // 7 is folded into 0
+)
+
+// Schedule is a parsed cron expression bound to a time zone.
+// Each field is a bitset: bit n is set when value n matches.
+type Schedule struct {
+	expr     string
+	loc      *time.Location
+	second   uint64
+	minute   uint64
+	hour     uint64
+	dom      uint64
+	month    uint64
+	dow      uint64
+	domAll   bool // day of month started with * or ?
+	dowAll   bool // day of week started with * or ?
+	everyHr  bool // hour field matches all 24 hours
+	withSecs bool // expression had six fields
+}
+
+// Parse reads a 5- or 6-field cron expression. The expression is read in
+// loc (UTC when nil) unless it starts with CRON_TZ=<zone> or TZ=<zone>.
+func Parse(expr string, loc *time.Location) (*Schedule, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	spec := strings.TrimSpace(expr)
+	if strings.HasPrefix(spec, "CRON_TZ=") || strings.HasPrefix(spec, "TZ=") {
+		zone, rest, _ := strings.Cut(spec, " ")
+		_, name, _ := strings.Cut(zone, "=")
+		if name == "" {
+			// LoadLocation("") is UTC, which would hide a typo like "TZ= 0 9 * * *".
+			return nil, fmt.Errorf("%w %q: %s has no zone name", ErrSyntax, expr, zone)
+		}
+		l, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %v", ErrSyntax, expr, err)
+		}
+		loc, spec = l, strings.TrimSpace(rest)
+	}
+
+	switch spec {
+	case "@yearly", "@annually":
+		spec = "0 0 1 1 *"
+	case "@monthly":
+		spec = "0 0 1 * *"
+	case "@weekly":
+		spec = "0 0 * * 0"
+	case "@daily", "@midnight":
+		spec = "0 0 * * *"
+	case "@hourly":
+		spec = "0 * * * *"
+	}
+
+	parts := strings.Fields(spec)
+	s := &Schedule{expr: expr, loc: loc}
+	switch len(parts) {
+	case 5:
+		parts = append([]string{"0"}, parts...)
+	case 6:
+		s.withSecs = true
+	default:
+		return nil, fmt.Errorf("%w %q: want 5 or 6 fields, got %d", ErrSyntax, expr, len(parts))
+	}
+
+	var err error
+	targets := []struct {
+		bits *uint64
+		star *bool
+		f    field
+	}{
+		{&s.second, nil, seconds},
+		{&s.minute, nil, minutes},
+		{&s.hour, nil, hours},
+		{&s.dom, &s.domAll, daysOfMon},
+		{&s.month, nil, monthsOfYr},
+		{&s.dow, &s.dowAll, daysOfWeek},
+	}
+	for i, t := range targets {
+		if *t.bits, err = parseField(parts[i], t.f); err != nil {
+			return nil, fmt.Errorf("%w %q: %v", ErrSyntax, expr, err)
+		}
+		if t.star != nil {
+			// Like Vixie cron, any field that starts with * counts as
+			// unrestricted for the day rule, so "0 12 1 * */2" runs on the
+			// 1st only when it is also an even weekday.
+			*t.star = strings.HasPrefix(parts[i], "*") || strings.HasPrefix(parts[i], "?")
+		}
+	}
+	s.everyHr = s.hour == 1<<24-1
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	return s, nil
+}
+
+// MustParse is Parse for expressions known at compile time.
+func MustParse(expr string, loc *time.Location) *Schedule {
+	s, err := Parse(expr, loc)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// parseField turns one column into a bitset.
+func parseField(text string, f field) (bits uint64, err error) {
+	for _, part := range strings.Split(text, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		lo, hi, step := f.min, f.max, 1
+
+		switch {
+		case rangePart == "*" || rangePart == "?":
+			if f.name == "day of week" {
+				hi = 6 // * means SUN-SAT, not SUN-SUN
+			}
+		case strings.Contains(rangePart, "-"):
+			a, b, _ := strings.Cut(rangePart, "-")
+			if lo, err = value(a, f); err != nil {
+				return 0, err
+			}
+			if hi, err = value(b, f); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("%s range %q is backwards", f.name, rangePart)
+			}
+		default:
+			if lo, err = value(rangePart, f); err != nil {
+				return 0, err
+			}
+			if !hasStep {
+				hi = lo // "5" is just 5; "5/15" means 5, 20, 35, ...
+			}
+		}
+
+		if hasStep {
+			if step, err = strconv.Atoi(stepPart); err != nil || step <= 0 {
+				return 0, fmt.Errorf("%s step %q must be a positive number", f.name, stepPart)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// value reads a number or a name (JAN, MON) and checks its range.
+func value(text string, f field) (int, error) {
+	if n, ok := f.names[strings.ToUpper(text)]; ok {
+		return n, nil
+	}
+	n, err := strconv.Atoi(text)
+	switch {
+	case err != nil:
+		return 0, fmt.Errorf("%s %q is not a number", f.name, text)
+	case n < f.min || n > f.max:
+		return 0, fmt.Errorf("%s %d is outside %d-%d", f.name, n, f.min, f.max)
+	}
+	return n, nil
+}
+
+// String returns the expression as written.
+func (s *Schedule) String() string { return s.expr }
+
+// Location returns the zone the schedule is read in.
+func (s *Schedule) Location() *time.Location { return s.loc }
+
+// ----------------------------------------------------------
+// 3. NEXT RUN
+// ----------------------------------------------------------
+
+// Next works in two layers:
+//
+//  1. nextCivil walks WALL-CLOCK time ("2025-03-09 02:30") with no zone at
+//     all. Civil times are stored as UTC, where every day has 24 hours, so
+//     the walk can never be confused by DST.
+//  2. resolve turns a wall-clock time into the real instants it names in
+//     the schedule's zone: none (spring-forward gap), one (normal), or two
+//     (fall-back overlap). The DST policy is applied there.
+
+// maxSearch bounds how far ahead Next looks, in years. Eight years covers
+// "0 0 29 2 *" even across 2100, which is not a leap year (2096 → 2104);
+// an expression like "0 0 30 2 *" never matches and Next returns zero.
+const maxSearch = 8
+
+// Next returns the first run strictly after `after`, or the zero time if
+// the schedule never fires.
+func (s *Schedule) Next(after time.Time) time.Time {
+	after = after.In(s.loc)
+	best := s.next(after, civil(after))
+
+	// In the first pass of a fall-back hour, an every-hour job also has
+	// runs in the second pass, which starts when the zone period ends.
+	if s.everyHr {
+		if _, end := after.ZoneBounds(); !end.IsZero() {
+			_, off := after.Zone()
+			if _, nextOff := end.Zone(); nextOff < off {
+				second := s.next(after, civil(end).Add(-time.Second))
+				if !second.IsZero() && (best.IsZero() || second.Before(best)) {
+					best = second
+				}
+			}
+		}
+	}
+	return best
+}
+
+// next returns the first run after `after` whose wall-clock time is later
+// than from.
+func (s *Schedule) next(after, from time.Time) time.Time {
+	for c := from; ; {
+		var ok bool
+		if c, ok = s.nextCivil(c); !ok {
+			return time.Time{}
+		}
+		instants, gapEnd := s.resolve(c)
+		switch {
+		case len(instants) == 0:
+			// Spring forward: fixed-time jobs run as soon as the gap ends;
+			// every-hour jobs just lose the times that never existed.
+			if !s.everyHr && gapEnd.After(after) {
+				return gapEnd
+			}
+		case len(instants) == 2 && !s.everyHr:
+			// Fall back: fixed-time jobs run in the first pass only.
+			if instants[0].After(after) {
+				return instants[0]
+			}
+		default:
+			for _, t := range instants {
+				if t.After(after) {
+					return t
+				}
+			}
+		}
+	}
+}
+
+// nextCivil returns the first matching wall-clock time after c.
+func (s *Schedule) nextCivil(c time.Time) (time.Time, bool) {
+	step := time.Minute
+	if s.withSecs {
+		step = time.Second
+	}
+	t := c.Truncate(step).Add(step)
+	limit := t.AddDate(maxSearch, 0, 0)
+
+	for t.Before(limit) {
+		y, m, d := t.Date()
+		switch {
+		case s.month&(1<<m) == 0:
+			t = time.Date(y, m+1, 1, 0, 0, 0, 0, time.UTC)
+		case !s.dayMatches(t):
+			t = time.Date(y, m, d+1, 0, 0, 0, 0, time.UTC)
+		case s.hour&(1<<t.Hour()) == 0:
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case s.minute&(1<<t.Minute()) == 0:
+			t = t.Truncate(time.Minute).Add(time.Minute)
+		case s.second&(1<<t.Second()) == 0:
+			t = t.Add(time.Second)
+		default:
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// dayMatches applies the classic cron rule: when both day fields are
+// restricted, a day matches if EITHER does ("0 0 1,15 * MON" runs on the
+// 1st, the 15th and every Monday). A field starting with * is never
+// restricted, so "0 12 1 * */2" needs BOTH: the 1st, on an even weekday.
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<t.Day()) != 0
+	dow := s.dow&(1<<t.Weekday()) != 0
+	if s.domAll || s.dowAll {
+		return dom && dow // a bare * sets every bit, so this is the other field
+	}
+	return dom || dow
+}
+
+// resolve returns the instants whose wall clock in s.loc reads c, in order.
+// When there are none, gapEnd is the first instant after the skipped range.
+func (s *Schedule) resolve(c time.Time) (instants []time.Time, gapEnd time.Time) {
+	t := time.Date(c.Year(), c.Month(), c.Day(), c.Hour(), c.Minute(), c.Second(), 0, s.loc)
+	start, end := t.ZoneBounds()
+
+	if !civil(t).Equal(c) {
+		// time.Date normalised a time inside the gap to one side of it.
+		if civil(t).Before(c) {
+			return nil, end
+		}
+		return nil, start
+	}
+
+	_, off := t.Zone()
+	if !start.IsZero() {
+		if _, prevOff := start.Add(-time.Second).Zone(); prevOff > off {
+			u := t.Add(-time.Duration(prevOff-off) * time.Second)
+			if u.Before(start) && civil(u).Equal(c) {
+				return []time.Time{u, t}, time.Time{}
+			}
+		}
+	}
+	if !end.IsZero() {
+		if _, nextOff := end.Zone(); nextOff < off {
+			u := t.Add(time.Duration(off-nextOff) * time.Second)
+			if !u.Before(end) && civil(u).Equal(c) {
+				return []time.Time{t, u}, time.Time{}
+			}
+		}
+	}
+	return []time.Time{t}, time.Time{}
+}
+
+// civil returns t's wall-clock reading as a zone-free (UTC) time.
+func civil(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+}
+
+// ----------------------------------------------------------
+// 4. SCHEDULER
+// ----------------------------------------------------------
+
+// Overlap decides what happens when a job is due while its previous run is
+// still going.
+type Overlap int
+
+const (
+	Skip       Overlap = iota // drop the new run
+	Queue                     // run it when the current one finishes (at most one waits)
+	Concurrent                // start it anyway
+)
+
+func (o Overlap) String() string {
+	switch o {
+	case Skip:
+		return "skip"
+	case Queue:
+		return "queue"
+	case Concurrent:
+		return "concurrent"
+	default:
+		return "Overlap(" + strconv.Itoa(int(o)) + ")"
+	}
+}
+
+// Job is a named unit of work on a schedule. Run receives the planned run
+// time, which makes a good idempotency key ("billing for 2025-11-01").
+// Run must return when ctx is cancelled.
+type Job struct {
+	Name     string
+	Spec     string
+	Location *time.Location // used when Spec has no CRON_TZ= prefix
+	Overlap  Overlap
+	Jitter   time.Duration // random delay in [0, Jitter) added to each run
+	Run      func(ctx context.Context, planned time.Time) error
+}
+
+// EventKind says what happened to a job.
+type EventKind int
+
+const (
+	Started EventKind = iota
+	Finished
+	Failed
+	Skipped
+	Queued
+	Dropped // a queued run discarded by Stop or replaced by a newer one
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case Started:
+		return "started"
+	case Finished:
+		return "finished"
+	case Failed:
+		return "failed"
+	case Skipped:
+		return "skipped"
+	case Queued:
+		return "queued"
+	case Dropped:
+		return "dropped"
+	default:
+		return "EventKind(" + strconv.Itoa(int(k)) + ")"
+	}
+}
+
+// Event is reported to Options.OnEvent.
+type Event struct {
+	Time    time.Time
+	Job     string
+	Kind    EventKind
+	Planned time.Time
+	Err     error
+}
+
+// Options configures a Scheduler. Zero values are usable.
+type Options struct {
+	Clock   Clock           // default RealClock
+	Rand    *rand.Rand      // jitter source; default math/rand/v2
+	OnEvent func(Event)     // called with the scheduler's lock held: do not call back into it
+	Context context.Context // parent of every job's context; default Background
+}
+
+// ErrPanicked wraps a panic raised by a job.
+var ErrPanicked = errors.New("cron: job panicked")
+
+// ErrStopped is returned by Add after Stop.
+var ErrStopped = errors.New("cron: scheduler stopped")
+
+// jobState is a Job plus its scheduling bookkeeping.
+type jobState struct {
+	Job
+	schedule *Schedule
+	planned  time.Time // next run according to the schedule
+	fireAt   time.Time // planned + jitter
+	running  int
+	queued   bool
+	queuedAt time.Time
+}
+
+// Scheduler runs jobs on their schedules.
+type Scheduler struct {
+	opts   Options
+	clock  Clock
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	jobs     []*jobState
+	started  bool
+	stopping bool
+
+	wg       sync.WaitGroup
+	wake     chan struct{}
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// New returns a scheduler; call Start to begin running jobs.
+func New(opts Options) *Scheduler {
+	if opts.Clock == nil {
+		opts.Clock = RealClock{}
+	}
+	if opts.Context == nil {
+		opts.Context = context.Background()
+	}
+	ctx, cancel := context.WithCancel(opts.Context)
+	return &Scheduler{
+		opts:   opts,
+		clock:  opts.Clock,
+		ctx:    ctx,
+		cancel: cancel,
+		wake:   make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// Add parses the job's spec and schedules its first run.
+func (s *Scheduler) Add(j Job) error {
+	if j.Run == nil {
+		return fmt.Errorf("cron: job %q has no Run func", j.Name)
+	}
+	schedule, err := Parse(j.Spec, j.Location)
+	if err != nil {
+		return fmt.Errorf("job %q: %w", j.Name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopping {
+		return ErrStopped
+	}
+	st := &jobState{Job: j, schedule: schedule}
+	st.planned = schedule.Next(s.clock.Now())
+	st.fireAt = s.withJitter(st)
+	s.jobs = append(s.jobs, st)
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Start launches the scheduling loop.
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started || s.stopping {
+		return
+	}
+	s.started = true
+	go s.loop()
+}
+
+// loop sleeps until the earliest job is due, runs whatever is due, repeats.
+func (s *Scheduler) loop() {
+	defer close(s.done)
+	for {
+		s.mu.Lock()
+		var earliest time.Time
+		for _, j := range s.jobs {
+			if !j.fireAt.IsZero() && (earliest.IsZero() || j.fireAt.Before(earliest)) {
+				earliest = j.fireAt
+			}
+		}
+		s.mu.Unlock()
+
+		var timer Timer
+		var fire <-chan time.Time
+		if !earliest.IsZero() {
+			timer = s.clock.NewTimer(earliest.Sub(s.clock.Now()))
+			fire = timer.C()
+		}
+
+		select {
+		case <-fire:
+			s.runDue()
+		case <-s.wake:
+		case <-s.stop:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+
+		select {
+		case <-s.stop:
+			return
+		default:
+		}
+	}
+}
+
+// runDue starts (or skips, or queues) every job whose time has come.
+func (s *Scheduler) runDue() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+
+	for _, j := range s.jobs {
+		if j.fireAt.IsZero() || j.fireAt.After(now) {
+			continue
+		}
+		planned := j.planned
+
+		// If the process was asleep past several runs, run once and carry on
+		// from now instead of replaying every missed run.
+		j.planned = j.schedule.Next(planned)
+		if !j.planned.IsZero() && !j.planned.After(now) {
+			j.planned = j.schedule.Next(now)
+		}
+		j.fireAt = s.withJitter(j)
+
+		switch {
+		case j.running == 0 || j.Overlap == Concurrent:
+			s.start(j, planned)
+		case j.Overlap == Queue && j.queued:
+			s.emit(Event{Time: now, Job: j.Name, Kind: Dropped, Planned: j.queuedAt})
+			j.queuedAt = planned
+			s.emit(Event{Time: now, Job: j.Name, Kind: Queued, Planned: planned})
+		case j.Overlap == Queue:
+			j.queued, j.queuedAt = true, planned
+			s.emit(Event{Time: now, Job: j.Name, Kind: Queued, Planned: planned})
+		default:
+			s.emit(Event{Time: now, Job: j.Name, Kind: Skipped, Planned: planned})
+		}
+	}
+}
+
+// start runs one job in its own goroutine. s.mu must be held.
+func (s *Scheduler) start(j *jobState, planned time.Time) {
+	j.running++
+	s.wg.Add(1)
+	s.emit(Event{Time: s.clock.Now(), Job: j.Name, Kind: Started, Planned: planned})
+
+	go func() {
+		defer s.wg.Done()
+		err := s.call(j, planned)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		j.running--
+		kind := Finished
+		if err != nil {
+			kind = Failed
+		}
+		s.emit(Event{Time: s.clock.Now(), Job: j.Name, Kind: kind, Planned: planned, Err: err})
+
+		if j.queued && !s.stopping {
+			j.queued = false
+			s.start(j, j.queuedAt)
+		}
+	}()
+}
+
+// call runs the job, turning a panic into an error so one bad job does not
+// take the whole scheduler down.
+func (s *Scheduler) call(j *jobState, planned time.Time) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrPanicked, r)
+		}
+	}()
+	return j.Run(s.ctx, planned)
+}
+
+// withJitter returns the job's planned time plus a random delay.
+// s.mu must be held (a *rand.Rand is not safe for concurrent use).
+func (s *Scheduler) withJitter(j *jobState) time.Time {
+	if j.planned.IsZero() || j.Jitter <= 0 {
+		return j.planned
+	}
+	var n int64
+	if s.opts.Rand != nil {
+		n = s.opts.Rand.Int64N(int64(j.Jitter))
+	} else {
+		n = rand.Int64N(int64(j.Jitter))
+	}
+	return j.planned.Add(time.Duration(n))
+}
+
+func (s *Scheduler) emit(e Event) {
+	if s.opts.OnEvent != nil {
+		s.opts.OnEvent(e)
+	}
+}
+
+// Stop stops scheduling new runs, drops queued ones and waits for running
+// jobs to finish. If ctx ends first, running jobs are cancelled and Stop
+// returns ctx.Err() without waiting for them to notice.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.stopOnce.Do(func() { close(s.stop) })
+
+	s.mu.Lock()
+	started := s.started
+	s.stopping = true
+	for _, j := range s.jobs {
+		if j.queued {
+			j.queued = false
+			s.emit(Event{Time: s.clock.Now(), Job: j.Name, Kind: Dropped, Planned: j.queuedAt})
+		}
+	}
+	s.mu.Unlock()
+	if started {
+		<-s.done
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		s.cancel()
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		return ctx.Err()
+	}
+}
+
+// ----------------------------------------------------------
+// 5. HELPERS
+// ----------------------------------------------------------
+
+// sleep waits d on clock, or until ctx is cancelled.
+func sleep(ctx context.Context, clock Clock, d time.Duration) error {
+	t := clock.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// settle waits until background goroutines have gone back to sleep on the
+// fake clock: the number of pending timers stops changing. Tests use the
+// same trick so that every Advance is fully processed before the next.
+func settle(clock *FakeClock) {
+	prev, stable := -1, 0
+	for stable < 3 {
+		time.Sleep(2 * time.Millisecond)
+		if n := clock.Waiters(); n == prev {
+			stable++
+		} else {
+			prev, stable = n, 0
+		}
+	}
+}
+
+// ----------------------------------------------------------
+// 6. DEMO
+// ----------------------------------------------------------
+
+func main() {
+	showNextRuns()
+	showDST()
+	showErrors()
+	runShop()
+}
+
+func showNextRuns() {
+	fmt.Println("=== Next runs ===")
+	ist, _ := time.LoadLocation("Asia/Kolkata")
+	from := time.Date(2025, 10, 17, 16, 47, 12, 0, ist) // a Friday afternoon
+
+	for _, expr := range []string{
+		"*/15 9-17 * * MON-FRI",                // stale-order sweep in office hours
+		"0 2 * * *",                            // nightly reconciliation
+		"0 0 1 * *",                            // subscription billing
+		"0 0 1,15 * SUN",                       // 1st, 15th OR any Sunday
+		"0 12 1 * */2",                         // the 1st AND a SUN/TUE/THU/SAT
+		"*/20 * * * * *",                       // six fields: every 20 seconds
+		"CRON_TZ=America/New_York 0 9 * * MON", // a US partner's Monday 9am
+		"@yearly",
+		"0 0 29 2 *",
+		"0 0 30 2 *",
+	} {
+		s := MustParse(expr, ist)
+		fmt.Printf("%-40s", expr)
+		t := from
+		for range 3 {
+			if t = s.Next(t); t.IsZero() {
+				fmt.Print("  never")
+				break
+			}
+			fmt.Print("  ", t.Format("Mon 02 Jan 2006 15:04:05 MST"))
+		}
+		fmt.Println()
+	}
+}
+
+func showDST() {
+	fmt.Println("\n=== Daylight saving (America/New_York) ===")
+	ny, _ := time.LoadLocation("America/New_York")
+	cases := []struct {
+		note string
+		expr string
+		from time.Time
+	}{
+		{"spring forward, 02:30 does not exist", "30 2 * * *", time.Date(2025, 3, 8, 12, 0, 0, 0, ny)},
+		{"spring forward, every 30 min", "*/30 * * * *", time.Date(2025, 3, 9, 1, 0, 0, 0, ny)},
+		{"fall back, 01:30 happens twice", "30 1 * * *", time.Date(2025, 11, 1, 12, 0, 0, 0, ny)},
+		{"fall back, every 30 min", "*/30 * * * *", time.Date(2025, 11, 2, 0, 30, 0, 0, ny)},
+	}
+	for _, c := range cases {
+		s := MustParse(c.expr, ny)
+		fmt.Printf("%s (%s)\n", c.note, c.expr)
+		t := c.from
+		for range 5 {
+			t = s.Next(t)
+			fmt.Printf("   %s   = %s UTC\n", t.Format("Mon 02 Jan 15:04 MST"), t.UTC().Format("15:04"))
+		}
+	}
+}
+
+func showErrors() {
+	fmt.Println("\n=== Bad expressions ===")
+	for _, expr := range []string{"61 * * * *", "* * *", "0 0 * * MON-SUNDAY", "*/0 * * * *", "5-1 * * * *", "CRON_TZ=Mars/Olympus 0 0 * * *", "TZ= 0 0 * * *"} {
+		_, err := Parse(expr, nil)
+		fmt.Printf("%-32s %v (is ErrSyntax: %t)\n", expr, err, errors.Is(err, ErrSyntax))
+	}
+}
+
+func runShop() {
+	fmt.Println("\n=== Scheduler with a fake clock ===")
+	ist, _ := time.LoadLocation("Asia/Kolkata")
+	start := time.Date(2025, 10, 17, 9, 59, 0, 0, ist)
+	clock := NewFakeClock(start)
+
+	var (
+		logMu sync.Mutex
+		log   []Event
+	)
+	sched := New(Options{
+		Clock: clock,
+		Rand:  rand.New(rand.NewPCG(7, 42)), // seeded: same jitter every run
+		OnEvent: func(e Event) {
+			logMu.Lock()
+			log = append(log, e)
+			logMu.Unlock()
+		},
+	})
+
+	// A slow job: takes 2m30s of (fake) time, every 2 minutes.
+	slow := func(ctx context.Context, _ time.Time) error {
+		return sleep(ctx, clock, 150*time.Second)
+	}
+	jobs := []Job{
+		{Name: "cancel-stale-orders", Spec: "* * * * *", Location: ist, Jitter: 10 * time.Second,
+			Run: func(ctx context.Context, planned time.Time) error { return nil }},
+		{Name: "reconcile", Spec: "*/2 * * * *", Location: ist, Overlap: Skip, Run: slow},
+		{Name: "billing", Spec: "*/2 * * * *", Location: ist, Overlap: Queue, Run: slow},
+		{Name: "export", Spec: "*/2 * * * *", Location: ist, Overlap: Concurrent, Run: slow},
+	}
+	order := map[string]int{}
+	for i, j := range jobs {
+		if err := sched.Add(j); err != nil {
+			fmt.Println("add:", err)
+			return
+		}
+		order[j.Name] = i
+	}
+	sched.Start()
+	settle(clock)
+
+	// Jump from timer to timer up to 10:07, letting the jobs react each time.
+	end := start.Add(8 * time.Minute)
+	for {
+		next, ok := clock.NextDeadline()
+		if !ok || next.After(end) {
+			break
+		}
+		clock.Advance(next.Sub(clock.Now()))
+		settle(clock)
+	}
+	clock.Advance(end.Sub(clock.Now()))
+	settle(clock)
+
+	// Graceful shutdown: billing finishes within the next 30s of fake time,
+	// export does not and is cancelled when the 200ms real deadline passes.
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	stopped := make(chan error, 1)
+	go func() { stopped <- sched.Stop(ctx) }()
+	settle(clock)
+	clock.Advance(30 * time.Second)
+	err := <-stopped
+	settle(clock)
+
+	logMu.Lock()
+	defer logMu.Unlock()
+	// Events of different jobs at the same instant arrive in any order;
+	// sort by time, then job, keeping each job's own order.
+	sort.SliceStable(log, func(a, b int) bool {
+		if !log[a].Time.Equal(log[b].Time) {
+			return log[a].Time.Before(log[b].Time)
+		}
+		return order[log[a].Job] < order[log[b].Job]
+	})
+	sweeps := 0
+	for _, e := range log {
+		if e.Job == "cancel-stale-orders" && e.Kind == Finished {
+			sweeps++
+		}
+		line := fmt.Sprintf("%s %-20s %-9s planned %s", e.Time.Format("15:04:05"), e.Job, e.Kind, e.Planned.Format("15:04"))
+		if e.Err != nil {
+			line += "  (" + e.Err.Error() + ")"
+		}
+		fmt.Println(line)
+	}
+	fmt.Printf("Stop at 10:07:00 with a 200ms deadline returned: %v\n", err)
+	fmt.Printf("stale-order sweeps run: %d\n", sweeps)
+}
+
+// ----------------------------------------------------------
+// SUMMARY
+// ----------------------------------------------------------
+// 1. A cron expression is parsed field by field into bitsets; a switch
+//    picks between *, a-b, */n and plain values, and names like MON/JAN.
+// 2. When both day fields are restricted, cron matches EITHER of them;
+//    when either starts with *, a day must match BOTH (as in Vixie cron).
+// 3. Next walks wall-clock time (stored as UTC, so no DST) and then maps
+//    the result into the real zone: a spring-forward gap moves fixed-time
+//    jobs to the end of the gap, a fall-back hour runs them once, and
+//    every-hour jobs follow real time through both.
+// 4. Overlap policies: Skip drops a run, Queue keeps at most one waiting,
+//    Concurrent starts it anyway. Jitter spreads jobs that share a time.
+// 5. Stop(ctx) is graceful until ctx ends, then cancels the jobs' context.
+// 6. Clock is an interface; with a FakeClock and a seeded *rand.Rand the
+//    whole schedule is deterministic, so tests never sleep.
+// ----------------------------------------------------------
@@ -0,0 +1,642 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"reflect"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// ----------------------------------------------------------
+// PRETTY PRINTER: A TYPE SWITCH FOR EVERY TYPE
+// ----------------------------------------------------------
+// 07-switch has whoAmI, a type switch that knows int, string and bool.
+// fmt.Println(order1) knows every type but prints {1 500000 Recieved
+// {...}} with no field names, and %+v still hides what is behind
+// pointers and interfaces.
+//
+// Dump is whoAmI grown up: instead of listing types, it switches on the
+// KIND (reflect.Kind — struct, pointer, slice, map, ...), which covers
+// every Go type there is. It shows:
+//   - type names, field names (unexported ones too) and pointer addresses;
+//   - nested values, indented; cycles are marked instead of looping;
+//   - a depth limit and an item limit for huge values;
+//   - sensitive fields (mobile, card, ...) masked;
+// and renders as plain text, colored text for terminals, or JSON.
+// ----------------------------------------------------------
+
+// Mode selects the output format.
+type Mode int
+
+const (
+	Text  Mode = iota // indented text
+	Color             // indented text with ANSI colors
+	JSON              // JSON, for logs
+)
+
+// Options configure Dump. The zero value is ready to use.
+type Options struct {
+	Mode          Mode
+	MaxDepth      int      // nesting shown before "…"; default 8
+	MaxItems      int      // slice/map elements shown; default 50
+	Mask          []string // field or map-key names to mask, any case
+	HideAddresses bool     // omit pointer addresses (stable output)
+}
+
+// ----------------------------------------------------------
+// 1. FROM VALUE TO TREE
+// ----------------------------------------------------------
+// Dump works in two steps: walk the value into a tree of nodes, then
+// render the tree. The walk — all the reflection — is shared by the
+// three output modes.
+
+// node is one value in the tree.
+type node struct {
+	name     string // field name, "[3]" or a quoted map key; "" for the root
+	key      string // the JSON member name
+	typ      string // Go type, e.g. *main.order
+	addr     string // pointer address, if the value was reached through one
+	meta     string // "len=2 cap=4"
+	note     string // "embedded", "masked", "cycle", ...
+	leaf     bool
+	text     string // leaf value as text: "Jhon" quoted, 42, nil
+	raw      any    // leaf value for JSON
+	brackets string // "{}" for structs and maps, "[]" for slices
+	children []*node
+}
+
+// walker holds the state of one Dump.
+type walker struct {
+	opts Options
+	mask map[string]bool
+	// path holds the pointers and maps on the way from the root to the
+	// current value. Seeing one again means a cycle. Values that are only
+	// shared (two fields pointing at one customer) are printed each time.
+	path map[visit]bool
+}
+
+// visit is one pointer or map on the path. The type is part of the key:
+// a struct and its first field share an address, and a pointer to that
+// field is not a way back to the struct.
+type visit struct {
+	addr uintptr
+	typ  reflect.Type
+}
+
+var (
+	stringerType = reflect.TypeFor[fmt.Stringer]()
+	errorType    = reflect.TypeFor[error]()
+)
+
+func (w *walker) walk(name, key string, v reflect.Value, depth int) *node {
+	n := &node{name: name, key: key}
+	if !v.IsValid() {
+		n.leaf, n.typ, n.text = true, "any", "nil"
+		return n
+	}
+	v = exported(v)
+	n.typ = strings.ReplaceAll(v.Type().String(), "interface {}", "any")
+
+	if w.mask[strings.ToLower(key)] {
+		n.leaf, n.note = true, "masked"
+		if v.Kind() == reflect.String {
+			n.text, n.raw = strconv.Quote(maskString(v.String())), maskString(v.String())
+		} else {
+			n.text, n.raw = "***", "***"
+		}
+		return n
+	}
+
+	// Types that describe themselves: time.Time, enums with String(),
+	// errors. Nil pointers are skipped: their methods would panic.
+	if v.Kind() != reflect.Interface && !(v.Kind() == reflect.Pointer && v.IsNil()) && v.CanInterface() {
+		s, method, panicked := describeSelf(v)
+		if panicked != nil {
+			// Show the raw value below, and say why there is no name.
+			n.note = fmt.Sprintf("%s panicked: %v", method, panicked)
+		}
+		if s != "" {
+			n.leaf, n.text, n.raw = true, s, s
+			switch { // an enum: show the number too
+			case v.CanInt():
+				n.text += " (" + strconv.FormatInt(v.Int(), 10) + ")"
+			case v.CanUint():
+				n.text += " (" + strconv.FormatUint(v.Uint(), 10) + ")"
+			}
+			if v.Kind() == reflect.Pointer {
+				n.addr = w.address(v.Pointer())
+			}
+			return n
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		n.leaf, n.text, n.raw = true, strconv.FormatBool(v.Bool()), v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n.leaf, n.text, n.raw = true, strconv.FormatInt(v.Int(), 10), v.Int()
+		if v.Kind() == reflect.Int32 && utf8.ValidRune(rune(v.Int())) && n.typ == "int32" {
+			n.note = strconv.QuoteRune(rune(v.Int())) // a rune: 97 is 'a'
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n.leaf, n.text, n.raw = true, strconv.FormatUint(v.Uint(), 10), v.Uint()
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		n.leaf, n.text, n.raw = true, strconv.FormatFloat(f, 'g', -1, v.Type().Bits()), f
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			n.raw = n.text // JSON has no NaN or Inf: write "NaN", "+Inf", "-Inf"
+		}
+	case reflect.Complex64, reflect.Complex128:
+		n.leaf, n.text = true, fmt.Sprint(v.Complex())
+		n.raw = n.text
+	case reflect.String:
+		n.leaf, n.text, n.raw = true, strconv.Quote(v.String()), v.String()
+
+	case reflect.Pointer:
+		if v.IsNil() {
+			n.leaf, n.text = true, "nil"
+			return n
+		}
+		addr := v.Pointer()
+		if w.path[visit{addr, v.Type()}] {
+			n.leaf, n.note, n.addr = true, "back to an enclosing value", w.address(addr)
+			n.text, n.raw = "<cycle>", "[cycle]"
+			return n
+		}
+		w.path[visit{addr, v.Type()}] = true
+		inner := w.walk(name, key, v.Elem(), depth)
+		delete(w.path, visit{addr, v.Type()})
+		inner.typ, inner.addr = n.typ, w.address(addr)
+		return inner
+
+	case reflect.Interface:
+		if v.IsNil() {
+			n.leaf, n.text = true, "nil"
+			return n
+		}
+		inner := w.walk(name, key, addressable(v.Elem()), depth)
+		inner.typ = n.typ + "(" + inner.typ + ")" // static(dynamic)
+		return inner
+
+	case reflect.Struct:
+		n.brackets = "{}"
+		if depth >= w.opts.MaxDepth {
+			return w.tooDeep(n)
+		}
+		t := v.Type()
+		for i := range t.NumField() {
+			f := t.Field(i)
+			child := w.walk(f.Name, f.Name, v.Field(i), depth+1)
+			if f.Anonymous && child.note == "" {
+				child.note = "embedded"
+			}
+			n.children = append(n.children, child)
+		}
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice {
+			if v.IsNil() {
+				n.leaf, n.text = true, "nil"
+				return n
+			}
+			n.meta = fmt.Sprintf("len=%d cap=%d", v.Len(), v.Cap())
+			if v.Type().Elem().Kind() == reflect.Uint8 { // []byte: show as text
+				n.leaf, n.text, n.raw = true, fmt.Sprintf("%q", v.Bytes()), v.Bytes()
+				return n
+			}
+		} else {
+			n.meta = fmt.Sprintf("len=%d", v.Len())
+		}
+		n.brackets = "[]"
+		if depth >= w.opts.MaxDepth {
+			return w.tooDeep(n)
+		}
+		for i := range min(v.Len(), w.opts.MaxItems) {
+			n.children = append(n.children, w.walk(fmt.Sprintf("[%d]", i), "", v.Index(i), depth+1))
+		}
+		w.more(n, v.Len())
+
+	case reflect.Map:
+		if v.IsNil() {
+			n.leaf, n.text = true, "nil"
+			return n
+		}
+		n.meta, n.brackets = fmt.Sprintf("len=%d", v.Len()), "{}"
+		addr := v.Pointer()
+		if w.path[visit{addr, v.Type()}] {
+			n.leaf, n.note, n.addr = true, "back to an enclosing value", w.address(addr)
+			n.text, n.raw = "<cycle>", "[cycle]"
+			return n
+		}
+		if depth >= w.opts.MaxDepth {
+			return w.tooDeep(n)
+		}
+		w.path[visit{addr, v.Type()}] = true
+		keys := v.MapKeys()
+		slices.SortFunc(keys, func(a, b reflect.Value) int { return cmp.Compare(fmt.Sprint(a), fmt.Sprint(b)) })
+		for _, k := range keys[:min(len(keys), w.opts.MaxItems)] {
+			key := fmt.Sprint(k)
+			name := key
+			if k.Kind() == reflect.String {
+				name = strconv.Quote(key)
+			}
+			n.children = append(n.children, w.walk(name, key, addressable(v.MapIndex(k)), depth+1))
+		}
+		delete(w.path, visit{addr, v.Type()})
+		w.more(n, v.Len())
+
+	case reflect.Func:
+		n.leaf = true
+		if v.IsNil() {
+			n.text = "nil"
+		} else {
+			n.text = runtime.FuncForPC(v.Pointer()).Name()
+			n.raw = n.text
+		}
+	default: // chan, unsafe.Pointer
+		n.leaf = true
+		if v.IsNil() {
+			n.text = "nil"
+		} else {
+			n.text = w.address(v.Pointer())
+			n.raw = n.text
+		}
+	}
+	return n
+}
+
+// describeSelf calls Error() or String() if v has one. These are user
+// code: an enum value out of range indexes past its names and panics, so
+// the panic is recovered and returned instead of crashing the dump.
+func describeSelf(v reflect.Value) (s, method string, panicked any) {
+	defer func() {
+		if r := recover(); r != nil {
+			s, panicked = "", r
+		}
+	}()
+	switch {
+	case v.Type().Implements(errorType):
+		method = "Error()"
+		s = v.Interface().(error).Error()
+	case v.Type().Implements(stringerType):
+		method = "String()"
+		s = v.Interface().(fmt.Stringer).String()
+	}
+	return s, method, nil
+}
+
+// tooDeep replaces a composite's contents with a marker.
+func (w *walker) tooDeep(n *node) *node {
+	n.leaf, n.note = true, "max depth"
+	n.text = n.brackets[:1] + "…" + n.brackets[1:]
+	n.raw = "[max depth]"
+	return n
+}
+
+// more adds a "… N more" marker when elements were cut off.
+func (w *walker) more(n *node, total int) {
+	if total > w.opts.MaxItems {
+		n.children = append(n.children, &node{leaf: true, note: fmt.Sprintf("%d more", total-w.opts.MaxItems), text: "…"})
+	}
+}
+
+func (w *walker) address(p uintptr) string {
+	if w.opts.HideAddresses {
+		return "0x…"
+	}
+	return fmt.Sprintf("%#x", p)
+}
+
+// maskString keeps the last four characters: "+91 7493957674" → "**********7674".
+func maskString(s string) string {
+	r := []rune(s)
+	keep := min(4, len(r)/3) // short values keep less
+	return strings.Repeat("*", len(r)-keep) + string(r[len(r)-keep:])
+}
+
+// exported returns a view of v that allows Interface(), needed to call
+// String() and Error() on values in unexported fields. This is the same
+// read-only unsafe view as in 16-structs/struct-diff. It needs v to be
+// addressable, which is why the walk copies values that are not (the
+// root, interface contents, map values) with addressable first.
+func exported(v reflect.Value) reflect.Value {
+	if v.CanInterface() || !v.CanAddr() {
+		return v // a non-addressable value is still readable via Int(), String(), ...
+	}
+	return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+}
+
+// addressable returns v, or an addressable copy of it when that is possible.
+func addressable(v reflect.Value) reflect.Value {
+	if v.CanAddr() || !v.CanInterface() {
+		return v
+	}
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+	return c
+}
+
+// ----------------------------------------------------------
+// 2. RENDERING
+// ----------------------------------------------------------
+
+// ANSI colors for the Color mode.
+const (
+	reset   = "\033[0m"
+	dim     = "\033[2m"
+	cyan    = "\033[36m"
+	green   = "\033[32m"
+	yellow  = "\033[33m"
+	red     = "\033[31m"
+	magenta = "\033[35m"
+)
+
+// textRenderer writes the tree as indented text, optionally colored.
+type textRenderer struct {
+	out   *strings.Builder
+	color bool
+}
+
+func (r textRenderer) paint(color, s string) string {
+	if !r.color || s == "" {
+		return s
+	}
+	return color + s + reset
+}
+
+func (r textRenderer) render(n *node, indent string) {
+	line := indent
+	if n.name != "" {
+		line += r.paint(cyan, n.name) + ": "
+	}
+	line += r.paint(dim, n.typ)
+	if n.addr != "" {
+		line += " " + r.paint(dim, "@"+n.addr)
+	}
+	if n.meta != "" {
+		line += " " + r.paint(dim, "("+n.meta+")")
+	}
+	if n.leaf {
+		if n.typ != "" {
+			line += " = "
+		}
+		line += r.paint(r.valueColor(n), n.text)
+	} else if len(n.children) == 0 {
+		line += " " + n.brackets
+	} else {
+		line += " " + n.brackets[:1]
+	}
+	if n.note != "" {
+		line += "  " + r.paint(magenta, "// "+n.note)
+	}
+	r.out.WriteString(line + "\n")
+	if n.leaf || len(n.children) == 0 {
+		return
+	}
+	for _, c := range n.children {
+		r.render(c, indent+"    ")
+	}
+	r.out.WriteString(indent + n.brackets[1:] + "\n")
+}
+
+func (r textRenderer) valueColor(n *node) string {
+	switch {
+	case n.note == "masked" || n.text == "<cycle>":
+		return red
+	case n.text == "nil":
+		return magenta
+	case strings.HasPrefix(n.text, `"`):
+		return green
+	}
+	return yellow
+}
+
+// toJSON converts the tree into values encoding/json can write. Structs
+// become ordered objects, so fields keep their declaration order.
+func toJSON(n *node) any {
+	if n.leaf {
+		return n.raw
+	}
+	if n.brackets == "[]" {
+		out := make([]any, 0, len(n.children))
+		for _, c := range n.children {
+			if c.note != "" && c.text == "…" {
+				out = append(out, "… "+c.note)
+				continue
+			}
+			out = append(out, toJSON(c))
+		}
+		return out
+	}
+	obj := orderedObject{}
+	for _, c := range n.children {
+		if c.text == "…" && c.key == "" {
+			obj = append(obj, member{"…", c.note})
+			continue
+		}
+		obj = append(obj, member{c.key, toJSON(c)})
+	}
+	return obj
+}
+
+// orderedObject is a JSON object that keeps its members in order.
+type orderedObject []member
+
+type member struct {
+	key   string
+	value any
+}
+
+func (o orderedObject) MarshalJSON() ([]byte, error) {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		k, _ := json.Marshal(m.key)
+		v, err := json.Marshal(m.value)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(k)
+		b.WriteByte(':')
+		b.Write(v)
+	}
+	b.WriteByte('}')
+	return []byte(b.String()), nil
+}
+
+// ----------------------------------------------------------
+// 3. API
+// ----------------------------------------------------------
+
+// Dump writes v to out in the chosen mode.
+func Dump(out io.Writer, v any, opts Options) error {
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = 8
+	}
+	if opts.MaxItems <= 0 {
+		opts.MaxItems = 50
+	}
+	w := &walker{opts: opts, mask: map[string]bool{}, path: map[visit]bool{}}
+	for _, m := range opts.Mask {
+		w.mask[strings.ToLower(m)] = true
+	}
+	var tree *node
+	if rv := reflect.ValueOf(v); rv.IsValid() {
+		tree = w.walk("", "", addressable(rv), 0)
+	} else {
+		tree = w.walk("", "", rv, 0)
+	}
+
+	if opts.Mode == JSON {
+		data, err := json.MarshalIndent(toJSON(tree), "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, string(data))
+		return err
+	}
+	var b strings.Builder
+	textRenderer{out: &b, color: opts.Mode == Color}.render(tree, "")
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+// Sdump returns what Dump would write.
+func Sdump(v any, opts Options) string {
+	var b strings.Builder
+	Dump(&b, v, opts) // a strings.Builder never fails
+	return b.String()
+}
+
+// ----------------------------------------------------------
+// MAIN
+// ----------------------------------------------------------
+
+// status is an enum with a String method, like 18-enums.
+type status int
+
+const (
+	received status = iota
+	confirmed
+	delivered
+)
+
+func (s status) String() string { return [...]string{"Recieved", "Confirmed", "Delivered"}[s] }
+
+type customer struct {
+	name   string
+	mobile string
+}
+
+type payment struct {
+	method string
+	card   string
+}
+
+type order struct {
+	id        string
+	amount    float32
+	status    status
+	createdAt time.Time
+	customer
+	items   []string
+	tags    map[string]string
+	payment any
+	coupon  *string
+	related *order
+}
+
+func main() {
+	order1 := &order{
+		id: "1", amount: 500000, status: confirmed,
+		createdAt: time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC),
+		customer:  customer{name: "Jhon", mobile: "+91 7493957674"},
+		items:     []string{"Paneer Tikka", "Naan"},
+		tags:      map[string]string{"channel": "web", "priority": "high"},
+		payment:   payment{method: "card", card: "4111 1111 1111 1111"},
+	}
+	order1.related = order1 // a cycle: fmt's %v would print the address only
+
+	fmt.Println("fmt.Println:", *order1)
+	fmt.Println()
+	fmt.Printf("fmt %%+v:    %+v\n", *order1)
+	fmt.Println()
+
+	opts := Options{Mask: []string{"mobile", "card"}}
+	if len(os.Args) > 1 && os.Args[1] == "-color" {
+		opts.Mode = Color
+	}
+	fmt.Println("Dump:")
+	Dump(os.Stdout, order1, opts)
+	fmt.Println()
+
+	// The same value for a log pipeline; addresses hidden so lines
+	// compare equal between runs.
+	fmt.Println("Dump as JSON:")
+	Dump(os.Stdout, order1, Options{Mode: JSON, Mask: []string{"mobile", "card"}, HideAddresses: true})
+	fmt.Println()
+
+	// Limits: a deep chain and a long slice.
+	type link struct {
+		n    int
+		next *link
+	}
+	var chain *link
+	for i := range 10 {
+		chain = &link{i, chain}
+	}
+	fmt.Println("MaxDepth 3:")
+	fmt.Print(Sdump(chain, Options{MaxDepth: 3, HideAddresses: true}))
+	fmt.Println("MaxItems 3:")
+	fmt.Print(Sdump([]int{1, 2, 3, 4, 5, 6, 7}, Options{MaxItems: 3}))
+	fmt.Println()
+
+	// A pointer to a struct's first field has the struct's address, but it
+	// is not a cycle: the field is printed in full.
+	type inner struct{ n int }
+	type outer struct {
+		in  inner
+		ref *inner
+	}
+	o := &outer{in: inner{7}}
+	o.ref = &o.in
+	fmt.Println("Pointer to the first field:")
+	fmt.Print(Sdump(o, Options{HideAddresses: true}))
+	fmt.Println()
+
+	// whoAmI's values, for comparison.
+	for _, v := range []any{42, "Yaswanth", true, 'a', nil, fmt.Errorf("card declined"), main} {
+		fmt.Print(Sdump(v, Options{}))
+	}
+	fmt.Println()
+
+	// Values that break naive printers: a String method that panics on an
+	// out-of-range enum, and floats JSON cannot represent.
+	fmt.Print(Sdump(status(7), Options{}))
+	fmt.Print(Sdump([]float64{1.5, math.NaN(), math.Inf(-1)}, Options{Mode: JSON}))
+	fmt.Println()
+	fmt.Println("Run with -color for terminal colors.")
+}
+
+// ----------------------------------------------------------
+// SUMMARY
+// ----------------------------------------------------------
+// 1. A type switch lists types; a switch on reflect.Kind covers them all.
+// 2. Walk once into a tree, render many ways (text, color, JSON).
+// 3. Pointers and maps on the current path detect cycles.
+// 4. Depth and item limits keep huge values printable.
+// 5. Masking by field name keeps secrets out of logs.
+// ----------------------------------------------------------
@@ -0,0 +1,650 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"math/cmplx"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ----------------------------------------------------------
+// A GENERIC MATRIX
+// ----------------------------------------------------------
+// 08-arrays shows a 2D array literal, [2][2]int{{1, 2}, {3, 4}}, but
+// nothing to compute with it. This program builds a dense Matrix[T] that
+// works for integers, floats and complex numbers:
+//
+//   - Storage: ONE slice in row-major order (row 0, then row 1, ...),
+//     like an array's contiguous memory. Element (i, j) is data[i*cols+j].
+//   - Operations: transpose, add, subtract, scale, multiply, determinant,
+//     inverse and solving A·x = b with an LU decomposition.
+//   - Safety: wrong sizes return errors (ErrDimension, ErrIndex) instead
+//     of "index out of range" panics.
+//   - Speed: multiplication is blocked (tiled) so the data being worked on
+//     stays in the CPU cache. Run `go run main.go -bench` to compare.
+// ----------------------------------------------------------
+
+// Number is every element type a Matrix supports. The ~ allows named
+// types such as `type Rupees int64`.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~float32 | ~float64 | ~complex64 | ~complex128
+}
+
+// Matrix is a rows×cols matrix stored row-major.
+type Matrix[T Number] struct {
+	rows, cols int
+	data       []T
+}
+
+var (
+	ErrDimension = errors.New("dimension mismatch")
+	ErrIndex     = errors.New("index out of range")
+	ErrSingular  = errors.New("matrix is singular")
+	ErrInteger   = errors.New("operation needs float or complex elements")
+)
+
+// ----------------------------------------------------------
+// CONSTRUCTION
+// ----------------------------------------------------------
+
+// New creates a rows×cols zero matrix.
+func New[T Number](rows, cols int) (*Matrix[T], error) {
+	if rows < 0 || cols < 0 {
+		return nil, fmt.Errorf("%w: negative size %d×%d", ErrDimension, rows, cols)
+	}
+	return &Matrix[T]{rows: rows, cols: cols, data: make([]T, rows*cols)}, nil
+}
+
+// FromRows copies nested slices. Every row must have the same length.
+func FromRows[T Number](rows [][]T) (*Matrix[T], error) {
+	cols := 0
+	if len(rows) > 0 {
+		cols = len(rows[0])
+	}
+	m, _ := New[T](len(rows), cols)
+	for i, row := range rows {
+		if len(row) != cols {
+			return nil, fmt.Errorf("%w: row %d has %d columns, row 0 has %d", ErrDimension, i, len(row), cols)
+		}
+		copy(m.data[i*cols:], row)
+	}
+	return m, nil
+}
+
+// Identity creates the n×n identity matrix.
+func Identity[T Number](n int) (*Matrix[T], error) {
+	m, err := New[T](n, n)
+	if err != nil {
+		return nil, err
+	}
+	for i := range n {
+		m.data[i*n+i] = 1
+	}
+	return m, nil
+}
+
+// Map converts every element, e.g. an int matrix to float64.
+func Map[T, U Number](m *Matrix[T], f func(T) U) *Matrix[U] {
+	out, _ := New[U](m.rows, m.cols)
+	for i, v := range m.data {
+		out.data[i] = f(v)
+	}
+	return out
+}
+
+// Dims returns the number of rows and columns.
+func (m *Matrix[T]) Dims() (rows, cols int) { return m.rows, m.cols }
+
+// At returns element (i, j).
+func (m *Matrix[T]) At(i, j int) (T, error) {
+	if i < 0 || i >= m.rows || j < 0 || j >= m.cols {
+		var zero T
+		return zero, fmt.Errorf("%w: (%d, %d) in a %d×%d matrix", ErrIndex, i, j, m.rows, m.cols)
+	}
+	return m.data[i*m.cols+j], nil
+}
+
+// Set stores v at (i, j).
+func (m *Matrix[T]) Set(i, j int, v T) error {
+	if i < 0 || i >= m.rows || j < 0 || j >= m.cols {
+		return fmt.Errorf("%w: (%d, %d) in a %d×%d matrix", ErrIndex, i, j, m.rows, m.cols)
+	}
+	m.data[i*m.cols+j] = v
+	return nil
+}
+
+// Rows copies the matrix back into nested slices.
+func (m *Matrix[T]) Rows() [][]T {
+	out := make([][]T, m.rows)
+	for i := range out {
+		out[i] = append([]T(nil), m.data[i*m.cols:(i+1)*m.cols]...)
+	}
+	return out
+}
+
+// ----------------------------------------------------------
+// ELEMENT-WISE OPERATIONS
+// ----------------------------------------------------------
+
+// Transpose swaps rows and columns.
+func (m *Matrix[T]) Transpose() *Matrix[T] {
+	t, _ := New[T](m.cols, m.rows)
+	for i := range m.rows {
+		for j := range m.cols {
+			t.data[j*m.rows+i] = m.data[i*m.cols+j]
+		}
+	}
+	return t
+}
+
+func (m *Matrix[T]) sameShape(op string, o *Matrix[T]) error {
+	if m.rows != o.rows || m.cols != o.cols {
+		return fmt.Errorf("%w: cannot %s %d×%d and %d×%d", ErrDimension, op, m.rows, m.cols, o.rows, o.cols)
+	}
+	return nil
+}
+
+// Add returns m + o.
+func (m *Matrix[T]) Add(o *Matrix[T]) (*Matrix[T], error) {
+	if err := m.sameShape("add", o); err != nil {
+		return nil, err
+	}
+	out, _ := New[T](m.rows, m.cols)
+	for i := range m.data {
+		out.data[i] = m.data[i] + o.data[i]
+	}
+	return out, nil
+}
+
+// Sub returns m - o.
+func (m *Matrix[T]) Sub(o *Matrix[T]) (*Matrix[T], error) {
+	if err := m.sameShape("subtract", o); err != nil {
+		return nil, err
+	}
+	out, _ := New[T](m.rows, m.cols)
+	for i := range m.data {
+		out.data[i] = m.data[i] - o.data[i]
+	}
+	return out, nil
+}
+
+// Scale returns k·m.
+func (m *Matrix[T]) Scale(k T) *Matrix[T] {
+	out, _ := New[T](m.rows, m.cols)
+	for i, v := range m.data {
+		out.data[i] = k * v
+	}
+	return out
+}
+
+// ----------------------------------------------------------
+// MULTIPLICATION
+// ----------------------------------------------------------
+
+// blockSize is the tile edge: three 64×64 float64 tiles are 96 KB, which
+// fits in a typical L2 cache.
+const blockSize = 64
+
+// Mul returns m·o using blocked multiplication.
+//
+// The textbook loop computes c[i][j] as a dot product of row i of m and
+// column j of o — walking down a column jumps cols elements each step,
+// so almost every access misses the cache for large matrices. Instead we
+// (1) order the loops i, k, j so the innermost loop walks rows of o and c
+// contiguously, and (2) work tile by tile so the tiles being combined stay
+// in the cache while they are reused.
+func (m *Matrix[T]) Mul(o *Matrix[T]) (*Matrix[T], error) {
+	if m.cols != o.rows {
+		return nil, fmt.Errorf("%w: cannot multiply %d×%d by %d×%d (inner sizes %d and %d differ)",
+			ErrDimension, m.rows, m.cols, o.rows, o.cols, m.cols, o.rows)
+	}
+	n, inner, p := m.rows, m.cols, o.cols
+	c, _ := New[T](n, p)
+	for ii := 0; ii < n; ii += blockSize {
+		for kk := 0; kk < inner; kk += blockSize {
+			for jj := 0; jj < p; jj += blockSize {
+				for i := ii; i < min(ii+blockSize, n); i++ {
+					cRow := c.data[i*p : (i+1)*p]
+					mRow := m.data[i*inner : (i+1)*inner]
+					for k := kk; k < min(kk+blockSize, inner); k++ {
+						a := mRow[k]
+						oRow := o.data[k*p : (k+1)*p]
+						for j := jj; j < min(jj+blockSize, p); j++ {
+							cRow[j] += a * oRow[j]
+						}
+					}
+				}
+			}
+		}
+	}
+	return c, nil
+}
+
+// mulNaive is the textbook triple loop, kept for the benchmark.
+func (m *Matrix[T]) mulNaive(o *Matrix[T]) *Matrix[T] {
+	c, _ := New[T](m.rows, o.cols)
+	for i := range m.rows {
+		for j := range o.cols {
+			var sum T
+			for k := range m.cols {
+				sum += m.data[i*m.cols+k] * o.data[k*o.cols+j]
+			}
+			c.data[i*o.cols+j] = sum
+		}
+	}
+	return c
+}
+
+// ----------------------------------------------------------
+// DETERMINANT, LU, SOLVE, INVERSE
+// ----------------------------------------------------------
+
+// isInteger reports whether T is an integer type. Named types (~int) are
+// covered because we look at the underlying Kind, not the type itself.
+func isInteger[T Number]() bool {
+	switch reflect.TypeFor[T]().Kind() {
+	case reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return false
+	}
+	return true
+}
+
+// magnitude is |v| as a float64, used to pick pivots.
+func magnitude[T Number](v T) float64 {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Complex64, reflect.Complex128:
+		return cmplx.Abs(rv.Complex())
+	case reflect.Float32, reflect.Float64:
+		return math.Abs(rv.Float())
+	}
+	return math.Abs(float64(rv.Int()))
+}
+
+func (m *Matrix[T]) square(op string) error {
+	if m.rows != m.cols {
+		return fmt.Errorf("%w: %s needs a square matrix, got %d×%d", ErrDimension, op, m.rows, m.cols)
+	}
+	return nil
+}
+
+// Det returns the determinant. Integer matrices use the Bareiss algorithm,
+// whose divisions are always exact, so the result has no rounding error.
+// Float and complex matrices use the LU decomposition.
+func (m *Matrix[T]) Det() (T, error) {
+	if err := m.square("determinant"); err != nil {
+		return 0, err
+	}
+	if m.rows == 0 {
+		return 1, nil
+	}
+	if isInteger[T]() {
+		return m.detBareiss(), nil
+	}
+	lu, err := m.LU()
+	if errors.Is(err, ErrSingular) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return lu.Det(), nil
+}
+
+// detBareiss is fraction-free Gaussian elimination: every intermediate
+// value is itself a determinant of a sub-matrix, so it is an integer.
+func (m *Matrix[T]) detBareiss() T {
+	n := m.rows
+	a := append([]T(nil), m.data...)
+	sign, prev := T(1), T(1)
+	for k := 0; k < n-1; k++ {
+		if a[k*n+k] == 0 { // swap in a row with a non-zero pivot
+			swap := -1
+			for r := k + 1; r < n; r++ {
+				if a[r*n+k] != 0 {
+					swap = r
+					break
+				}
+			}
+			if swap < 0 {
+				return 0
+			}
+			for j := range n {
+				a[k*n+j], a[swap*n+j] = a[swap*n+j], a[k*n+j]
+			}
+			sign = -sign
+		}
+		for i := k + 1; i < n; i++ {
+			for j := k + 1; j < n; j++ {
+				a[i*n+j] = (a[i*n+j]*a[k*n+k] - a[i*n+k]*a[k*n+j]) / prev
+			}
+		}
+		prev = a[k*n+k]
+	}
+	return sign * a[n*n-1]
+}
+
+// LU is a decomposition P·A = L·U: L is lower triangular with ones on the
+// diagonal, U is upper triangular and P is a row permutation. Both L and
+// U are stored in one matrix (L below the diagonal, U on and above).
+type LU[T Number] struct {
+	lu   *Matrix[T]
+	perm []int // row i of P·A is row perm[i] of A
+	sign T     // +1 or -1: the parity of the permutation
+}
+
+// LU decomposes m with partial pivoting: in each column the row with the
+// largest magnitude is used as the pivot, which keeps rounding errors small.
+func (m *Matrix[T]) LU() (*LU[T], error) {
+	if isInteger[T]() {
+		return nil, fmt.Errorf("%w: LU of an integer matrix (convert with Map first)", ErrInteger)
+	}
+	if err := m.square("LU"); err != nil {
+		return nil, err
+	}
+	n := m.rows
+	a := &Matrix[T]{rows: n, cols: n, data: append([]T(nil), m.data...)}
+	perm := make([]int, n)
+	for i := range perm {
+		perm[i] = i
+	}
+	sign := T(1)
+
+	// Tolerance for "is this pivot zero?", relative to the pivot's own
+	// column: a tolerance from the whole matrix would call diag(1, 1e-13)
+	// singular just because its columns have very different scales.
+	tol := make([]float64, n)
+	for k := range n {
+		for i := range n {
+			tol[k] = math.Max(tol[k], magnitude(m.data[i*n+k]))
+		}
+		tol[k] *= float64(n) * 1e-12
+	}
+
+	for k := range n {
+		p := k
+		for i := k + 1; i < n; i++ {
+			if magnitude(a.data[i*n+k]) > magnitude(a.data[p*n+k]) {
+				p = i
+			}
+		}
+		if magnitude(a.data[p*n+k]) <= tol[k] {
+			return nil, fmt.Errorf("%w: no pivot in column %d", ErrSingular, k)
+		}
+		if p != k {
+			for j := range n {
+				a.data[k*n+j], a.data[p*n+j] = a.data[p*n+j], a.data[k*n+j]
+			}
+			perm[k], perm[p] = perm[p], perm[k]
+			sign = -sign
+		}
+		for i := k + 1; i < n; i++ {
+			f := a.data[i*n+k] / a.data[k*n+k]
+			a.data[i*n+k] = f // store the L factor below the diagonal
+			for j := k + 1; j < n; j++ {
+				a.data[i*n+j] -= f * a.data[k*n+j]
+			}
+		}
+	}
+	return &LU[T]{lu: a, perm: perm, sign: sign}, nil
+}
+
+// Det is the product of U's diagonal, with the permutation's sign.
+func (d *LU[T]) Det() T {
+	det := d.sign
+	for i := range d.lu.rows {
+		det *= d.lu.data[i*d.lu.cols+i]
+	}
+	return det
+}
+
+// Solve returns x with A·x = b, using forward then back substitution.
+func (d *LU[T]) Solve(b []T) ([]T, error) {
+	n := d.lu.rows
+	if len(b) != n {
+		return nil, fmt.Errorf("%w: right-hand side has %d values, matrix has %d rows", ErrDimension, len(b), n)
+	}
+	x := make([]T, n)
+	for i := range n { // L·y = P·b
+		sum := b[d.perm[i]]
+		for j := range i {
+			sum -= d.lu.data[i*n+j] * x[j]
+		}
+		x[i] = sum
+	}
+	for i := n - 1; i >= 0; i-- { // U·x = y
+		sum := x[i]
+		for j := i + 1; j < n; j++ {
+			sum -= d.lu.data[i*n+j] * x[j]
+		}
+		x[i] = sum / d.lu.data[i*n+i]
+	}
+	return x, nil
+}
+
+// Solve solves m·x = b.
+func (m *Matrix[T]) Solve(b []T) ([]T, error) {
+	lu, err := m.LU()
+	if err != nil {
+		return nil, err
+	}
+	return lu.Solve(b)
+}
+
+// Inverse returns m⁻¹ by solving m·x = e_j for every column e_j of I.
+func (m *Matrix[T]) Inverse() (*Matrix[T], error) {
+	lu, err := m.LU()
+	if err != nil {
+		return nil, err
+	}
+	n := m.rows
+	inv, _ := New[T](n, n)
+	e := make([]T, n)
+	for j := range n {
+		clear(e)
+		e[j] = 1
+		col, _ := lu.Solve(e)
+		for i := range n {
+			inv.data[i*n+j] = col[i]
+		}
+	}
+	return inv, nil
+}
+
+// ApproxEqual reports whether every element differs by at most tol.
+func (m *Matrix[T]) ApproxEqual(o *Matrix[T], tol float64) bool {
+	if m.sameShape("compare", o) != nil {
+		return false
+	}
+	for i := range m.data {
+		if magnitude(m.data[i]-o.data[i]) > tol {
+			return false
+		}
+	}
+	return true
+}
+
+// ----------------------------------------------------------
+// PRETTY PRINTING
+// ----------------------------------------------------------
+
+// String draws the matrix with aligned columns:
+//
+//	⎡ 1  2 ⎤
+//	⎣ 3  4 ⎦
+func (m *Matrix[T]) String() string {
+	if m.rows == 0 || m.cols == 0 {
+		return fmt.Sprintf("[%d×%d]", m.rows, m.cols)
+	}
+	cells := make([]string, len(m.data))
+	widths := make([]int, m.cols)
+	for i, v := range m.data {
+		cells[i] = formatNumber(v)
+		widths[i%m.cols] = max(widths[i%m.cols], len(cells[i]))
+	}
+	var b strings.Builder
+	for i := range m.rows {
+		left, right := "⎢", "⎥"
+		switch {
+		case m.rows == 1:
+			left, right = "[", "]"
+		case i == 0:
+			left, right = "⎡", "⎤"
+		case i == m.rows-1:
+			left, right = "⎣", "⎦"
+		}
+		b.WriteString(left)
+		for j := range m.cols {
+			fmt.Fprintf(&b, " %*s", widths[j], cells[i*m.cols+j])
+		}
+		b.WriteString(" " + right)
+		if i < m.rows-1 {
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// formatNumber prints floats with up to 6 significant digits and hides
+// -0 and tiny rounding noise like 1e-17.
+func formatNumber[T Number](v T) string {
+	clean := func(f float64) float64 {
+		if math.Abs(f) < 1e-12 {
+			return 0
+		}
+		return f
+	}
+	switch x := any(v).(type) {
+	case float64:
+		return fmt.Sprintf("%.6g", clean(x))
+	case float32:
+		return fmt.Sprintf("%.6g", clean(float64(x)))
+	case complex128:
+		return fmt.Sprintf("%.4g", complex(clean(real(x)), clean(imag(x))))
+	case complex64:
+		return fmt.Sprintf("%.4g", complex(clean(float64(real(x))), clean(float64(imag(x)))))
+	}
+	return fmt.Sprint(v)
+}
+
+// ----------------------------------------------------------
+// BENCHMARK
+// ----------------------------------------------------------
+
+func runBenchmarks() {
+	for _, n := range []int{128, 512} {
+		a, _ := New[float64](n, n)
+		b, _ := New[float64](n, n)
+		for i := range a.data {
+			a.data[i] = float64(i % 7)
+			b.data[i] = float64(i % 5)
+		}
+		naive := testing.Benchmark(func(tb *testing.B) {
+			for tb.Loop() {
+				a.mulNaive(b)
+			}
+		})
+		blocked := testing.Benchmark(func(tb *testing.B) {
+			for tb.Loop() {
+				a.Mul(b)
+			}
+		})
+		fmt.Printf("%d×%d  naive %12v/op   blocked %12v/op   speed-up %.1fx\n", n, n,
+			time.Duration(naive.NsPerOp()), time.Duration(blocked.NsPerOp()),
+			float64(naive.NsPerOp())/float64(blocked.NsPerOp()))
+	}
+}
+
+func main() {
+	bench := flag.Bool("bench", false, "compare naive and blocked multiplication")
+	flag.Parse()
+	if *bench {
+		runBenchmarks()
+		return
+	}
+
+	// 1. The 2D array from 08-arrays, as a Matrix.
+	arr := [2][2]int{{1, 2}, {3, 4}}
+	a, _ := FromRows([][]int{arr[0][:], arr[1][:]})
+	fmt.Println("A =")
+	fmt.Println(a)
+	fmt.Println("Aᵀ =")
+	fmt.Println(a.Transpose())
+	id2, _ := Identity[int](2)
+	sum, _ := a.Add(id2)
+	fmt.Println("A + I =")
+	fmt.Println(sum)
+	fmt.Println("3·A =")
+	fmt.Println(a.Scale(3))
+	prod, _ := a.Mul(a.Transpose())
+	fmt.Println("A·Aᵀ =")
+	fmt.Println(prod)
+	det, _ := a.Det()
+	fmt.Println("det(A) =", det) // -2, exact (Bareiss)
+	fmt.Println()
+
+	// 2. Solving a linear system:
+	//     2x +  y -  z =   8
+	//    -3x -  y + 2z = -11
+	//    -2x +  y + 2z =  -3
+	sys, _ := FromRows([][]float64{{2, 1, -1}, {-3, -1, 2}, {-2, 1, 2}})
+	x, err := sys.Solve([]float64{8, -11, -3})
+	fmt.Printf("Solution x, y, z = %.4g (err: %v)\n", x, err) // [2 3 -1]
+	inv, _ := sys.Inverse()
+	fmt.Println("Inverse =")
+	fmt.Println(inv)
+	check, _ := sys.Mul(inv)
+	id3, _ := Identity[float64](3)
+	fmt.Println("A·A⁻¹ ≈ I:", check.ApproxEqual(id3, 1e-9))
+	tiny, _ := FromRows([][]float64{{1, 0}, {0, 1e-13}})
+	tinyDet, err := tiny.Det()
+	fmt.Println("det(diag(1, 1e-13)) =", tinyDet, err) // small columns are not singular
+	fmt.Println()
+
+	// 3. Integers → floats with Map, and complex matrices.
+	fa := Map(a, func(v int) float64 { return float64(v) })
+	fInv, _ := fa.Inverse()
+	fmt.Println("A⁻¹ (as float64) =")
+	fmt.Println(fInv)
+	c, _ := FromRows([][]complex128{{1 + 1i, 2}, {3, 4 - 1i}})
+	cDet, _ := c.Det()
+	fmt.Println("Complex C =")
+	fmt.Println(c)
+	fmt.Println("det(C) =", formatNumber(cDet)) // (1+i)(4-i) - 6 = (-1+3i)
+	fmt.Println()
+
+	// 4. Errors instead of panics.
+	_, err = FromRows([][]int{{1, 2}, {3}})
+	fmt.Println("Ragged rows:  ", err)
+	wide, _ := New[int](2, 3)
+	_, err = wide.Mul(wide)
+	fmt.Println("2×3 · 2×3:    ", err)
+	_, err = a.At(2, 0)
+	fmt.Println("At(2, 0):     ", err)
+	_, err = a.Inverse()
+	fmt.Println("Int inverse:  ", err)
+	singular, _ := FromRows([][]float64{{1, 2}, {2, 4}})
+	_, err = singular.Inverse()
+	fmt.Println("Singular:     ", err)
+	_, err = Identity[int](-1)
+	fmt.Println("Identity(-1): ", err)
+	fmt.Println()
+	fmt.Println("Run with -bench to compare naive and blocked multiplication.")
+}
+
+// ----------------------------------------------------------
+// SUMMARY
+// ----------------------------------------------------------
+// 1. A matrix is one contiguous slice plus its shape — like an array.
+// 2. One generic type serves int, float and complex elements; reflection
+//    on the type parameter picks exact (Bareiss) or LU algorithms.
+// 3. Loop order and tiling decide how well a multiplication uses the cache.
+// 4. LU with partial pivoting gives determinant, Solve and Inverse.
+// 5. Shape problems are reported as errors wrapping ErrDimension/ErrIndex.
+// ----------------------------------------------------------
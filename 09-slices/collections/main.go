@@ -0,0 +1,632 @@
+package main
+
+import (
+	"cmp"
+	"flag"
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// ----------------------------------------------------------
+// GENERIC COLLECTIONS
+// ----------------------------------------------------------
+// 09-slices and 10-maps cover the two built-in collections. Real code
+// keeps re-building the same few structures on top of them:
+//
+//   - Set[T]            "is this id in the list?" without a loop.
+//   - OrderedMap[K, V]  a map that remembers insertion order (maps don't).
+//   - Deque[T]          a queue with cheap push/pop at BOTH ends
+//     (q = q[1:] leaks memory; prepend copies everything).
+//   - PriorityQueue[T]  always pop the most urgent item first.
+//
+// All four are generic, and all of them can be used with `for range`
+// thanks to iterators (Go 1.23): a method returning iter.Seq[T] is a
+// function that calls `yield` once per element.
+//
+//	for id := range ids.All() { ... }
+//
+// Run `go run main.go -bench` to compare each one with the naive slice or
+// map version using testing.Benchmark.
+// ----------------------------------------------------------
+
+// ----------------------------------------------------------
+// 1. SET
+// ----------------------------------------------------------
+
+// Set is an unordered collection of unique values, backed by a map whose
+// values take no memory (struct{}).
+type Set[T comparable] struct {
+	m map[T]struct{}
+}
+
+// NewSet creates a set containing items.
+func NewSet[T comparable](items ...T) *Set[T] {
+	s := &Set[T]{m: make(map[T]struct{}, len(items))}
+	for _, it := range items {
+		s.Add(it)
+	}
+	return s
+}
+
+// Add inserts v and reports whether it was new.
+func (s *Set[T]) Add(v T) bool {
+	if s.m == nil {
+		s.m = map[T]struct{}{}
+	}
+	if _, ok := s.m[v]; ok {
+		return false
+	}
+	s.m[v] = struct{}{}
+	return true
+}
+
+// Remove deletes v and reports whether it was present.
+func (s *Set[T]) Remove(v T) bool {
+	_, ok := s.m[v]
+	delete(s.m, v)
+	return ok
+}
+
+// Has reports whether v is in the set.
+func (s *Set[T]) Has(v T) bool {
+	_, ok := s.m[v]
+	return ok
+}
+
+// Len returns the number of elements.
+func (s *Set[T]) Len() int { return len(s.m) }
+
+// All iterates over the elements in no particular order.
+func (s *Set[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for v := range s.m {
+			if !yield(v) {
+				return // the loop body executed `break`
+			}
+		}
+	}
+}
+
+// Union returns the elements in s or other.
+func (s *Set[T]) Union(other *Set[T]) *Set[T] {
+	out := NewSet[T]()
+	for v := range s.All() {
+		out.Add(v)
+	}
+	for v := range other.All() {
+		out.Add(v)
+	}
+	return out
+}
+
+// Intersection returns the elements in both s and other.
+func (s *Set[T]) Intersection(other *Set[T]) *Set[T] {
+	small, big := s, other
+	if small.Len() > big.Len() {
+		small, big = big, small // loop over the smaller set
+	}
+	out := NewSet[T]()
+	for v := range small.All() {
+		if big.Has(v) {
+			out.Add(v)
+		}
+	}
+	return out
+}
+
+// Difference returns the elements in s that are not in other.
+func (s *Set[T]) Difference(other *Set[T]) *Set[T] {
+	out := NewSet[T]()
+	for v := range s.All() {
+		if !other.Has(v) {
+			out.Add(v)
+		}
+	}
+	return out
+}
+
+// IsSubset reports whether every element of s is in other.
+func (s *Set[T]) IsSubset(other *Set[T]) bool {
+	if s.Len() > other.Len() {
+		return false
+	}
+	for v := range s.All() {
+		if !other.Has(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// Sorted returns the elements of an ordered set in ascending order
+// (a free function, because methods cannot add the cmp.Ordered constraint).
+func Sorted[T cmp.Ordered](s *Set[T]) []T {
+	return slices.Sorted(s.All())
+}
+
+// ----------------------------------------------------------
+// 2. ORDERED MAP
+// ----------------------------------------------------------
+
+// OrderedMap is a map that iterates in insertion order. A built-in map
+// gives O(1) lookup; a doubly linked list of entries keeps the order and
+// makes Delete O(1) too (no searching a keys slice). The zero value is an
+// empty map, ready to use.
+type OrderedMap[K comparable, V any] struct {
+	m          map[K]*omEntry[K, V]
+	head, tail *omEntry[K, V]
+}
+
+type omEntry[K comparable, V any] struct {
+	key        K
+	val        V
+	prev, next *omEntry[K, V]
+}
+
+// NewOrderedMap creates an empty ordered map.
+func NewOrderedMap[K comparable, V any]() *OrderedMap[K, V] {
+	return &OrderedMap[K, V]{m: map[K]*omEntry[K, V]{}}
+}
+
+// Set stores v under k. Updating an existing key keeps its position.
+func (om *OrderedMap[K, V]) Set(k K, v V) {
+	if om.m == nil {
+		om.m = map[K]*omEntry[K, V]{}
+	}
+	if e, ok := om.m[k]; ok {
+		e.val = v
+		return
+	}
+	e := &omEntry[K, V]{key: k, val: v, prev: om.tail}
+	if om.tail != nil {
+		om.tail.next = e
+	} else {
+		om.head = e
+	}
+	om.tail = e
+	om.m[k] = e
+}
+
+// Get returns the value for k.
+func (om *OrderedMap[K, V]) Get(k K) (V, bool) {
+	if e, ok := om.m[k]; ok {
+		return e.val, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Delete removes k and reports whether it was present.
+func (om *OrderedMap[K, V]) Delete(k K) bool {
+	e, ok := om.m[k]
+	if !ok {
+		return false
+	}
+	if e.prev != nil {
+		e.prev.next = e.next
+	} else {
+		om.head = e.next
+	}
+	if e.next != nil {
+		e.next.prev = e.prev
+	} else {
+		om.tail = e.prev
+	}
+	delete(om.m, k)
+	return true
+}
+
+// Len returns the number of entries.
+func (om *OrderedMap[K, V]) Len() int { return len(om.m) }
+
+// All iterates over key/value pairs in insertion order. It is an
+// iter.Seq2, so it ranges with two variables: for k, v := range om.All().
+func (om *OrderedMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for e := om.head; e != nil; {
+			next := e.next // read first, so the loop body may Delete(k)
+			if !yield(e.key, e.val) {
+				return
+			}
+			e = next
+		}
+	}
+}
+
+// Keys iterates over the keys in insertion order.
+func (om *OrderedMap[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for k := range om.All() {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// ----------------------------------------------------------
+// 3. DEQUE (RING BUFFER)
+// ----------------------------------------------------------
+
+// Deque is a double-ended queue stored in a ring buffer: the elements
+// occupy buf[head], buf[head+1], ... wrapping around to buf[0]. Pushing
+// and popping at either end moves `head` instead of copying elements.
+type Deque[T any] struct {
+	buf  []T
+	head int // index of the front element
+	n    int // number of elements
+}
+
+func (d *Deque[T]) grow() {
+	if d.n < len(d.buf) {
+		return
+	}
+	bigger := make([]T, max(8, 2*len(d.buf)))
+	for i := range d.n {
+		bigger[i] = d.buf[(d.head+i)%len(d.buf)] // unwrap into the new buffer
+	}
+	d.buf, d.head = bigger, 0
+}
+
+// PushBack adds v at the back.
+func (d *Deque[T]) PushBack(v T) {
+	d.grow()
+	d.buf[(d.head+d.n)%len(d.buf)] = v
+	d.n++
+}
+
+// PushFront adds v at the front.
+func (d *Deque[T]) PushFront(v T) {
+	d.grow()
+	d.head = (d.head - 1 + len(d.buf)) % len(d.buf)
+	d.buf[d.head] = v
+	d.n++
+}
+
+// PopFront removes and returns the front element.
+func (d *Deque[T]) PopFront() (T, bool) {
+	var zero T
+	if d.n == 0 {
+		return zero, false
+	}
+	v := d.buf[d.head]
+	d.buf[d.head] = zero // let the garbage collector free what v points to
+	d.head = (d.head + 1) % len(d.buf)
+	d.n--
+	return v, true
+}
+
+// PopBack removes and returns the back element.
+func (d *Deque[T]) PopBack() (T, bool) {
+	var zero T
+	if d.n == 0 {
+		return zero, false
+	}
+	i := (d.head + d.n - 1) % len(d.buf)
+	v := d.buf[i]
+	d.buf[i] = zero
+	d.n--
+	return v, true
+}
+
+// At returns the i-th element from the front (0 = front).
+func (d *Deque[T]) At(i int) T {
+	if i < 0 || i >= d.n {
+		panic(fmt.Sprintf("deque: index %d out of range [0:%d]", i, d.n))
+	}
+	return d.buf[(d.head+i)%len(d.buf)]
+}
+
+// Len returns the number of elements.
+func (d *Deque[T]) Len() int { return d.n }
+
+// All iterates from front to back. The deque must not be modified
+// during the loop.
+func (d *Deque[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for i := range d.n {
+			if !yield(d.At(i)) {
+				return
+			}
+		}
+	}
+}
+
+// ----------------------------------------------------------
+// 4. PRIORITY QUEUE (BINARY HEAP)
+// ----------------------------------------------------------
+
+// PriorityQueue pops elements in the order defined by less: the element
+// for which less(a, b) is true for every other b comes out first.
+// It is a binary heap in a slice: the children of items[i] are at 2i+1
+// and 2i+2, and every parent comes before its children.
+type PriorityQueue[T any] struct {
+	items []T
+	less  func(a, b T) bool
+}
+
+// NewPriorityQueue creates a queue ordered by less.
+func NewPriorityQueue[T any](less func(a, b T) bool) *PriorityQueue[T] {
+	return &PriorityQueue[T]{less: less}
+}
+
+// Push adds v in O(log n).
+func (pq *PriorityQueue[T]) Push(v T) {
+	pq.items = append(pq.items, v)
+	// Sift up: swap with the parent while we come before it.
+	i := len(pq.items) - 1
+	for i > 0 {
+		parent := (i - 1) / 2
+		if !pq.less(pq.items[i], pq.items[parent]) {
+			break
+		}
+		pq.items[i], pq.items[parent] = pq.items[parent], pq.items[i]
+		i = parent
+	}
+}
+
+// Peek returns the first element without removing it.
+func (pq *PriorityQueue[T]) Peek() (T, bool) {
+	if len(pq.items) == 0 {
+		var zero T
+		return zero, false
+	}
+	return pq.items[0], true
+}
+
+// Pop removes and returns the first element in O(log n).
+func (pq *PriorityQueue[T]) Pop() (T, bool) {
+	var zero T
+	if len(pq.items) == 0 {
+		return zero, false
+	}
+	top := pq.items[0]
+	last := len(pq.items) - 1
+	pq.items[0] = pq.items[last]
+	pq.items[last] = zero
+	pq.items = pq.items[:last]
+
+	// Sift down: swap with the child that comes first until in place.
+	i := 0
+	for {
+		first := i
+		for _, c := range []int{2*i + 1, 2*i + 2} {
+			if c < len(pq.items) && pq.less(pq.items[c], pq.items[first]) {
+				first = c
+			}
+		}
+		if first == i {
+			return top, true
+		}
+		pq.items[i], pq.items[first] = pq.items[first], pq.items[i]
+		i = first
+	}
+}
+
+// Len returns the number of elements.
+func (pq *PriorityQueue[T]) Len() int { return len(pq.items) }
+
+// Drain pops elements in priority order for as long as the loop runs.
+// Breaking out early leaves the remaining elements in the queue.
+func (pq *PriorityQueue[T]) Drain() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for pq.Len() > 0 {
+			v, _ := pq.Pop()
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// ----------------------------------------------------------
+// BENCHMARKS: GENERIC COLLECTION vs NAIVE VERSION
+// ----------------------------------------------------------
+
+const benchSize = 1000
+
+// sink keeps results alive so the compiler cannot skip the work.
+var sink bool
+
+// benchmarks pairs each collection with the hand-written code it replaces.
+var benchmarks = []struct {
+	name string
+	fn   func(b *testing.B)
+}{
+	{"Set.Has", func(b *testing.B) {
+		s := NewSet[int]()
+		for i := range benchSize {
+			s.Add(i)
+		}
+		for i := 0; b.Loop(); i++ {
+			sink = s.Has(i % (2 * benchSize))
+		}
+	}},
+	{"slices.Contains", func(b *testing.B) {
+		var s []int
+		for i := range benchSize {
+			s = append(s, i)
+		}
+		for i := 0; b.Loop(); i++ {
+			sink = slices.Contains(s, i%(2*benchSize))
+		}
+	}},
+	{"OrderedMap Set+Delete", func(b *testing.B) {
+		om := NewOrderedMap[int, int]()
+		for i := range benchSize {
+			om.Set(i, i)
+		}
+		for i := 0; b.Loop(); i++ {
+			om.Delete(i % benchSize)
+			om.Set(i%benchSize, i)
+		}
+	}},
+	{"map+keys slice Set+Delete", func(b *testing.B) {
+		m := map[int]int{}
+		var keys []int
+		for i := range benchSize {
+			m[i] = i
+			keys = append(keys, i)
+		}
+		for i := 0; b.Loop(); i++ {
+			k := i % benchSize
+			delete(m, k)
+			keys = slices.Delete(keys, slices.Index(keys, k), slices.Index(keys, k)+1)
+			m[k] = i
+			keys = append(keys, k)
+		}
+	}},
+	{"Deque PushFront+PopBack", func(b *testing.B) {
+		var d Deque[int]
+		for i := range benchSize {
+			d.PushBack(i)
+		}
+		for i := 0; b.Loop(); i++ {
+			d.PushFront(i)
+			d.PopBack()
+		}
+	}},
+	{"slice prepend+trim", func(b *testing.B) {
+		var s []int
+		for i := range benchSize {
+			s = append(s, i)
+		}
+		for i := 0; b.Loop(); i++ {
+			s = append([]int{i}, s...)
+			s = s[:len(s)-1]
+		}
+	}},
+	{"PriorityQueue Push+Pop", func(b *testing.B) {
+		pq := NewPriorityQueue(func(a, b int) bool { return a < b })
+		for i := range benchSize {
+			pq.Push(i * 7 % benchSize)
+		}
+		for i := 0; b.Loop(); i++ {
+			pq.Push(i % benchSize)
+			pq.Pop()
+		}
+	}},
+	{"sorted slice insert+pop", func(b *testing.B) {
+		var s []int
+		for i := range benchSize {
+			s = append(s, i*7%benchSize)
+		}
+		slices.Sort(s)
+		for i := 0; b.Loop(); i++ {
+			v := i % benchSize
+			at, _ := slices.BinarySearch(s, v)
+			s = slices.Insert(s, at, v)
+			s = s[1:] // pop the smallest
+		}
+	}},
+}
+
+func runBenchmarks() {
+	fmt.Printf("%-28s %12s %10s\n", "benchmark (n=1000)", "ns/op", "allocs/op")
+	for _, bm := range benchmarks {
+		r := testing.Benchmark(bm.fn)
+		fmt.Printf("%-28s %12.1f %10d\n", bm.name, float64(r.T.Nanoseconds())/float64(r.N), r.AllocsPerOp())
+	}
+}
+
+// ----------------------------------------------------------
+// MAIN
+// ----------------------------------------------------------
+
+// task is an order-processing job with a priority (lower = more urgent).
+type task struct {
+	orderID  string
+	priority int
+}
+
+func main() {
+	bench := flag.Bool("bench", false, "run the benchmarks")
+	flag.Parse()
+	if *bench {
+		runBenchmarks()
+		return
+	}
+
+	// 1. Sets: which customers ordered in both months?
+	january := NewSet("asha", "ravi", "jhon", "meera")
+	february := NewSet("ravi", "meera", "kiran")
+	fmt.Println("Both months:   ", Sorted(january.Intersection(february))) // [meera ravi]
+	fmt.Println("Either month:  ", Sorted(january.Union(february)))        // [asha jhon kiran meera ravi]
+	fmt.Println("Only January:  ", Sorted(january.Difference(february)))   // [asha jhon]
+	fmt.Println("Feb ⊆ Jan?     ", february.IsSubset(january))             // false
+	fmt.Println("Add ravi again:", january.Add("ravi"))                    // false: already there
+	fmt.Println()
+
+	// 2. OrderedMap: order statuses in the order they happened.
+	timeline := NewOrderedMap[string, string]()
+	timeline.Set("Recieved", "10:00")
+	timeline.Set("Confirmed", "10:05")
+	timeline.Set("Prepared", "10:30")
+	timeline.Set("Delivered", "11:10")
+	timeline.Set("Confirmed", "10:06") // update keeps the position
+	timeline.Delete("Prepared")
+	for status, at := range timeline.All() {
+		fmt.Printf("%-10s %s\n", status, at)
+	}
+	fmt.Println("Keys:", slices.Collect(timeline.Keys()))
+	var zero OrderedMap[string, int] // no constructor needed, like Set
+	zero.Set("items", 3)
+	fmt.Println("Zero-value map:", slices.Collect(zero.Keys()), zero.Len())
+	fmt.Println()
+
+	// 3. Deque: undo history (push/pop at the back), oldest entries
+	//    dropped from the front when it grows beyond 3.
+	var history Deque[string]
+	for _, action := range []string{"add item", "apply coupon", "change address", "remove item"} {
+		history.PushBack(action)
+		if history.Len() > 3 {
+			dropped, _ := history.PopFront()
+			fmt.Println("Forgot oldest:", dropped)
+		}
+	}
+	undo, _ := history.PopBack()
+	fmt.Println("Undo:", undo)
+	fmt.Println("History:", slices.Collect(history.All()))
+	history.PushFront("urgent fix")
+	fmt.Println("After PushFront:", slices.Collect(history.All()), "front =", history.At(0))
+	fmt.Println()
+
+	// 4. PriorityQueue: process the most urgent orders first.
+	queue := NewPriorityQueue(func(a, b task) bool {
+		if a.priority != b.priority {
+			return a.priority < b.priority
+		}
+		return a.orderID < b.orderID // ties: by order id, for a stable result
+	})
+	for _, t := range []task{{"1004", 3}, {"1001", 1}, {"1003", 2}, {"1002", 1}, {"1005", 5}} {
+		queue.Push(t)
+	}
+	next, _ := queue.Peek()
+	fmt.Println("Next up:", next.orderID)
+	var order []string
+	for t := range queue.Drain() {
+		order = append(order, fmt.Sprintf("%s(p%d)", t.orderID, t.priority))
+		if len(order) == 3 {
+			break // the iterator stops popping; the rest stays queued
+		}
+	}
+	fmt.Println("Processed:", strings.Join(order, " "), "- still queued:", queue.Len())
+	fmt.Println()
+	fmt.Println("Run with -bench to compare against naive slices and maps.")
+}
+
+// ----------------------------------------------------------
+// SUMMARY
+// ----------------------------------------------------------
+// 1. Generics let one Set/OrderedMap/Deque/PriorityQueue work for any
+//    element type; constraints (comparable, cmp.Ordered) say what is needed.
+// 2. Methods returning iter.Seq / iter.Seq2 make custom collections work
+//    with `for range`, slices.Collect and slices.Sorted.
+// 3. When the loop body breaks, yield returns false and the iterator must
+//    stop — Drain relies on that to leave unprocessed items queued.
+// 4. A map gives O(1) Has; a linked list gives O(1) ordered Delete; a ring
+//    buffer gives O(1) at both ends; a heap gives O(log n) Push and Pop.
+// ----------------------------------------------------------
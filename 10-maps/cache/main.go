@@ -0,0 +1,672 @@
+package main
+
+import (
+	"container/list"
+	"errors"
+	"flag"
+	"fmt"
+	"hash/maphash"
+	"math/rand/v2"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// ----------------------------------------------------------
+// A BOUNDED CACHE: LRU / LFU, TTL, SHARDS
+// ----------------------------------------------------------
+// 10-maps shows the built-in map. A map makes a fine cache until:
+//   - it grows without limit        → a CAPACITY with an eviction policy:
+//     LRU evicts the Least Recently Used entry,
+//     LFU evicts the Least Frequently Used one;
+//   - the data goes stale           → a TTL per entry;
+//   - many goroutines use it        → one mutex becomes a bottleneck, so
+//     the cache is split into SHARDS, each with its own lock; a key's hash
+//     picks its shard, and goroutines working on different shards never wait
+//     for each other.
+//
+// Also: OnEvict callbacks (to log or write back), a Loader that fills
+// misses (the cache-aside pattern, built in), and hit/miss statistics.
+//
+// Run `go run main.go -bench` to compare with a map guarded by one mutex.
+// ----------------------------------------------------------
+
+// Policy chooses which entry to evict when a shard is full.
+type Policy int
+
+const (
+	LRU Policy = iota
+	LFU
+)
+
+func (p Policy) String() string { return [...]string{"LRU", "LFU"}[p] }
+
+// Reason says why an entry left the cache.
+type Reason int
+
+const (
+	Evicted Reason = iota // capacity reached
+	Expired               // TTL passed
+	Deleted               // removed by Delete
+)
+
+func (r Reason) String() string { return [...]string{"evicted", "expired", "deleted"}[r] }
+
+// Options configure a Cache.
+type Options[K comparable, V any] struct {
+	Capacity int           // maximum entries in total (split across shards)
+	Shards   int           // number of shards; default 16
+	Policy   Policy        // LRU (default) or LFU
+	TTL      time.Duration // default TTL for Set; 0 = no expiry
+
+	// OnEvict is called (outside any lock) when an entry leaves the cache.
+	OnEvict func(key K, value V, reason Reason)
+	// Loader fills misses in GetOrLoad.
+	Loader func(key K) (V, error)
+	// Now is the clock; nil means time.Now.
+	Now func() time.Time
+}
+
+// Stats are the cache counters.
+type Stats struct {
+	Hits, Misses, Loads, LoadErrors, Evictions, Expirations uint64
+}
+
+// HitRatio is hits / (hits + misses).
+func (s Stats) HitRatio() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// ----------------------------------------------------------
+// ENTRIES AND EVICTION POLICIES
+// ----------------------------------------------------------
+
+type entry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time     // zero = never
+	elem    *list.Element // position in the policy's list
+	bucket  *list.Element // LFU only: the access-count bucket holding elem
+}
+
+// policy keeps entries in eviction order.
+type policy[K comparable, V any] interface {
+	add(e *entry[K, V])
+	touch(e *entry[K, V]) // the entry was read or updated
+	remove(e *entry[K, V])
+	victim() *entry[K, V] // the entry to evict next
+}
+
+// lruPolicy: one list, most recently used at the front.
+type lruPolicy[K comparable, V any] struct{ order *list.List }
+
+func (p *lruPolicy[K, V]) add(e *entry[K, V])    { e.elem = p.order.PushFront(e) }
+func (p *lruPolicy[K, V]) touch(e *entry[K, V])  { p.order.MoveToFront(e.elem) }
+func (p *lruPolicy[K, V]) remove(e *entry[K, V]) { p.order.Remove(e.elem) }
+
+func (p *lruPolicy[K, V]) victim() *entry[K, V] {
+	if back := p.order.Back(); back != nil {
+		return back.Value.(*entry[K, V])
+	}
+	return nil
+}
+
+// lfuPolicy keeps one list of entries per access count, and those
+// buckets in a list sorted by count, lowest first. Touching an entry
+// moves it to the next bucket (created right after its own if missing),
+// and an empty bucket is unlinked, so every operation is O(1): the victim
+// is the least recently used entry of the first bucket.
+type lfuPolicy[K comparable, V any] struct {
+	buckets *list.List // of *freqBucket, ascending freq
+}
+
+// freqBucket holds the entries accessed exactly freq times, most recent first.
+type freqBucket struct {
+	freq    int
+	entries *list.List
+}
+
+// bucketAfter returns the bucket for freq, inserting it after at (or at
+// the front when at is nil) if it does not exist yet.
+func (p *lfuPolicy[K, V]) bucketAfter(at *list.Element, freq int) *list.Element {
+	next := p.buckets.Front()
+	if at != nil {
+		next = at.Next()
+	}
+	if next != nil && next.Value.(*freqBucket).freq == freq {
+		return next
+	}
+	b := &freqBucket{freq: freq, entries: list.New()}
+	if at == nil {
+		return p.buckets.PushFront(b)
+	}
+	return p.buckets.InsertAfter(b, at)
+}
+
+func (p *lfuPolicy[K, V]) add(e *entry[K, V]) {
+	e.bucket = p.bucketAfter(nil, 1)
+	e.elem = e.bucket.Value.(*freqBucket).entries.PushFront(e)
+}
+
+func (p *lfuPolicy[K, V]) touch(e *entry[K, V]) {
+	old := e.bucket
+	e.bucket = p.bucketAfter(old, old.Value.(*freqBucket).freq+1)
+	p.detach(old, e.elem)
+	e.elem = e.bucket.Value.(*freqBucket).entries.PushFront(e)
+}
+
+func (p *lfuPolicy[K, V]) remove(e *entry[K, V]) {
+	p.detach(e.bucket, e.elem)
+}
+
+// detach takes elem out of bucket, unlinking the bucket when it is empty.
+func (p *lfuPolicy[K, V]) detach(bucket, elem *list.Element) {
+	entries := bucket.Value.(*freqBucket).entries
+	entries.Remove(elem)
+	if entries.Len() == 0 {
+		p.buckets.Remove(bucket)
+	}
+}
+
+func (p *lfuPolicy[K, V]) victim() *entry[K, V] {
+	if first := p.buckets.Front(); first != nil {
+		return first.Value.(*freqBucket).entries.Back().Value.(*entry[K, V])
+	}
+	return nil
+}
+
+// ----------------------------------------------------------
+// SHARD
+// ----------------------------------------------------------
+
+// shard is an independent mini-cache with its own lock.
+type shard[K comparable, V any] struct {
+	mu       sync.Mutex
+	items    map[K]*entry[K, V]
+	policy   policy[K, V]
+	capacity int
+	loading  map[K]*load[V] // in-flight loader calls, one per key
+
+	// Counters live in the shard, not the Cache: a single shared counter
+	// would be one hot cache line that every goroutine writes, undoing
+	// the point of sharding.
+	hits, misses, loads, loadErrors, evictions, expirations atomic.Uint64
+}
+
+// load is one in-flight Loader call; other callers for the same key wait.
+type load[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// removal is an eviction to report once the lock is released.
+type removal[K comparable, V any] struct {
+	key    K
+	value  V
+	reason Reason
+}
+
+// ----------------------------------------------------------
+// CACHE
+// ----------------------------------------------------------
+
+// Cache is a sharded, bounded cache safe for concurrent use.
+type Cache[K comparable, V any] struct {
+	opts   Options[K, V]
+	seed   maphash.Seed
+	shards []*shard[K, V]
+}
+
+// ErrNoLoader is returned by GetOrLoad when Options.Loader is nil.
+var ErrNoLoader = errors.New("cache: no loader configured")
+
+// ErrLoaderPanicked is returned to every caller waiting on a Loader call
+// that panicked.
+var ErrLoaderPanicked = errors.New("cache: loader panicked")
+
+// New creates a cache. Capacity is required; it is divided between the
+// shards, so eviction is per shard — an approximation of a global LRU/LFU
+// that is what lets shards work without a shared lock.
+func New[K comparable, V any](opts Options[K, V]) (*Cache[K, V], error) {
+	if opts.Capacity <= 0 {
+		return nil, fmt.Errorf("cache: capacity must be positive, got %d", opts.Capacity)
+	}
+	if opts.Shards <= 0 {
+		opts.Shards = 16
+	}
+	opts.Shards = min(opts.Shards, opts.Capacity) // every shard holds at least one
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	c := &Cache[K, V]{opts: opts, seed: maphash.MakeSeed()}
+	for i := range opts.Shards {
+		// Spread the remainder so the shard capacities add up exactly.
+		capacity := opts.Capacity / opts.Shards
+		if i < opts.Capacity%opts.Shards {
+			capacity++
+		}
+		s := &shard[K, V]{items: map[K]*entry[K, V]{}, capacity: capacity, loading: map[K]*load[V]{}}
+		if opts.Policy == LFU {
+			s.policy = &lfuPolicy[K, V]{buckets: list.New()}
+		} else {
+			s.policy = &lruPolicy[K, V]{order: list.New()}
+		}
+		c.shards = append(c.shards, s)
+	}
+	return c, nil
+}
+
+// shardFor hashes the key to pick its shard. maphash.Comparable hashes any
+// comparable value with a per-process random seed.
+func (c *Cache[K, V]) shardFor(key K) *shard[K, V] {
+	return c.shards[maphash.Comparable(c.seed, key)%uint64(len(c.shards))]
+}
+
+// report calls OnEvict and updates counters; never called with a lock held,
+// so a callback may safely use the cache.
+func (c *Cache[K, V]) report(s *shard[K, V], removed []removal[K, V]) {
+	for _, r := range removed {
+		switch r.reason {
+		case Evicted:
+			s.evictions.Add(1)
+		case Expired:
+			s.expirations.Add(1)
+		}
+		if c.opts.OnEvict != nil {
+			c.opts.OnEvict(r.key, r.value, r.reason)
+		}
+	}
+}
+
+// get looks up key in s, which must be locked. Expired entries are removed.
+func (c *Cache[K, V]) get(s *shard[K, V], key K, now time.Time) (*entry[K, V], []removal[K, V]) {
+	e, ok := s.items[key]
+	if !ok {
+		return nil, nil
+	}
+	if !e.expires.IsZero() && !now.Before(e.expires) {
+		s.policy.remove(e)
+		delete(s.items, key)
+		return nil, []removal[K, V]{{e.key, e.value, Expired}}
+	}
+	s.policy.touch(e)
+	return e, nil
+}
+
+// Get returns the value for key if present and not expired.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	s := c.shardFor(key)
+	s.mu.Lock()
+	e, removed := c.get(s, key, c.opts.Now())
+	var v V
+	if e != nil {
+		v = e.value
+	}
+	s.mu.Unlock()
+	c.report(s, removed)
+
+	if e == nil {
+		s.misses.Add(1)
+		return v, false
+	}
+	s.hits.Add(1)
+	return v, true
+}
+
+// Set stores value with the default TTL.
+func (c *Cache[K, V]) Set(key K, value V) { c.SetWithTTL(key, value, c.opts.TTL) }
+
+// SetWithTTL stores value with its own TTL (0 = no expiry).
+func (c *Cache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	s := c.shardFor(key)
+	s.mu.Lock()
+	removed := c.set(s, key, value, ttl)
+	s.mu.Unlock()
+	c.report(s, removed)
+}
+
+// set stores an entry in s, which must be locked, evicting if full.
+func (c *Cache[K, V]) set(s *shard[K, V], key K, value V, ttl time.Duration) []removal[K, V] {
+	var expires time.Time
+	if ttl > 0 {
+		expires = c.opts.Now().Add(ttl)
+	}
+	if e, ok := s.items[key]; ok {
+		e.value, e.expires = value, expires
+		s.policy.touch(e)
+		return nil
+	}
+	var removed []removal[K, V]
+	for len(s.items) >= s.capacity {
+		victim := s.policy.victim()
+		s.policy.remove(victim)
+		delete(s.items, victim.key)
+		removed = append(removed, removal[K, V]{victim.key, victim.value, Evicted})
+	}
+	e := &entry[K, V]{key: key, value: value, expires: expires}
+	s.policy.add(e)
+	s.items[key] = e
+	return removed
+}
+
+// Delete removes key and reports whether it was present.
+func (c *Cache[K, V]) Delete(key K) bool {
+	s := c.shardFor(key)
+	s.mu.Lock()
+	e, ok := s.items[key]
+	if ok {
+		s.policy.remove(e)
+		delete(s.items, key)
+	}
+	s.mu.Unlock()
+	if ok {
+		c.report(s, []removal[K, V]{{e.key, e.value, Deleted}})
+	}
+	return ok
+}
+
+// GetOrLoad returns the cached value, or calls Loader on a miss and caches
+// the result. Concurrent misses for the same key share one Loader call.
+// Loader errors are returned and not cached; a Loader panic becomes an
+// ErrLoaderPanicked error.
+func (c *Cache[K, V]) GetOrLoad(key K) (v V, err error) {
+	if v, ok := c.Get(key); ok {
+		return v, nil
+	}
+	if c.opts.Loader == nil {
+		var zero V
+		return zero, ErrNoLoader
+	}
+
+	s := c.shardFor(key)
+	s.mu.Lock()
+	if l, ok := s.loading[key]; ok { // someone is already loading it
+		s.mu.Unlock()
+		<-l.done
+		return l.value, l.err
+	}
+	l := &load[V]{done: make(chan struct{})}
+	s.loading[key] = l
+	s.mu.Unlock()
+
+	// The loader runs WITHOUT the shard lock: it may be slow (a database
+	// query), and other keys in this shard must stay usable meanwhile.
+	s.loads.Add(1)
+	// The deferred cleanup runs even if Loader panics, so the key is not
+	// stuck in s.loading and waiters are never blocked forever.
+	defer func() {
+		if r := recover(); r != nil {
+			l.err = fmt.Errorf("%w: %v", ErrLoaderPanicked, r)
+		}
+		s.mu.Lock()
+		delete(s.loading, key)
+		var removed []removal[K, V]
+		if l.err == nil {
+			removed = c.set(s, key, l.value, c.opts.TTL)
+		}
+		s.mu.Unlock()
+		close(l.done)
+		c.report(s, removed)
+
+		if l.err != nil {
+			s.loadErrors.Add(1)
+		}
+		v, err = l.value, l.err
+	}()
+	l.value, l.err = c.opts.Loader(key)
+	return l.value, l.err
+}
+
+// PurgeExpired removes every expired entry now instead of lazily on Get.
+func (c *Cache[K, V]) PurgeExpired() int {
+	now := c.opts.Now()
+	total := 0
+	for _, s := range c.shards {
+		var removed []removal[K, V]
+		s.mu.Lock()
+		for key, e := range s.items {
+			if !e.expires.IsZero() && !now.Before(e.expires) {
+				s.policy.remove(e)
+				delete(s.items, key)
+				removed = append(removed, removal[K, V]{key, e.value, Expired})
+			}
+		}
+		s.mu.Unlock()
+		c.report(s, removed)
+		total += len(removed)
+	}
+	return total
+}
+
+// Len returns the number of entries, including expired ones not yet purged.
+func (c *Cache[K, V]) Len() int {
+	n := 0
+	for _, s := range c.shards {
+		s.mu.Lock()
+		n += len(s.items)
+		s.mu.Unlock()
+	}
+	return n
+}
+
+// Stats returns the counters summed over all shards.
+func (c *Cache[K, V]) Stats() Stats {
+	var st Stats
+	for _, s := range c.shards {
+		st.Hits += s.hits.Load()
+		st.Misses += s.misses.Load()
+		st.Loads += s.loads.Load()
+		st.LoadErrors += s.loadErrors.Load()
+		st.Evictions += s.evictions.Load()
+		st.Expirations += s.expirations.Load()
+	}
+	return st
+}
+
+// ----------------------------------------------------------
+// BENCHMARK: SHARDED CACHE vs MUTEX + MAP
+// ----------------------------------------------------------
+
+// mutexMap is the simplest concurrent cache: one lock for everything.
+type mutexMap[K comparable, V any] struct {
+	mu sync.Mutex
+	m  map[K]V
+}
+
+func (m *mutexMap[K, V]) Get(k K) (V, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.m[k]
+	return v, ok
+}
+
+func (m *mutexMap[K, V]) Set(k K, v V) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.m[k] = v
+}
+
+func runBenchmarks() {
+	const keys = 10_000
+	workload := func(get func(int) bool, set func(int)) func(*testing.B) {
+		return func(b *testing.B) {
+			for i := range keys {
+				set(i)
+			}
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					i++
+					if i%10 == 0 { // 90% reads, 10% writes
+						set(i % keys)
+					} else {
+						get((i * 7) % keys)
+					}
+				}
+			})
+		}
+	}
+
+	mm := &mutexMap[int, int]{m: map[int]int{}}
+	cases := []struct {
+		name string
+		fn   func(*testing.B)
+	}{
+		{"map + one mutex", workload(
+			func(k int) bool { _, ok := mm.Get(k); return ok },
+			func(k int) { mm.Set(k, k) })},
+	}
+	for _, shards := range []int{1, 16, 64} {
+		c, _ := New(Options[int, int]{Capacity: keys, Shards: shards})
+		cases = append(cases, struct {
+			name string
+			fn   func(*testing.B)
+		}{fmt.Sprintf("LRU cache, %d shard(s)", shards), workload(
+			func(k int) bool { _, ok := c.Get(k); return ok },
+			func(k int) { c.Set(k, k) })})
+	}
+	fmt.Printf("Parallel 90%% Get / 10%% Set, 10k keys, GOMAXPROCS=%d:\n", runtime.GOMAXPROCS(0))
+	for _, tc := range cases {
+		r := testing.Benchmark(tc.fn)
+		fmt.Printf("  %-24s %8.1f ns/op\n", tc.name, float64(r.T.Nanoseconds())/float64(r.N))
+	}
+	fmt.Println("The cache does more work per call (list updates, TTL checks), so on")
+	fmt.Println("one core it is slower than a bare map. Shards only pay off when")
+	fmt.Println("several cores contend for the lock: compare GOMAXPROCS=1 and higher.")
+}
+
+// ----------------------------------------------------------
+// MAIN
+// ----------------------------------------------------------
+
+// customer is the struct from 16-structs.
+type customer struct {
+	name   string
+	mobile string
+}
+
+func main() {
+	bench := flag.Bool("bench", false, "compare with a mutex-guarded map")
+	flag.Parse()
+	if *bench {
+		runBenchmarks()
+		return
+	}
+
+	now := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	logEvict := func(key string, _ string, reason Reason) { fmt.Printf("    (%s %s)\n", key, reason) }
+
+	// 1. LRU vs LFU with capacity 3 and the same access pattern. One shard
+	//    so the eviction order is exact.
+	for _, p := range []Policy{LRU, LFU} {
+		fmt.Printf("%s, capacity 3:\n", p)
+		c, _ := New(Options[string, string]{Capacity: 3, Shards: 1, Policy: p, OnEvict: logEvict, Now: clock})
+		c.Set("order-1", "Recieved")
+		c.Set("order-2", "Confirmed")
+		c.Set("order-3", "Prepared")
+		for range 3 {
+			c.Get("order-1") // order-1 is popular...
+		}
+		c.Get("order-2")
+		c.Get("order-3") // ...but order-3 was used most recently
+		c.Set("order-4", "Delivered")
+		_, ok1 := c.Get("order-1")
+		_, ok2 := c.Get("order-2")
+		fmt.Printf("  order-1 cached: %v, order-2 cached: %v\n", ok1, ok2)
+	}
+	fmt.Println()
+
+	// 2. TTL: a per-cache default and a per-entry override.
+	fmt.Println("TTL:")
+	c, _ := New(Options[string, string]{Capacity: 10, Shards: 1, TTL: time.Minute, OnEvict: logEvict, Now: clock})
+	c.Set("rate:USD/INR", "83.12")
+	c.SetWithTTL("otp:7493957674", "482913", 10*time.Second)
+	now = now.Add(30 * time.Second)
+	_, otpOK := c.Get("otp:7493957674")
+	rate, rateOK := c.Get("rate:USD/INR")
+	fmt.Printf("  after 30s: otp cached=%v, rate=%q cached=%v\n", otpOK, rate, rateOK)
+	c.Set("rate:EUR/INR", "90.40")
+	now = now.Add(time.Minute)
+	fmt.Println("  purged after 90s:", c.PurgeExpired())
+	fmt.Println()
+
+	// 3. Loader: misses are filled from the "database"; 20 concurrent
+	//    misses for the same customer cause ONE query.
+	var queries atomic.Int64
+	db := map[string]customer{"c1": {"Jhon", "+91 7493957674"}, "c2": {"Asha", "+91 9000000000"}}
+	customers, _ := New(Options[string, customer]{
+		Capacity: 100,
+		Loader: func(id string) (customer, error) {
+			queries.Add(1)
+			time.Sleep(20 * time.Millisecond) // a slow query
+			if id == "" {
+				panic("empty customer id") // a buggy loader
+			}
+			if c, ok := db[id]; ok {
+				return c, nil
+			}
+			return customer{}, fmt.Errorf("customer %s not found", id)
+		},
+	})
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			customers.GetOrLoad("c1")
+		}()
+	}
+	wg.Wait()
+	cust, _ := customers.GetOrLoad("c1")
+	fmt.Printf("Loaded %+v with %d query for 21 lookups\n", cust, queries.Load())
+	_, err := customers.GetOrLoad("c9")
+	fmt.Println("Missing customer:", err)
+	_, err = customers.GetOrLoad("")
+	_, again := customers.GetOrLoad("") // not stuck behind the first call
+	fmt.Println("Panicking loader:", err, "/ again:", errors.Is(again, ErrLoaderPanicked))
+	s := customers.Stats()
+	fmt.Printf("Stats: %+v hit ratio %.0f%%\n", s, s.HitRatio()*100)
+	fmt.Println()
+
+	// 4. Many goroutines, many keys, 16 shards (run with -race to check).
+	//    Keys are skewed toward low IDs, like real traffic on recent orders.
+	orders, _ := New(Options[int, string]{Capacity: 500, Shards: 16})
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 2000 {
+				key := rand.IntN(rand.IntN(1000) + 1)
+				if _, ok := orders.Get(key); !ok {
+					orders.Set(key, "order-"+strconv.Itoa(key))
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	s2 := orders.Stats()
+	fmt.Printf("Concurrent: %d entries (capacity 500), %d evictions, hit ratio %.0f%%\n",
+		orders.Len(), s2.Evictions, s2.HitRatio()*100)
+	fmt.Println()
+	fmt.Println("Run with -bench to compare against a mutex-guarded map.")
+}
+
+// ----------------------------------------------------------
+// SUMMARY
+// ----------------------------------------------------------
+// 1. A cache is a map plus a policy: LRU (a list ordered by recency) or
+//    LFU (lists per access count) decides what to drop when full.
+// 2. TTLs are checked lazily on Get, or eagerly with PurgeExpired.
+// 3. Sharding splits one big lock into many small ones.
+// 4. Callbacks run outside the lock; loads run outside the lock and are
+//    de-duplicated per key.
+// 5. Atomic counters expose hits, misses, loads, evictions, expirations.
+// ----------------------------------------------------------
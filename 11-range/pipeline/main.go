@@ -0,0 +1,477 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ------------------------------------------------------------
+// ITERATOR PIPELINES
+// ------------------------------------------------------------
+// 11-range loops over slices, maps and strings by hand. Since Go 1.23
+// `range` also accepts FUNCTIONS, called iterators:
+//
+//	iter.Seq[V]     = func(yield func(V) bool)
+//	iter.Seq2[K, V] = func(yield func(K, V) bool)
+//
+// The iterator calls yield once per element; the loop body runs inside
+// yield. When the body executes `break`, yield returns false and the
+// iterator MUST stop (Go panics if it calls yield again).
+//
+// Small functions that take an iterator and return a new one can be
+// chained into a pipeline:
+//
+//	Take(Filter(Map(lines, parse), isDelivered), 3)
+//
+// Nothing runs until the final `for range`, and each element flows through
+// the whole chain before the next one is read — so a pipeline over a huge
+// file (or an infinite sequence) only reads what the consumer asks for.
+// ------------------------------------------------------------
+
+// ------------------------------------------------------------
+// TRANSFORMING ADAPTERS
+// ------------------------------------------------------------
+
+// Map applies f to every element.
+func Map[T, U any](seq iter.Seq[T], f func(T) U) iter.Seq[U] {
+	return func(yield func(U) bool) {
+		for v := range seq {
+			if !yield(f(v)) {
+				return
+			}
+		}
+	}
+}
+
+// Filter keeps the elements for which keep returns true.
+func Filter[T any](seq iter.Seq[T], keep func(T) bool) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for v := range seq {
+			if keep(v) && !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Take yields at most the first n elements. It stops reading the source
+// as soon as it has n, which is what makes infinite sources usable.
+func Take[T any](seq iter.Seq[T], n int) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		if n <= 0 {
+			return
+		}
+		count := 0
+		for v := range seq {
+			if !yield(v) {
+				return
+			}
+			if count++; count == n {
+				return
+			}
+		}
+	}
+}
+
+// Skip drops the first n elements.
+func Skip[T any](seq iter.Seq[T], n int) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		skipped := 0
+		for v := range seq {
+			if skipped < n {
+				skipped++
+				continue
+			}
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Zip pairs up elements of a and b, stopping at the shorter one.
+// Two sequences cannot both be ranged over at once, so iter.Pull turns
+// b into a "next()" function; stop() must be called to release it.
+func Zip[A, B any](a iter.Seq[A], b iter.Seq[B]) iter.Seq2[A, B] {
+	return func(yield func(A, B) bool) {
+		next, stop := iter.Pull(b)
+		defer stop()
+		for va := range a {
+			vb, ok := next()
+			if !ok || !yield(va, vb) {
+				return
+			}
+		}
+	}
+}
+
+// Chunk groups elements into slices of n (the last one may be shorter).
+// Each chunk is a new slice, so callers may keep it.
+func Chunk[T any](seq iter.Seq[T], n int) iter.Seq[[]T] {
+	if n <= 0 {
+		panic("pipeline: Chunk size must be positive")
+	}
+	return func(yield func([]T) bool) {
+		chunk := make([]T, 0, n)
+		for v := range seq {
+			chunk = append(chunk, v)
+			if len(chunk) == n {
+				if !yield(chunk) {
+					return
+				}
+				chunk = make([]T, 0, n)
+			}
+		}
+		if len(chunk) > 0 {
+			yield(chunk)
+		}
+	}
+}
+
+// Window yields every run of n consecutive elements (a sliding window):
+// [1 2 3 4] with n=2 gives [1 2] [2 3] [3 4]. Each window is a new slice.
+func Window[T any](seq iter.Seq[T], n int) iter.Seq[[]T] {
+	if n <= 0 {
+		panic("pipeline: Window size must be positive")
+	}
+	return func(yield func([]T) bool) {
+		buf := make([]T, 0, n)
+		for v := range seq {
+			if len(buf) == n {
+				buf = buf[1:]
+			}
+			buf = append(buf, v)
+			if len(buf) == n && !yield(slices.Clone(buf)) {
+				return
+			}
+		}
+	}
+}
+
+// FlatMap maps each element to a sequence and yields all of their elements.
+func FlatMap[T, U any](seq iter.Seq[T], f func(T) iter.Seq[U]) iter.Seq[U] {
+	return func(yield func(U) bool) {
+		for v := range seq {
+			for u := range f(v) {
+				if !yield(u) {
+					return // stops both loops
+				}
+			}
+		}
+	}
+}
+
+// Distinct drops elements that were already seen.
+func Distinct[T comparable](seq iter.Seq[T]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		seen := map[T]struct{}{}
+		for v := range seq {
+			if _, dup := seen[v]; dup {
+				continue
+			}
+			seen[v] = struct{}{}
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// GroupBy collects elements by key and yields (key, group) pairs in the
+// order each key was first seen. Unlike the other adapters it must read
+// the whole input before yielding the first group, because the last
+// element might belong to the first group.
+func GroupBy[T any, K comparable](seq iter.Seq[T], key func(T) K) iter.Seq2[K, []T] {
+	return func(yield func(K, []T) bool) {
+		groups := map[K][]T{}
+		var order []K
+		for v := range seq {
+			k := key(v)
+			if _, ok := groups[k]; !ok {
+				order = append(order, k)
+			}
+			groups[k] = append(groups[k], v)
+		}
+		for _, k := range order {
+			if !yield(k, groups[k]) {
+				return
+			}
+		}
+	}
+}
+
+// Reduce folds the sequence into one value: Reduce(nums, 0, add) is a sum.
+func Reduce[T, A any](seq iter.Seq[T], initial A, f func(A, T) A) A {
+	acc := initial
+	for v := range seq {
+		acc = f(acc, v)
+	}
+	return acc
+}
+
+// ------------------------------------------------------------
+// Seq2 HELPERS
+// ------------------------------------------------------------
+
+// Filter2 keeps the pairs for which keep returns true.
+func Filter2[K, V any](seq iter.Seq2[K, V], keep func(K, V) bool) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for k, v := range seq {
+			if keep(k, v) && !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
+// Map2 turns each pair into a single value.
+func Map2[K, V, U any](seq iter.Seq2[K, V], f func(K, V) U) iter.Seq[U] {
+	return func(yield func(U) bool) {
+		for k, v := range seq {
+			if !yield(f(k, v)) {
+				return
+			}
+		}
+	}
+}
+
+// Keys drops the values of a Seq2.
+func Keys[K, V any](seq iter.Seq2[K, V]) iter.Seq[K] {
+	return Map2(seq, func(k K, _ V) K { return k })
+}
+
+// Values drops the keys of a Seq2.
+func Values[K, V any](seq iter.Seq2[K, V]) iter.Seq[V] {
+	return Map2(seq, func(_ K, v V) V { return v })
+}
+
+// ------------------------------------------------------------
+// SOURCES AND SINKS
+// ------------------------------------------------------------
+// Slices and maps already have adapters in the standard library:
+//   slices.Values(s), slices.All(s) → iterators;  slices.Collect(seq) → slice
+//   maps.All(m), maps.Keys(m)       → iterators;  maps.Collect(seq2) → map
+// Channels and bufio.Scanner need a little code.
+
+// FromChan yields values received from ch until it is closed.
+func FromChan[T any](ch <-chan T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for v := range ch {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// ToChan sends the sequence on a new channel from a goroutine. Cancel ctx
+// if you stop receiving early, or the goroutine blocks forever on send
+// (a goroutine leak).
+func ToChan[T any](ctx context.Context, seq iter.Seq[T], buffer int) <-chan T {
+	ch := make(chan T, buffer)
+	go func() {
+		defer close(ch)
+		for v := range seq {
+			select {
+			case ch <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// Lines yields the lines of a bufio.Scanner. As with a normal scanner loop,
+// check sc.Err() afterwards.
+func Lines(sc *bufio.Scanner) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for sc.Scan() {
+			if !yield(sc.Text()) {
+				return
+			}
+		}
+	}
+}
+
+// Naturals is an infinite sequence 1, 2, 3, ... It only terminates because
+// consumers stop asking. pulled counts how many numbers were produced.
+func Naturals(pulled *int) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for n := 1; ; n++ {
+			*pulled++
+			if !yield(n) {
+				return
+			}
+		}
+	}
+}
+
+// ------------------------------------------------------------
+// CONTRACT CHECK
+// ------------------------------------------------------------
+
+// stopsAfter consumes seq like `for range` with a `break` after n
+// elements, and reports an error if the iterator ignores the break and
+// calls yield again. (A real range loop would panic in that case.)
+func stopsAfter[T any](seq iter.Seq[T], n int) (err error) {
+	got, stopped := 0, false
+	seq(func(T) bool {
+		if stopped {
+			err = fmt.Errorf("yield called again after returning false")
+			return false
+		}
+		got++
+		if got == n {
+			stopped = true
+			return false
+		}
+		return true
+	})
+	return err
+}
+
+// ------------------------------------------------------------
+// MAIN
+// ------------------------------------------------------------
+
+type order struct {
+	id       string
+	customer string
+	amount   float64
+	status   string
+	items    []string
+}
+
+const ordersCSV = `1001,asha,1500,Delivered,phone;case
+1002,ravi,250,Recieved,cable
+1003,asha,3200,Delivered,laptop
+1004,jhon,80,Confirmed,sticker
+1005,meera,990,Delivered,headphones;cable
+1006,ravi,4100,Prepared,monitor
+1007,jhon,640,Delivered,keyboard;mouse`
+
+func parseOrder(line string) order {
+	f := strings.Split(line, ",")
+	amount, _ := strconv.ParseFloat(f[2], 64)
+	return order{id: f[0], customer: f[1], amount: amount, status: f[3], items: strings.Split(f[4], ";")}
+}
+
+func main() {
+	// 1. Laziness: an infinite source, three adapters, and only as much
+	//    work as the consumer needs.
+	pulled := 0
+	squaresOfOdd := Take(Map(Filter(Naturals(&pulled), func(n int) bool { return n%2 == 1 }),
+		func(n int) int { return n * n }), 4)
+	fmt.Println("First 4 odd squares:", slices.Collect(squaresOfOdd)) // [1 9 25 49]
+	fmt.Println("Numbers pulled from the infinite source:", pulled)   // 7, not ∞
+	fmt.Println()
+
+	// 2. A pipeline over lines read with bufio.Scanner.
+	sc := bufio.NewScanner(strings.NewReader(ordersCSV))
+	orders := slices.Collect(Map(Lines(sc), parseOrder))
+	if err := sc.Err(); err != nil {
+		fmt.Println("read error:", err)
+		return
+	}
+	all := slices.Values(orders)
+	delivered := Filter(all, func(o order) bool { return o.status == "Delivered" })
+
+	total := Reduce(delivered, 0.0, func(sum float64, o order) float64 { return sum + o.amount })
+	fmt.Println("Delivered revenue:", total) // 6330
+	fmt.Println("Customers:", slices.Collect(Distinct(Map(all, func(o order) string { return o.customer }))))
+	fmt.Println("All items:", slices.Collect(Distinct(FlatMap(all, func(o order) iter.Seq[string] {
+		return slices.Values(o.items)
+	}))))
+	fmt.Println("Page 2 (size 2):", slices.Collect(Map(Take(Skip(all, 2), 2), func(o order) string { return o.id })))
+	fmt.Println()
+
+	// 3. Grouping, batching and moving averages.
+	for status, group := range GroupBy(all, func(o order) string { return o.status }) {
+		ids := slices.Collect(Map(slices.Values(group), func(o order) string { return o.id }))
+		fmt.Printf("%-10s %v\n", status, ids)
+	}
+	for batch := range Chunk(Map(all, func(o order) string { return o.id }), 3) {
+		fmt.Println("Settlement batch:", batch)
+	}
+	amounts := Map(all, func(o order) float64 { return o.amount })
+	for w := range Take(Window(amounts, 3), 2) {
+		fmt.Printf("Moving average of %v = %.2f\n", w, Reduce(slices.Values(w), 0.0,
+			func(s, v float64) float64 { return s + v })/3)
+	}
+	fmt.Println()
+
+	// 4. Seq2: zip invoice numbers onto delivered orders, then go through a map.
+	invoices := maps.Collect(Zip(
+		Map(delivered, func(o order) string { return o.id }),
+		Map(Naturals(new(int)), func(n int) string { return fmt.Sprintf("INV-%03d", n) }),
+	))
+	for _, id := range slices.Sorted(maps.Keys(invoices)) {
+		fmt.Println(id, "→", invoices[id])
+	}
+	big := Filter2(maps.All(invoices), func(id, _ string) bool { return id > "1004" })
+	fmt.Println("Invoices for orders after 1004:", slices.Sorted(Values(big)))
+	fmt.Println()
+
+	// 5. Channels: stream a pipeline through a channel, stop early, and
+	//    cancel so the producing goroutine exits instead of leaking.
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := ToChan(ctx, Naturals(new(int)), 0)
+	for n := range FromChan(ch) {
+		if n == 3 {
+			break
+		}
+	}
+	cancel()
+	for range ch { // returns once the goroutine has closed the channel
+	}
+	fmt.Println("Channel closed after break + cancel: the producer goroutine exited")
+	fmt.Println()
+
+	// 6. Every adapter honours `break`: none calls yield after it got false.
+	src := func() iter.Seq[int] { return Naturals(new(int)) }
+	id := func(n int) int { return n }
+	checks := []struct {
+		name string
+		err  error
+	}{
+		{"Map", stopsAfter(Map(src(), id), 2)},
+		{"Filter", stopsAfter(Filter(src(), func(int) bool { return true }), 2)},
+		{"Take", stopsAfter(Take(src(), 5), 2)},
+		{"Skip", stopsAfter(Skip(src(), 3), 2)},
+		{"Zip", stopsAfter(Keys(Zip(src(), src())), 2)},
+		{"Chunk", stopsAfter(Chunk(src(), 3), 2)},
+		{"Window", stopsAfter(Window(src(), 3), 2)},
+		{"FlatMap", stopsAfter(FlatMap(src(), func(n int) iter.Seq[int] { return Take(src(), 3) }), 2)},
+		{"Distinct", stopsAfter(Distinct(Map(src(), func(n int) int { return n / 2 })), 2)},
+		{"GroupBy", stopsAfter(Keys(GroupBy(slices.Values([]int{1, 2, 3, 4}), func(n int) int { return n % 3 })), 2)},
+		{"FromChan", stopsAfter(FromChan(ToChan(context.Background(), slices.Values([]int{1, 2, 3}), 3)), 2)},
+	}
+	for _, c := range checks {
+		result := "ok"
+		if c.err != nil {
+			result = c.err.Error()
+		}
+		fmt.Printf("break after 2 → %-9s %s\n", c.name, result)
+	}
+}
+
+// ------------------------------------------------------------
+// SUMMARY
+// ------------------------------------------------------------
+// 1. An iterator is a func(yield) — `for range` can loop over it directly.
+// 2. Adapters wrap iterators: Map, Filter, Take, Skip, Zip, Chunk, Window,
+//    FlatMap, Distinct and GroupBy; Reduce and slices.Collect consume them.
+// 3. Pipelines are lazy: elements flow one at a time, so Take on an
+//    infinite source terminates and reads only what it needs.
+// 4. When yield returns false (the loop body broke out), the adapter must
+//    return immediately — and clean up (Zip's stop, ToChan's ctx).
+// 5. slices/maps provide sources and sinks; FromChan, ToChan and Lines
+//    connect channels and bufio.Scanner.
+// ------------------------------------------------------------
@@ -0,0 +1,464 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ------------------------------------------------------------
+// UNICODE-AWARE TEXT
+// ------------------------------------------------------------
+// `range "golang"` yields byte offsets and RUNES (code points). For
+// English that is the same as "characters"; for our menu it is not:
+//
+//	"Café"  é is two runes: e + COMBINING ACUTE ACCENT
+//	"हिन्दी"       two characters, six runes (vowel signs, a virama)
+//	"👨‍👩‍👧"          one character, five runes joined by ZERO WIDTH JOINERs
+//	"🇮🇳"          one flag, two REGIONAL INDICATOR runes
+//
+// What a reader calls a character is a GRAPHEME CLUSTER (Unicode
+// UAX #29). This file segments text into clusters and builds on that:
+// display width for terminal tables, truncation that never cuts a
+// character in half, reversal, case folding, and an inspector.
+//
+// The standard library has the Unicode tables (package unicode) but not
+// the segmentation rules, so they are implemented here — the rules that
+// matter for Latin, Indic and emoji text. Hangul jamo sequences and
+// "prepend" characters are not handled; production code would use a
+// full implementation such as github.com/rivo/uniseg.
+// ------------------------------------------------------------
+
+// ------------------------------------------------------------
+// 1. CHARACTER CLASSES
+// ------------------------------------------------------------
+
+const (
+	zwj  = '\u200D' // ZERO WIDTH JOINER: glues emoji into one picture
+	zwnj = '\u200C' // ZERO WIDTH NON-JOINER
+	vs16 = '\uFE0F' // VARIATION SELECTOR-16: "show as emoji"
+)
+
+// extendedPictographic approximates the Extended_Pictographic property:
+// the emoji and symbols that ZWJ sequences are built from.
+var extendedPictographic = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{0x00A9, 0x00AE, 5}, {0x203C, 0x2049, 13}, {0x2122, 0x2139, 23},
+		{0x2194, 0x21AA, 1}, {0x231A, 0x23FF, 1}, {0x25AA, 0x25FE, 1},
+		{0x2600, 0x27BF, 1}, {0x2934, 0x2935, 1}, {0x2B05, 0x2B55, 1},
+		{0x3030, 0x303D, 13}, {0x3297, 0x3299, 2},
+	},
+	R32: []unicode.Range32{
+		{0x1F000, 0x1F1E5, 1}, {0x1F200, 0x1F3FA, 1}, {0x1F400, 0x1FAFF, 1},
+	},
+}
+
+// wide lists East Asian Wide and Fullwidth characters, which take two
+// terminal columns: CJK, Hangul syllables, fullwidth forms and the
+// emoji that are shown as pictures by default. (Abridged.)
+var wide = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{0x1100, 0x115F, 1}, {0x231A, 0x231B, 1}, {0x23E9, 0x23EC, 1},
+		{0x2614, 0x2615, 1}, {0x2648, 0x2653, 1}, {0x26A1, 0x26A1, 1},
+		{0x26BD, 0x26BE, 1}, {0x2705, 0x2705, 1}, {0x2728, 0x2728, 1},
+		{0x274C, 0x274C, 1}, {0x2B50, 0x2B50, 1}, {0x2E80, 0x303E, 1},
+		{0x3041, 0x33FF, 1}, {0x3400, 0x4DBF, 1}, {0x4E00, 0x9FFF, 1},
+		{0xA000, 0xA4CF, 1}, {0xAC00, 0xD7A3, 1}, {0xF900, 0xFAFF, 1},
+		{0xFE30, 0xFE4F, 1}, {0xFF00, 0xFF60, 1}, {0xFFE0, 0xFFE6, 1},
+	},
+	R32: []unicode.Range32{
+		{0x1F004, 0x1F004, 1}, {0x1F300, 0x1F64F, 1}, {0x1F680, 0x1F6FF, 1},
+		{0x1F900, 0x1F9FF, 1}, {0x1FA70, 0x1FAFF, 1}, {0x20000, 0x3FFFD, 1},
+	},
+}
+
+// viramas are the Indic "linkers": a consonant + virama + consonant is
+// written as one conjunct, e.g. न + ् + द → न्द. (Unicode 15.1 rule GB9c.)
+var viramas = map[rune]bool{
+	0x094D: true, // Devanagari
+	0x09CD: true, // Bengali
+	0x0ACD: true, // Gujarati
+	0x0B4D: true, // Oriya
+	0x0C4D: true, // Telugu
+	0x0D4D: true, // Malayalam
+}
+
+func isRegionalIndicator(r rune) bool { return r >= 0x1F1E6 && r <= 0x1F1FF }
+func isEmojiModifier(r rune) bool     { return r >= 0x1F3FB && r <= 0x1F3FF } // skin tones
+
+// isExtend reports runes that attach to the previous character:
+// combining marks, joiners, variation selectors and skin tones.
+func isExtend(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me) || r == zwj || r == zwnj || isEmojiModifier(r)
+}
+
+// isControl reports runes that always stand alone: control codes, line
+// and paragraph separators, and invisible format characters other than
+// the joiners.
+func isControl(r rune) bool {
+	return unicode.In(r, unicode.Cc, unicode.Zl, unicode.Zp) ||
+		unicode.Is(unicode.Cf, r) && r != zwj && r != zwnj
+}
+
+// isIndicConsonant approximates the Indic consonants of the scripts in viramas.
+func isIndicConsonant(r rune) bool {
+	return r >= 0x0900 && r <= 0x0D7F && unicode.Is(unicode.Lo, r)
+}
+
+// ------------------------------------------------------------
+// 2. GRAPHEME CLUSTERS
+// ------------------------------------------------------------
+
+// segmenter carries the state the boundary rules need: what came before
+// in the current cluster.
+type segmenter struct {
+	prev        rune
+	regional    int // regional indicators in a row
+	emoji       int // 1: pictograph (+ extends) seen, 2: ...followed by ZWJ
+	conjunct    int // 1: Indic consonant (+ extends) seen, 2: ...followed by virama
+	hasPrevious bool
+}
+
+// boundary reports whether a new cluster starts before r, then updates
+// the state. The GBxx names are the rule numbers in UAX #29.
+func (s *segmenter) boundary(r rune) bool {
+	brk := s.breaks(r)
+
+	switch {
+	case isRegionalIndicator(r):
+		s.regional++
+	default:
+		s.regional = 0
+	}
+	switch {
+	case unicode.Is(extendedPictographic, r) && !isEmojiModifier(r):
+		s.emoji = 1
+	case r == zwj && s.emoji == 1:
+		s.emoji = 2
+	case isExtend(r) && s.emoji == 1:
+	default:
+		s.emoji = 0
+	}
+	switch {
+	case isIndicConsonant(r):
+		s.conjunct = 1
+	case viramas[r] && s.conjunct >= 1:
+		s.conjunct = 2
+	case isExtend(r) && s.conjunct >= 1:
+	default:
+		s.conjunct = 0
+	}
+	s.prev, s.hasPrevious = r, true
+	return brk
+}
+
+func (s *segmenter) breaks(r rune) bool {
+	switch {
+	case !s.hasPrevious:
+		return true // GB1: start of text
+	case s.prev == '\r' && r == '\n':
+		return false // GB3: CR LF is one character
+	case isControl(s.prev) || s.prev == '\r' || s.prev == '\n',
+		isControl(r) || r == '\r' || r == '\n':
+		return true // GB4, GB5
+	case isExtend(r), unicode.Is(unicode.Mc, r):
+		return false // GB9, GB9a: marks attach to what precedes them
+	case s.conjunct == 2 && isIndicConsonant(r):
+		return false // GB9c: consonant virama × consonant
+	case s.emoji == 2 && unicode.Is(extendedPictographic, r):
+		return false // GB11: pictograph ZWJ × pictograph
+	case isRegionalIndicator(r) && s.regional%2 == 1:
+		return false // GB12, GB13: regional indicators pair up into flags
+	}
+	return true // GB999
+}
+
+// Graphemes yields the grapheme clusters of s.
+func Graphemes(s string) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		var seg segmenter
+		start := 0
+		for i, r := range s {
+			if seg.boundary(r) && i > start {
+				if !yield(s[start:i]) {
+					return
+				}
+				start = i
+			}
+		}
+		if start < len(s) {
+			yield(s[start:])
+		}
+	}
+}
+
+// Length counts grapheme clusters — what a person would count.
+func Length(s string) int {
+	n := 0
+	for range Graphemes(s) {
+		n++
+	}
+	return n
+}
+
+// ------------------------------------------------------------
+// 3. DISPLAY WIDTH
+// ------------------------------------------------------------
+
+// runeWidth is the number of terminal columns r advances the cursor:
+// 0 for marks and invisible characters, 2 for wide characters, else 1.
+// This is the classic wcwidth rule most terminals follow.
+func runeWidth(r rune) int {
+	switch {
+	case r == 0, isExtend(r), isControl(r):
+		return 0
+	case unicode.Is(wide, r):
+		return 2
+	}
+	return 1
+}
+
+// clusterWidth is the width of one grapheme cluster. Emoji sequences
+// (flags, skin tones, ZWJ families, text symbols turned into emoji by
+// VS16) render as one two-column picture however many runes they have.
+func clusterWidth(g string) int {
+	if n := utf8.RuneCountInString(g); n > 1 {
+		first, _ := utf8.DecodeRuneInString(g)
+		if unicode.Is(extendedPictographic, first) || isRegionalIndicator(first) || strings.ContainsRune(g, vs16) {
+			return 2
+		}
+	}
+	w := 0
+	for _, r := range g {
+		w += runeWidth(r)
+	}
+	return w
+}
+
+// Width is the number of terminal columns s occupies.
+func Width(s string) int {
+	w := 0
+	for g := range Graphemes(s) {
+		w += clusterWidth(g)
+	}
+	return w
+}
+
+// PadRight pads s with spaces to width columns. fmt's %-20s pads to 20
+// RUNES, which misaligns columns as soon as a wide or combining
+// character appears.
+func PadRight(s string, width int) string {
+	return s + strings.Repeat(" ", max(0, width-Width(s)))
+}
+
+// Truncate shortens s to at most width columns, ending in ellipsis if
+// anything was cut. It cuts only between grapheme clusters, so it never
+// leaves half a character or invalid UTF-8.
+func Truncate(s string, width int, ellipsis string) string {
+	if Width(s) <= width {
+		return s
+	}
+	budget := width - Width(ellipsis)
+	if budget < 0 {
+		return ""
+	}
+	var b strings.Builder
+	used := 0
+	for g := range Graphemes(s) {
+		w := clusterWidth(g)
+		if used+w > budget {
+			break
+		}
+		b.WriteString(g)
+		used += w
+	}
+	return b.String() + ellipsis
+}
+
+// ------------------------------------------------------------
+// 4. REVERSE AND CASE FOLDING
+// ------------------------------------------------------------
+
+// Reverse reverses the grapheme clusters of s, keeping each intact.
+func Reverse(s string) string {
+	clusters := slices.Collect(Graphemes(s))
+	slices.Reverse(clusters)
+	return strings.Join(clusters, "")
+}
+
+// reverseRunes is the naive version, for comparison: it moves combining
+// marks onto the wrong letter and splits flags into other flags.
+func reverseRunes(s string) string {
+	r := []rune(s)
+	slices.Reverse(r)
+	return string(r)
+}
+
+// fullFolds are the case foldings that change the number of runes,
+// which unicode.SimpleFold cannot express.
+var fullFolds = map[rune]string{
+	'ß':      "ss",
+	'ẞ':      "ss",
+	'ﬁ':      "fi",
+	'ﬂ':      "fl",
+	'\u0130': "i\u0307", // İ, Turkish capital dotted I
+}
+
+// Fold returns s case-folded for caseless comparison and lookup keys:
+// Fold(a) == Fold(b) when a and b differ only in case. Lowercasing is
+// not enough — "ß" and "SS", "ς" (final sigma) and "Σ" must match.
+//
+// unicode.SimpleFold walks the ORBIT of runes that are equal under case
+// folding (k → K → K KELVIN SIGN → k); the smallest one, lowercased, is
+// a canonical representative.
+//
+// Folding does not normalise: "é" as one rune and as e + accent still
+// differ. That needs Unicode normalisation (golang.org/x/text/unicode/norm).
+func Fold(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if full, ok := fullFolds[r]; ok {
+			b.WriteString(full)
+			continue
+		}
+		lowest := r
+		for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+			lowest = min(lowest, f)
+		}
+		b.WriteRune(unicode.ToLower(lowest))
+	}
+	return b.String()
+}
+
+// ------------------------------------------------------------
+// 5. INSPECTOR
+// ------------------------------------------------------------
+
+// categoryNames and scriptNames are the two-letter general categories
+// ("Lu", "Mn", ...; not "LC", which groups Lu, Ll and Lt) and the script
+// names, sorted for stable lookups.
+var (
+	categoryNames = sortedNames(unicode.Categories, func(name string) bool { return len(name) == 2 && name != "LC" })
+	scriptNames   = sortedNames(unicode.Scripts, func(string) bool { return true })
+)
+
+func sortedNames(tables map[string]*unicode.RangeTable, keep func(string) bool) []string {
+	var names []string
+	for name := range tables {
+		if keep(name) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+func lookup(r rune, names []string, tables map[string]*unicode.RangeTable) string {
+	for _, name := range names {
+		if unicode.Is(tables[name], r) {
+			return name
+		}
+	}
+	return "?"
+}
+
+// Inspect writes every grapheme cluster of s with its width, and under
+// it every rune: code point, UTF-8 bytes, general category and script.
+func Inspect(w io.Writer, s string) {
+	for g := range Graphemes(s) {
+		fmt.Fprintf(w, "%q  width %d\n", g, clusterWidth(g))
+		for _, r := range g {
+			buf := utf8.AppendRune(nil, r)
+			fmt.Fprintf(w, "    %-8s % -12X  %-2s  %s\n",
+				fmt.Sprintf("U+%04X", r), buf, lookup(r, categoryNames, unicode.Categories), lookup(r, scriptNames, unicode.Scripts))
+		}
+	}
+}
+
+// ------------------------------------------------------------
+// MAIN
+// ------------------------------------------------------------
+
+func main() {
+	menu := []struct {
+		name  string
+		price int
+	}{
+		{"Paneer Tikka", 240},
+		{"पनीर टिक्का", 240},
+		{"తెలుగు థాలి", 320},
+		{"Cafe\u0301 Latte", 180},
+		{"寿司 Sushi Platter", 650},
+		{"🍕 Pizza", 399},
+		{"👨‍👩‍👧 Family Meal Combo Deluxe", 899},
+		{"🇮🇳 Thali", 299},
+	}
+
+	// 1. Counting: bytes vs runes vs characters.
+	fmt.Printf("%-6s %-6s %-6s %-6s %s\n", "bytes", "runes", "chars", "width", "name")
+	for _, m := range menu {
+		fmt.Printf("%-6d %-6d %-6d %-6d %s\n",
+			len(m.name), utf8.RuneCountInString(m.name), Length(m.name), Width(m.name), m.name)
+	}
+	fmt.Println()
+
+	// 2. A table: fmt pads by runes, PadRight by columns; long names are
+	//    truncated on character boundaries.
+	fmt.Printf("fmt with %%-14s (misaligned in a terminal):\n")
+	for _, m := range menu[:4] {
+		fmt.Printf("  |%-14s| ₹%d\n", m.name, m.price)
+	}
+	fmt.Println("PadRight + Truncate to 14 columns:")
+	for _, m := range menu {
+		fmt.Printf("  |%s| ₹%d\n", PadRight(Truncate(m.name, 14, "…"), 14), m.price)
+	}
+	fmt.Println()
+
+	// 3. Byte slicing vs truncation.
+	name := "తెలుగు థాలి"
+	cut := name[:8]
+	fmt.Printf("name[:8] = %q valid UTF-8: %v\n", cut, utf8.ValidString(cut))
+	fmt.Printf("Truncate(name, 4, \"…\") = %q\n", Truncate(name, 4, "…"))
+	fmt.Println()
+
+	// 4. Reversal.
+	for _, s := range []string{"Cafe\u0301", "🇮🇳🇺🇸", "हिन्दी"} {
+		fmt.Printf("%-10q runes reversed: %q  clusters reversed: %q\n", s, reverseRunes(s), Reverse(s))
+	}
+	fmt.Println()
+
+	// 5. Case folding.
+	pairs := [][2]string{{"STRASSE", "straße"}, {"ΟΔΥΣΣΕΥΣ", "οδυσσευς"}, {"ΣΊΣΥΦΟΣ", "σίσυφος"}, {"\uFB01le", "FILE"}}
+	for _, p := range pairs {
+		fmt.Printf("%-10s vs %-10s  ToLower equal: %-5v  EqualFold: %-5v  Fold equal: %v\n",
+			p[0], p[1], strings.ToLower(p[0]) == strings.ToLower(p[1]),
+			strings.EqualFold(p[0], p[1]), Fold(p[0]) == Fold(p[1]))
+	}
+	fmt.Println()
+
+	// 6. Inspector.
+	if len(os.Args) > 1 {
+		Inspect(os.Stdout, strings.Join(os.Args[1:], " "))
+		return
+	}
+	Inspect(os.Stdout, "e\u0301👍🏽न्द🇮🇳")
+	fmt.Println()
+	fmt.Println("Pass text as arguments to inspect it: go run main.go 'నమస్తే'")
+}
+
+// ------------------------------------------------------------
+// SUMMARY
+// ------------------------------------------------------------
+// 1. Bytes ≠ runes ≠ characters. range over a string gives runes; a
+//    character (grapheme cluster) can be many runes.
+// 2. Segment with the UAX #29 rules: marks attach, viramas join Indic
+//    consonants, ZWJ joins emoji, regional indicators pair into flags.
+// 3. Display width is per cluster: marks are 0 columns, CJK and emoji 2.
+// 4. Truncate and reverse by cluster, never by byte or rune.
+// 5. Compare case-insensitively with folding, not ToLower.
+// ------------------------------------------------------------
@@ -0,0 +1,401 @@
+package main
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// --------------------------------------------------------
+// MEMOIZATION AND SINGLE-FLIGHT
+// --------------------------------------------------------
+// 12-functions shows that functions are values: processIt takes one,
+// returnsFunc returns one. That makes it possible to WRAP a function:
+// take an expensive func(K) (V, error) and return a new one with the same
+// signature that remembers results.
+//
+//	rate := Memoize(fetchExchangeRate, Options{TTL: time.Minute}).Func()
+//	rate("USD/INR") // slow: calls the API
+//	rate("USD/INR") // fast: from the cache
+//
+// Three problems a plain map does not solve:
+//  1. STALENESS: exchange rates change → entries expire after a TTL.
+//  2. MEMORY: unbounded keys → at most MaxEntries, least recently used
+//     entries are evicted first (LRU).
+//  3. STAMPEDES: 100 goroutines miss the same key at once → without care
+//     the API is called 100 times. A single-flight Group lets the first
+//     caller do the work while the other 99 wait for its result.
+//
+// Errors are NOT cached unless you opt in with CacheErrors, because most
+// errors (timeouts) are temporary. Permanent ones ("unknown currency")
+// can be cached for a short ErrorTTL.
+// --------------------------------------------------------
+
+// --------------------------------------------------------
+// SINGLE-FLIGHT GROUP
+// --------------------------------------------------------
+
+// Group collapses concurrent calls with the same key into one execution.
+type Group[K comparable, V any] struct {
+	mu    sync.Mutex
+	calls map[K]*call[V]
+}
+
+// call is one in-flight execution; waiters block on done.
+type call[V any] struct {
+	done chan struct{}
+	val  V
+	err  error
+}
+
+// ErrPanicked is returned to every waiter when the function panicked.
+var ErrPanicked = errors.New("memoize: function panicked")
+
+// Do runs fn once for all concurrent callers with the same key.
+// shared reports whether the result came from another caller's execution.
+func (g *Group[K, V]) Do(key K, fn func() (V, error)) (v V, err error, shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = map[K]*call[V]{}
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		<-c.done // wait for the leader
+		return c.val, c.err, true
+	}
+	c := &call[V]{done: make(chan struct{})}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	// The deferred cleanup runs even if fn panics, so waiters are never
+	// stuck forever on a call that will not finish.
+	defer func() {
+		if r := recover(); r != nil {
+			c.err = fmt.Errorf("%w: %v", ErrPanicked, r)
+			err = c.err
+		}
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(c.done)
+	}()
+	c.val, c.err = fn()
+	return c.val, c.err, false
+}
+
+// --------------------------------------------------------
+// MEMOIZE
+// --------------------------------------------------------
+
+// Options configure a Memo. The zero value caches forever, without a
+// size limit, and never caches errors.
+type Options struct {
+	TTL        time.Duration // 0 = never expires
+	MaxEntries int           // 0 = unlimited
+
+	// CacheErrors decides which errors are remembered; nil caches none.
+	CacheErrors func(error) bool
+	ErrorTTL    time.Duration // how long a cached error lives; 0 = TTL
+
+	// Now is the clock. nil means time.Now; examples pass a fake one.
+	Now func() time.Time
+}
+
+// Stats are cache counters. Hits + Misses + Shared = total Get calls.
+type Stats struct {
+	Hits      uint64 // answered from the cache
+	Misses    uint64 // called the function
+	Shared    uint64 // waited for another caller's in-flight call
+	Evictions uint64 // removed because of MaxEntries
+	Expired   uint64 // removed because of TTL
+}
+
+// HitRate is the share of calls that did not run the function.
+func (s Stats) HitRate() float64 {
+	total := s.Hits + s.Misses + s.Shared
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits+s.Shared) / float64(total)
+}
+
+// Memo is a memoized func(K) (V, error).
+type Memo[K comparable, V any] struct {
+	fn    func(K) (V, error)
+	opts  Options
+	group Group[K, V]
+
+	mu      sync.Mutex
+	entries map[K]*list.Element // values are *entry[K, V]
+	lru     *list.List          // front = most recently used
+
+	hits, misses, shared, evictions, expired atomic.Uint64
+}
+
+type entry[K comparable, V any] struct {
+	key     K
+	val     V
+	err     error
+	expires time.Time // zero = never
+}
+
+// Memoize wraps fn with a cache.
+func Memoize[K comparable, V any](fn func(K) (V, error), opts Options) *Memo[K, V] {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	if opts.ErrorTTL == 0 {
+		opts.ErrorTTL = opts.TTL
+	}
+	return &Memo[K, V]{fn: fn, opts: opts, entries: map[K]*list.Element{}, lru: list.New()}
+}
+
+// Func returns the memoized function as a plain function value, so it can
+// be passed anywhere the original was (like processIt in 12-functions).
+func (m *Memo[K, V]) Func() func(K) (V, error) { return m.Get }
+
+// Get returns the cached result for key, or calls the function.
+func (m *Memo[K, V]) Get(key K) (V, error) {
+	if e, ok := m.lookup(key); ok {
+		m.hits.Add(1)
+		return e.val, e.err
+	}
+	v, err, shared := m.group.Do(key, func() (V, error) {
+		// Another leader may have filled the cache between our lookup
+		// and getting here; check again before doing the work.
+		if e, ok := m.lookup(key); ok {
+			m.hits.Add(1)
+			return e.val, e.err
+		}
+		m.misses.Add(1)
+		v, err := m.fn(key)
+		m.store(key, v, err)
+		return v, err
+	})
+	if shared {
+		m.shared.Add(1)
+	}
+	return v, err
+}
+
+// lookup returns a live entry and marks it as recently used.
+func (m *Memo[K, V]) lookup(key K) (*entry[K, V], bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	el, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*entry[K, V])
+	if !e.expires.IsZero() && !m.opts.Now().Before(e.expires) {
+		m.lru.Remove(el)
+		delete(m.entries, key)
+		m.expired.Add(1)
+		return nil, false
+	}
+	m.lru.MoveToFront(el)
+	return e, true
+}
+
+// store caches a result, following the error policy and the size bound.
+func (m *Memo[K, V]) store(key K, v V, err error) {
+	ttl := m.opts.TTL
+	if err != nil {
+		if m.opts.CacheErrors == nil || !m.opts.CacheErrors(err) {
+			return
+		}
+		ttl = m.opts.ErrorTTL
+	}
+	e := &entry[K, V]{key: key, val: v, err: err}
+	if ttl > 0 {
+		e.expires = m.opts.Now().Add(ttl)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if el, ok := m.entries[key]; ok {
+		el.Value = e
+		m.lru.MoveToFront(el)
+		return
+	}
+	m.entries[key] = m.lru.PushFront(e)
+	for m.opts.MaxEntries > 0 && m.lru.Len() > m.opts.MaxEntries {
+		oldest := m.lru.Back()
+		m.lru.Remove(oldest)
+		delete(m.entries, oldest.Value.(*entry[K, V]).key)
+		m.evictions.Add(1)
+	}
+}
+
+// Forget removes key from the cache, e.g. after a known rate change.
+func (m *Memo[K, V]) Forget(key K) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if el, ok := m.entries[key]; ok {
+		m.lru.Remove(el)
+		delete(m.entries, key)
+	}
+}
+
+// Len returns the number of cached entries (including not yet noticed
+// expired ones).
+func (m *Memo[K, V]) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lru.Len()
+}
+
+// Stats returns a snapshot of the counters.
+func (m *Memo[K, V]) Stats() Stats {
+	return Stats{
+		Hits:      m.hits.Load(),
+		Misses:    m.misses.Load(),
+		Shared:    m.shared.Load(),
+		Evictions: m.evictions.Load(),
+		Expired:   m.expired.Load(),
+	}
+}
+
+// --------------------------------------------------------
+// SLOW FUNCTIONS TO MEMOIZE
+// --------------------------------------------------------
+
+var (
+	ErrUnknownCurrency = errors.New("unknown currency pair")
+	ErrTimeout         = errors.New("rate service timeout")
+)
+
+// rateService pretends to be a remote exchange-rate API.
+type rateService struct {
+	calls  atomic.Int64
+	delay  time.Duration
+	failOn map[string]error
+}
+
+func (s *rateService) fetch(pair string) (float64, error) {
+	s.calls.Add(1)
+	time.Sleep(s.delay)
+	if err, ok := s.failOn[pair]; ok {
+		return 0, err
+	}
+	rates := map[string]float64{"USD/INR": 83.12, "EUR/INR": 90.45, "GBP/INR": 105.3}
+	rate, ok := rates[pair]
+	if !ok {
+		return 0, ErrUnknownCurrency
+	}
+	return rate, nil
+}
+
+// fakeClock is a clock we move by hand.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func main() {
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)}
+
+	// --------------------------------------------------------
+	// 1. STAMPEDE PROTECTION
+	// --------------------------------------------------------
+	// 100 goroutines ask for the same rate at the same moment.
+	svc := &rateService{delay: 50 * time.Millisecond}
+	rates := Memoize(svc.fetch, Options{TTL: time.Minute, Now: clock.Now})
+	var wg sync.WaitGroup
+	for range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rates.Get("USD/INR")
+		}()
+	}
+	wg.Wait()
+	fmt.Println("100 concurrent lookups → API calls:", svc.calls.Load()) // Output: 1
+	fmt.Printf("Stats: %+v\n", rates.Stats())
+	fmt.Println()
+
+	// --------------------------------------------------------
+	// 2. TTL
+	// --------------------------------------------------------
+	rate := rates.Func() // a plain func(string) (float64, error)
+	r, _ := rate("USD/INR")
+	fmt.Println("USD/INR from cache:", r, "- API calls:", svc.calls.Load()) // still 1
+	clock.Advance(61 * time.Second)
+	r, _ = rate("USD/INR")
+	fmt.Println("USD/INR after 61s:", r, "- API calls:", svc.calls.Load()) // 2: expired
+	fmt.Println()
+
+	// --------------------------------------------------------
+	// 3. SIZE BOUND (LRU)
+	// --------------------------------------------------------
+	svc.delay = 0
+	small := Memoize(svc.fetch, Options{MaxEntries: 2, Now: clock.Now})
+	small.Get("USD/INR")
+	small.Get("EUR/INR")
+	small.Get("USD/INR") // USD is now the most recently used
+	small.Get("GBP/INR") // evicts EUR, the least recently used
+	before := svc.calls.Load()
+	small.Get("USD/INR")
+	fmt.Println("USD/INR still cached:", svc.calls.Load() == before)
+	small.Get("EUR/INR")
+	fmt.Println("EUR/INR was evicted: ", svc.calls.Load() == before+1)
+	fmt.Printf("Stats: %+v\n", small.Stats())
+	fmt.Println()
+
+	// --------------------------------------------------------
+	// 4. ERROR CACHING POLICY
+	// --------------------------------------------------------
+	// Cache "unknown currency" for 10s (it will not fix itself), but never
+	// cache timeouts (the next call might succeed).
+	svc.failOn = map[string]error{"JPY/INR": ErrTimeout}
+	lookups := Memoize(svc.fetch, Options{
+		TTL:         time.Minute,
+		CacheErrors: func(err error) bool { return errors.Is(err, ErrUnknownCurrency) },
+		ErrorTTL:    10 * time.Second,
+		Now:         clock.Now,
+	})
+	for _, pair := range []string{"XYZ/INR", "XYZ/INR", "JPY/INR", "JPY/INR"} {
+		before := svc.calls.Load()
+		_, err := lookups.Get(pair)
+		fmt.Printf("%s → %v (API called: %v)\n", pair, err, svc.calls.Load() > before)
+	}
+	clock.Advance(11 * time.Second)
+	before = svc.calls.Load()
+	lookups.Get("XYZ/INR")
+	fmt.Println("XYZ/INR after ErrorTTL, API called again:", svc.calls.Load() > before)
+	fmt.Printf("Stats: %+v hit rate %.0f%%\n", lookups.Stats(), lookups.Stats().HitRate()*100)
+	fmt.Println()
+
+	// --------------------------------------------------------
+	// 5. PANICS DO NOT STRAND WAITERS
+	// --------------------------------------------------------
+	var g Group[string, int]
+	_, err, _ := g.Do("boom", func() (int, error) { panic("gateway client bug") })
+	fmt.Println("Panicking call:", err)
+}
+
+// --------------------------------------------------------
+// SUMMARY
+// --------------------------------------------------------
+// 1. Because functions are values, Memoize can wrap any func(K) (V, error)
+//    and hand back a function with the same shape.
+// 2. TTL bounds staleness; MaxEntries + LRU bounds memory.
+// 3. Group (single-flight) turns N concurrent misses into 1 call.
+// 4. Errors are only cached when a policy says so, with their own TTL.
+// 5. Stats (hits, misses, shared, evictions, expired) show whether the
+//    cache is actually helping.
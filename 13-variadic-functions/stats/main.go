@@ -0,0 +1,610 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+)
+
+// ----------------------------------------------------------
+// STATISTICS WITH VARIADIC GENERIC FUNCTIONS
+// ----------------------------------------------------------
+// 13-variadic-functions has:
+//
+//	func sum(nums ...int) int
+//
+// Two problems: it only takes int, and it silently OVERFLOWS —
+// sum(math.MaxInt, 1) is a large negative number. This file keeps the
+// variadic shape and adds:
+//   - generics, so every function works for all integer and float types;
+//   - Sum with overflow checking for integers;
+//   - KahanSum, which keeps the rounding error of float additions;
+//   - mean, median, mode, variance, standard deviation, percentiles and
+//     histograms;
+//   - a Stream that summarises millions of values in constant memory.
+// ----------------------------------------------------------
+
+// Integer, Float and Number are the type constraints. The ~ means
+// "this type or any type defined on it", e.g. `type paise int64`.
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+type Float interface{ ~float32 | ~float64 }
+
+type Number interface{ Integer | Float }
+
+var (
+	ErrOverflow = errors.New("stats: integer overflow")
+	ErrEmpty    = errors.New("stats: no values")
+	ErrRange    = errors.New("stats: percentile out of range [0, 100]")
+)
+
+// ----------------------------------------------------------
+// 1. SUMS
+// ----------------------------------------------------------
+
+// Sum adds integers and reports ErrOverflow instead of wrapping around.
+//
+// Overflow check: adding a positive b must make the total bigger, and
+// adding a negative b must make it smaller. If not, it wrapped.
+func Sum[T Integer](nums ...T) (T, error) {
+	var total T
+	for _, n := range nums {
+		next := total + n
+		if (n > 0 && next < total) || (n < 0 && next > total) {
+			return total, fmt.Errorf("%w: %v + %v", ErrOverflow, total, n)
+		}
+		total = next
+	}
+	return total, nil
+}
+
+// KahanSum adds floats while tracking the low-order bits each addition
+// loses. Naive summation of 0.1 ten million times drifts in the 10th
+// digit; this stays exact to the last bit or two.
+//
+// This is Neumaier's variant of Kahan's algorithm: it also handles a
+// term larger than the running total, as in 1 + 1e100 - 1e100.
+func KahanSum[T Float](nums ...T) T {
+	var k kahan
+	for _, n := range nums {
+		k.add(float64(n))
+	}
+	return T(k.value())
+}
+
+// kahan is a compensated running sum.
+type kahan struct{ sum, c float64 }
+
+func (k *kahan) add(x float64) {
+	t := k.sum + x
+	if math.Abs(k.sum) >= math.Abs(x) {
+		k.c += (k.sum - t) + x // low bits of x were lost
+	} else {
+		k.c += (x - t) + k.sum // low bits of sum were lost
+	}
+	k.sum = t
+}
+
+func (k *kahan) value() float64 { return k.sum + k.c }
+
+// ----------------------------------------------------------
+// 2. DESCRIPTIVE STATISTICS
+// ----------------------------------------------------------
+// Results are float64 whatever T is: the mean of ints 1 and 2 is 1.5.
+
+// Mean is the arithmetic average.
+func Mean[T Number](nums ...T) (float64, error) {
+	if len(nums) == 0 {
+		return 0, ErrEmpty
+	}
+	var k kahan
+	for _, n := range nums {
+		k.add(float64(n))
+	}
+	return k.value() / float64(len(nums)), nil
+}
+
+// Median is the middle value; for an even count, the mean of the two
+// middle values. The input is not modified.
+func Median[T Number](nums ...T) (float64, error) {
+	return Percentile(50, nums...)
+}
+
+// Mode returns the most frequent values, smallest first (there may be
+// several), and how often they occur.
+func Mode[T Number](nums ...T) ([]T, int, error) {
+	if len(nums) == 0 {
+		return nil, 0, ErrEmpty
+	}
+	counts := map[T]int{}
+	best := 0
+	for _, n := range nums {
+		counts[n]++
+		best = max(best, counts[n])
+	}
+	var modes []T
+	for n, c := range counts {
+		if c == best {
+			modes = append(modes, n)
+		}
+	}
+	slices.Sort(modes)
+	return modes, best, nil
+}
+
+// Variance is the population variance: the mean squared distance from
+// the mean. Computed in two passes (mean first), which avoids the
+// catastrophic cancellation of the textbook E[x²] - E[x]² formula.
+func Variance[T Number](nums ...T) (float64, error) {
+	return variance(0, nums)
+}
+
+// SampleVariance divides by n-1 instead of n (Bessel's correction): the
+// right choice when nums is a sample of a larger population.
+func SampleVariance[T Number](nums ...T) (float64, error) {
+	if len(nums) == 1 {
+		return 0, fmt.Errorf("%w: sample variance needs at least 2", ErrEmpty)
+	}
+	return variance(1, nums)
+}
+
+func variance[T Number](ddof int, nums []T) (float64, error) {
+	mean, err := Mean(nums...)
+	if err != nil {
+		return 0, err
+	}
+	var k kahan
+	for _, n := range nums {
+		d := float64(n) - mean
+		k.add(d * d)
+	}
+	return k.value() / float64(len(nums)-ddof), nil
+}
+
+// StdDev is the population standard deviation, in the same unit as nums.
+func StdDev[T Number](nums ...T) (float64, error) {
+	v, err := Variance(nums...)
+	return math.Sqrt(v), err
+}
+
+// Percentile returns the p-th percentile (0–100), interpolating linearly
+// between the two nearest ranks — the method of Excel's PERCENTILE.INC
+// and NumPy's default.
+func Percentile[T Number](p float64, nums ...T) (float64, error) {
+	ps, err := Percentiles(nums, p)
+	if err != nil {
+		return 0, err
+	}
+	return ps[0], nil
+}
+
+// Percentiles returns several percentiles with a single sort.
+func Percentiles[T Number](nums []T, ps ...float64) ([]float64, error) {
+	if len(nums) == 0 {
+		return nil, ErrEmpty
+	}
+	sorted := slices.Clone(nums)
+	slices.Sort(sorted)
+	out := make([]float64, len(ps))
+	for i, p := range ps {
+		if p < 0 || p > 100 || math.IsNaN(p) {
+			return nil, fmt.Errorf("%w: %v", ErrRange, p)
+		}
+		out[i] = interpolate(sorted, p)
+	}
+	return out, nil
+}
+
+// interpolate finds percentile p in sorted data.
+func interpolate[T Number](sorted []T, p float64) float64 {
+	rank := p / 100 * float64(len(sorted)-1)
+	lo := int(rank)
+	if lo == len(sorted)-1 {
+		return float64(sorted[lo])
+	}
+	frac := rank - float64(lo)
+	return float64(sorted[lo]) + frac*(float64(sorted[lo+1])-float64(sorted[lo]))
+}
+
+// Bin is one histogram bucket, covering [Lo, Hi).
+type Bin struct {
+	Lo, Hi float64
+	Count  int
+}
+
+// Histogram splits the range of nums into bins of equal width. The last
+// bin includes its upper edge, so the maximum is counted.
+func Histogram[T Number](bins int, nums ...T) ([]Bin, error) {
+	if len(nums) == 0 {
+		return nil, ErrEmpty
+	}
+	lo, hi := float64(slices.Min(nums)), float64(slices.Max(nums))
+	h := newHistogram(lo, hi, bins)
+	for _, n := range nums {
+		h.add(float64(n))
+	}
+	return h.bins, nil
+}
+
+// histogram counts values into fixed bins. Values outside [lo, hi] are
+// counted as under/over rather than dropped.
+type histogram struct {
+	lo, width   float64
+	bins        []Bin
+	under, over int
+}
+
+func newHistogram(lo, hi float64, bins int) *histogram {
+	bins = max(bins, 1)
+	width := (hi - lo) / float64(bins)
+	h := &histogram{lo: lo, width: width, bins: make([]Bin, bins)}
+	for i := range h.bins {
+		h.bins[i].Lo = lo + float64(i)*width
+		h.bins[i].Hi = lo + float64(i+1)*width
+	}
+	h.bins[bins-1].Hi = hi // no rounding drift at the top edge
+	return h
+}
+
+func (h *histogram) add(x float64) {
+	last := len(h.bins) - 1
+	switch {
+	case x < h.lo:
+		h.under++
+	case x > h.bins[last].Hi:
+		h.over++
+	case h.width == 0:
+		h.bins[0].Count++ // all values equal
+	default:
+		h.bins[min(int((x-h.lo)/h.width), last)].Count++
+	}
+}
+
+// printHistogram draws bins as bars scaled to width characters.
+func printHistogram(bins []Bin, width int) {
+	most := 0
+	for _, b := range bins {
+		most = max(most, b.Count)
+	}
+	for _, b := range bins {
+		bar := 0
+		if most > 0 {
+			bar = b.Count * width / most
+		}
+		fmt.Printf("  %8.0f – %-8.0f %-*s %d\n", b.Lo, b.Hi, width, strings.Repeat("█", bar), b.Count)
+	}
+}
+
+// ----------------------------------------------------------
+// 3. STREAMING
+// ----------------------------------------------------------
+// The functions above need every value in memory (median needs a sort).
+// For a year of orders that may not fit. A Stream sees each value once
+// and keeps a fixed amount of state:
+//   - count, sum, min, max          exact
+//   - mean and variance             exact, by Welford's online algorithm
+//   - percentiles                   estimated, by the P² algorithm
+//   - histogram                     exact, with bins chosen up front
+//   - mode                          approximated by the fullest bin
+
+// Stream accumulates statistics over values it does not keep.
+type Stream[T Number] struct {
+	n         int
+	sum       kahan
+	mean, m2  float64 // Welford: running mean and sum of squared deviations
+	min, max  T
+	quantiles []*p2
+	histogram *histogram
+	percents  []float64
+}
+
+// StreamOptions choose what a Stream estimates besides the exact stats.
+type StreamOptions struct {
+	Percentiles    []float64 // e.g. 50, 95, 99
+	HistLo, HistHi float64   // histogram range
+	HistBins       int       // 0 = no histogram
+}
+
+// NewStream creates a Stream.
+func NewStream[T Number](opts StreamOptions) (*Stream[T], error) {
+	s := &Stream[T]{percents: opts.Percentiles}
+	for _, p := range opts.Percentiles {
+		if p < 0 || p > 100 || math.IsNaN(p) {
+			return nil, fmt.Errorf("%w: %v", ErrRange, p)
+		}
+		// P² keeps its extreme markers near, not at, the ends, so 0 and
+		// 100 are answered from the exact min and max instead (nil here).
+		var q *p2
+		if p > 0 && p < 100 {
+			q = &p2{p: p / 100}
+		}
+		s.quantiles = append(s.quantiles, q)
+	}
+	if opts.HistBins > 0 {
+		s.histogram = newHistogram(opts.HistLo, opts.HistHi, opts.HistBins)
+	}
+	return s, nil
+}
+
+// Add feeds values into the stream.
+func (s *Stream[T]) Add(nums ...T) {
+	for _, n := range nums {
+		x := float64(n)
+		if s.n == 0 || n < s.min {
+			s.min = n
+		}
+		if s.n == 0 || n > s.max {
+			s.max = n
+		}
+		s.n++
+		s.sum.add(x)
+		// Welford: update the mean, then accumulate the product of the
+		// distances from the old and the new mean.
+		delta := x - s.mean
+		s.mean += delta / float64(s.n)
+		s.m2 += delta * (x - s.mean)
+
+		for _, q := range s.quantiles {
+			if q != nil {
+				q.add(x)
+			}
+		}
+		if s.histogram != nil {
+			s.histogram.add(x)
+		}
+	}
+}
+
+// Count, Sum, Mean, Min and Max are exact.
+func (s *Stream[T]) Count() int     { return s.n }
+func (s *Stream[T]) Sum() float64   { return s.sum.value() }
+func (s *Stream[T]) Mean() float64  { return s.mean }
+func (s *Stream[T]) Min() (T, bool) { return s.min, s.n > 0 }
+func (s *Stream[T]) Max() (T, bool) { return s.max, s.n > 0 }
+
+// Variance is the population variance; StdDev its square root.
+func (s *Stream[T]) Variance() float64 {
+	if s.n == 0 {
+		return 0
+	}
+	return s.m2 / float64(s.n)
+}
+
+func (s *Stream[T]) StdDev() float64 { return math.Sqrt(s.Variance()) }
+
+// Percentile returns the estimate for p, which must be one of the
+// percentiles given in StreamOptions. Percentiles 0 and 100 are exact.
+func (s *Stream[T]) Percentile(p float64) (float64, bool) {
+	i := slices.Index(s.percents, p)
+	if i < 0 || s.n == 0 {
+		return 0, false
+	}
+	switch p {
+	case 0:
+		return float64(s.min), true
+	case 100:
+		return float64(s.max), true
+	}
+	return s.quantiles[i].value(), true
+}
+
+// Histogram returns the bins and the counts below and above their range.
+func (s *Stream[T]) Histogram() (bins []Bin, under, over int) {
+	if s.histogram == nil {
+		return nil, 0, 0
+	}
+	return s.histogram.bins, s.histogram.under, s.histogram.over
+}
+
+// ModalBin is the fullest histogram bin, the streaming stand-in for Mode.
+func (s *Stream[T]) ModalBin() (Bin, bool) {
+	if s.histogram == nil || s.n == 0 {
+		return Bin{}, false
+	}
+	return slices.MaxFunc(s.histogram.bins, func(a, b Bin) int { return a.Count - b.Count }), true
+}
+
+// p2 estimates one quantile with the P² algorithm (Jain & Chlamtac,
+// 1985). It keeps five markers: the minimum, the maximum, the quantile
+// itself and one halfway to each side. As values arrive, markers that
+// drift from their ideal rank are moved, and their heights adjusted by
+// fitting a parabola through their neighbours.
+type p2 struct {
+	p       float64
+	count   int
+	q       [5]float64 // marker heights
+	n       [5]int     // marker positions (ranks, 1-based)
+	desired [5]float64 // ideal positions
+	step    [5]float64 // how far each ideal position moves per value
+}
+
+func (e *p2) add(x float64) {
+	if e.count < 5 {
+		e.q[e.count] = x
+		e.count++
+		if e.count == 5 {
+			slices.Sort(e.q[:])
+			e.n = [5]int{1, 2, 3, 4, 5}
+			e.desired = [5]float64{1, 1 + 2*e.p, 1 + 4*e.p, 3 + 2*e.p, 5}
+			e.step = [5]float64{0, e.p / 2, e.p, (1 + e.p) / 2, 1}
+		}
+		return
+	}
+	e.count++
+
+	// Find the cell k with q[k] <= x < q[k+1], stretching the ends.
+	var k int
+	switch {
+	case x < e.q[0]:
+		e.q[0] = x
+	case x >= e.q[4]:
+		e.q[4] = x
+		k = 3
+	default:
+		for k < 3 && x >= e.q[k+1] {
+			k++
+		}
+	}
+	for i := k + 1; i < 5; i++ {
+		e.n[i]++
+	}
+	for i := range e.desired {
+		e.desired[i] += e.step[i]
+	}
+
+	// Move the three middle markers toward their ideal positions.
+	for i := 1; i <= 3; i++ {
+		d := e.desired[i] - float64(e.n[i])
+		if (d >= 1 && e.n[i+1]-e.n[i] > 1) || (d <= -1 && e.n[i-1]-e.n[i] < -1) {
+			dir := 1
+			if d < 0 {
+				dir = -1
+			}
+			q := e.parabolic(i, float64(dir))
+			if e.q[i-1] < q && q < e.q[i+1] {
+				e.q[i] = q
+			} else {
+				e.q[i] = e.linear(i, dir)
+			}
+			e.n[i] += dir
+		}
+	}
+}
+
+func (e *p2) parabolic(i int, d float64) float64 {
+	n0, n1, n2 := float64(e.n[i-1]), float64(e.n[i]), float64(e.n[i+1])
+	return e.q[i] + d/(n2-n0)*((n1-n0+d)*(e.q[i+1]-e.q[i])/(n2-n1)+(n2-n1-d)*(e.q[i]-e.q[i-1])/(n1-n0))
+}
+
+func (e *p2) linear(i, d int) float64 {
+	return e.q[i] + float64(d)*(e.q[i+d]-e.q[i])/float64(e.n[i+d]-e.n[i])
+}
+
+// value is the current estimate; exact while fewer than five values.
+func (e *p2) value() float64 {
+	if e.count < 5 {
+		sorted := slices.Clone(e.q[:e.count])
+		slices.Sort(sorted)
+		return interpolate(sorted, e.p*100)
+	}
+	return e.q[2]
+}
+
+// ----------------------------------------------------------
+// MAIN
+// ----------------------------------------------------------
+
+// sum is the original from 13-variadic-functions, for comparison.
+func sum(nums ...int) int {
+	total := 0
+	for _, val := range nums {
+		total += val
+	}
+	return total
+}
+
+// paise is an order amount in paise (1 rupee = 100 paise). Integer
+// money avoids float rounding; the ~int64 in Integer lets it use Sum.
+type paise int64
+
+func main() {
+	// 1. Overflow.
+	fmt.Println("sum(MaxInt, 1)       =", sum(math.MaxInt, 1))
+	_, err := Sum(math.MaxInt, 1)
+	fmt.Println("Sum(MaxInt, 1) error =", err)
+	small, err := Sum[int8](100, 27)
+	fmt.Println("Sum[int8](100, 27)   =", small, err)
+	_, err = Sum[uint8](200, 100)
+	fmt.Println("Sum[uint8](200, 100) error =", err)
+	fmt.Println()
+
+	// 2. Compensated float sums.
+	tenths := make([]float64, 10_000_000)
+	for i := range tenths {
+		tenths[i] = 0.1
+	}
+	naive := 0.0
+	for _, x := range tenths {
+		naive += x
+	}
+	fmt.Printf("0.1 × 10,000,000  naive: %.10f  KahanSum: %.10f\n", naive, KahanSum(tenths...))
+	one, huge := 1.0, 1e100 // variables: constants would be folded exactly
+	fmt.Println("1 + 1e100 - 1e100 naive:", one+huge-huge, " KahanSum:", KahanSum(one, huge, -huge))
+	fmt.Println()
+
+	// 3. Descriptive statistics over a day's orders (rupees).
+	amounts := []float64{240, 320, 180, 650, 399, 899, 299, 240, 1250, 180, 240, 560}
+	mean, _ := Mean(amounts...)
+	median, _ := Median(amounts...)
+	modes, times, _ := Mode(amounts...)
+	sd, _ := StdDev(amounts...)
+	ps, _ := Percentiles(amounts, 25, 75, 90)
+	fmt.Printf("Orders: %v\n", amounts)
+	fmt.Printf("  mean ₹%.2f  median ₹%.2f  mode %v (×%d)  stddev ₹%.2f\n", mean, median, modes, times, sd)
+	fmt.Printf("  p25 ₹%.2f  p75 ₹%.2f  p90 ₹%.2f\n", ps[0], ps[1], ps[2])
+	bins, _ := Histogram(4, amounts...)
+	printHistogram(bins, 20)
+	_, err = Mean[float64]()
+	fmt.Println("  Mean() of nothing:", err)
+	_, err = Percentile(120, amounts...)
+	fmt.Println("  Percentile(120):  ", err)
+
+	// The same functions on a named integer type.
+	inPaise := []paise{24000, 32050, 18099}
+	total, _ := Sum(inPaise...)
+	avg, _ := Mean(inPaise...)
+	fmt.Printf("  paise: total %d, mean %.2f\n", total, avg)
+	fmt.Println()
+
+	// 4. Streaming a million orders. Amounts are log-normal — most orders
+	//    are small, a few are large — with a fixed seed so runs repeat.
+	const n = 1_000_000
+	rng := rand.New(rand.NewPCG(1, 2))
+	stream, _ := NewStream[float64](StreamOptions{
+		Percentiles: []float64{0, 50, 95, 99, 100},
+		HistLo:      0, HistHi: 3000, HistBins: 6,
+	})
+	all := make([]float64, 0, n) // kept only to check the estimates
+	for range n {
+		amount := math.Round(math.Exp(6+0.6*rng.NormFloat64())*100) / 100
+		stream.Add(amount)
+		all = append(all, amount)
+	}
+	exactMean, _ := Mean(all...)
+	exactSD, _ := StdDev(all...)
+	exactPs, _ := Percentiles(all, 0, 50, 95, 99, 100)
+	lo, _ := stream.Min()
+	hi, _ := stream.Max()
+	fmt.Printf("Stream of %d orders: sum ₹%.2f, min ₹%.2f, max ₹%.2f\n", stream.Count(), stream.Sum(), lo, hi)
+	fmt.Printf("  %-8s %12s %12s\n", "", "stream", "exact")
+	fmt.Printf("  %-8s %12.2f %12.2f\n", "mean", stream.Mean(), exactMean)
+	fmt.Printf("  %-8s %12.2f %12.2f\n", "stddev", stream.StdDev(), exactSD)
+	for i, p := range []float64{0, 50, 95, 99, 100} {
+		est, _ := stream.Percentile(p)
+		fmt.Printf("  %-8s %12.2f %12.2f\n", fmt.Sprintf("p%.0f", p), est, exactPs[i])
+	}
+	hist, under, over := stream.Histogram()
+	printHistogram(hist, 30)
+	fmt.Printf("  below range: %d, above range: %d\n", under, over)
+	if modal, ok := stream.ModalBin(); ok {
+		fmt.Printf("  most orders are ₹%.0f–₹%.0f\n", modal.Lo, modal.Hi)
+	}
+}
+
+// ----------------------------------------------------------
+// SUMMARY
+// ----------------------------------------------------------
+// 1. `nums ...T` with a type constraint: one variadic function for every
+//    numeric type, including named ones like paise.
+// 2. Check integer overflow by the sign of the change; compensate float
+//    rounding with Kahan–Neumaier summation.
+// 3. Exact statistics need the data; percentiles need it sorted.
+// 4. Streams keep O(1) state: Welford for mean and variance, P² for
+//    percentiles, fixed bins for the histogram.
+// ----------------------------------------------------------
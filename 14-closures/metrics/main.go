@@ -0,0 +1,638 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//
+// ------------------------ A METRICS REGISTRY ------------------------
+// counter() in 14-closures returns a closure over a plain `count`:
+//
+//	return func() int { count += 1; return count }
+//
+// That is fine in one goroutine, but `count += 1` is read-modify-write:
+// two goroutines can both read 5 and both write 6, losing an increment
+// (`go run -race` reports it as a DATA RACE). Production code counts
+// things from many goroutines at once — every HTTP request runs in its own.
+//
+// This program grows counter() into a small metrics library:
+//   - Counter   — only goes up (payments made). sync/atomic, no locks.
+//   - Gauge     — goes up and down (orders currently in a status).
+//   - Histogram — counts observations in buckets (payment latency).
+//   - Vec       — a labelled family: payments_total{gateway="stripe"}.
+//   - Registry  — owns all metrics and writes them in the Prometheus text
+//     format, served over HTTP at /metrics.
+//
+// Run it with:
+//
+//	go run -race main.go                  # demo, prints the exposition
+//	go run main.go -addr=:9090            # also serve http://localhost:9090/metrics
+// ---------------------------------------------------------------------
+//
+
+// ------------------------- COUNTER -------------------------
+
+// Counter is a monotonically increasing count. The zero value is ready to use.
+type Counter struct {
+	v atomic.Uint64
+}
+
+// Inc adds one and returns the new value.
+func (c *Counter) Inc() uint64 { return c.v.Add(1) }
+
+// Add adds n and returns the new value.
+func (c *Counter) Add(n uint64) uint64 { return c.v.Add(n) }
+
+// Value returns the current count.
+func (c *Counter) Value() uint64 { return c.v.Load() }
+
+// counter is 14-closures' counter(), made safe: the closure still
+// captures its own state, but the state is an atomic Counter.
+func counter() func() uint64 {
+	var c Counter
+	return func() uint64 { return c.Inc() }
+}
+
+// ------------------------- GAUGE -------------------------
+
+// Gauge is a value that can go up and down. float64 has no atomic type,
+// so we store its bits in an atomic.Uint64 and update with compare-and-swap.
+type Gauge struct {
+	bits atomic.Uint64
+}
+
+// Set replaces the value.
+func (g *Gauge) Set(v float64) { g.bits.Store(math.Float64bits(v)) }
+
+// Add adds delta (which may be negative).
+func (g *Gauge) Add(delta float64) { addFloat(&g.bits, delta) }
+
+// Inc adds one.
+func (g *Gauge) Inc() { g.Add(1) }
+
+// Dec subtracts one.
+func (g *Gauge) Dec() { g.Add(-1) }
+
+// Value returns the current value.
+func (g *Gauge) Value() float64 { return math.Float64frombits(g.bits.Load()) }
+
+// addFloat atomically adds delta to a float64 stored as bits. If another
+// goroutine changed the value between our Load and CompareAndSwap, the
+// swap fails and we simply try again with the fresh value.
+func addFloat(bits *atomic.Uint64, delta float64) {
+	for {
+		old := bits.Load()
+		next := math.Float64bits(math.Float64frombits(old) + delta)
+		if bits.CompareAndSwap(old, next) {
+			return
+		}
+	}
+}
+
+// ------------------------- HISTOGRAM -------------------------
+
+// DefBuckets are latency buckets in seconds, from 5ms to 10s.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Histogram counts observations into buckets by upper bound.
+// Each bucket is counted separately here; the exposition makes them
+// cumulative (le="0.1" includes everything ≤ 0.1), as Prometheus expects.
+type Histogram struct {
+	upper  []float64
+	counts []atomic.Uint64 // len(upper)+1; the last one is +Inf
+	sum    atomic.Uint64   // float64 bits
+}
+
+// NewHistogram creates a histogram. Buckets must be sorted and unique.
+func NewHistogram(buckets []float64) (*Histogram, error) {
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i] <= buckets[i-1] {
+			return nil, fmt.Errorf("histogram buckets must be strictly increasing, got %v", buckets)
+		}
+	}
+	return &Histogram{
+		upper:  append([]float64(nil), buckets...),
+		counts: make([]atomic.Uint64, len(buckets)+1),
+	}, nil
+}
+
+// Observe records one value.
+func (h *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.upper, v) // first bucket with upper >= v
+	h.counts[i].Add(1)
+	addFloat(&h.sum, v)
+}
+
+// ObserveSince records the seconds elapsed since start.
+func (h *Histogram) ObserveSince(start time.Time) {
+	h.Observe(time.Since(start).Seconds())
+}
+
+// snapshot returns cumulative bucket counts, the sum and the count.
+func (h *Histogram) snapshot() (cumulative []uint64, sum float64, count uint64) {
+	cumulative = make([]uint64, len(h.counts))
+	var running uint64
+	for i := range h.counts {
+		running += h.counts[i].Load()
+		cumulative[i] = running
+	}
+	// Report the +Inf bucket as the total of the buckets so the output is
+	// self-consistent even while other goroutines keep observing.
+	return cumulative, math.Float64frombits(h.sum.Load()), running
+}
+
+// ------------------------- LABELLED FAMILIES -------------------------
+
+// Vec is a family of metrics of the same kind, one per combination of
+// label values: payments_total{gateway="stripe",outcome="success"}.
+// M is *Counter, *Gauge or *Histogram.
+type Vec[M any] struct {
+	labels   []string
+	newChild func() M
+
+	mu       sync.RWMutex
+	children map[string]*child[M]
+}
+
+type child[M any] struct {
+	values []string
+	metric M
+}
+
+func newVec[M any](labels []string, newChild func() M) *Vec[M] {
+	return &Vec[M]{labels: labels, newChild: newChild, children: map[string]*child[M]{}}
+}
+
+// With returns the metric for the given label values, creating it on first
+// use. It panics on a wrong number of values, like an out-of-range index:
+// that is a programming error, not a runtime condition.
+func (v *Vec[M]) With(values ...string) M {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: got %d label values for labels %v", len(values), v.labels))
+	}
+	key := strings.Join(values, "\xff") // a byte that cannot appear in UTF-8 text
+
+	// Fast path: a read lock, so many goroutines can look up at once.
+	v.mu.RLock()
+	c, ok := v.children[key]
+	v.mu.RUnlock()
+	if ok {
+		return c.metric
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if c, ok := v.children[key]; ok { // someone else created it meanwhile
+		return c.metric
+	}
+	c = &child[M]{values: append([]string(nil), values...), metric: v.newChild()}
+	v.children[key] = c
+	return c.metric
+}
+
+// each calls f for every child, sorted by label values for stable output.
+func (v *Vec[M]) each(f func(labels string, m M)) {
+	v.mu.RLock()
+	kids := make([]*child[M], 0, len(v.children))
+	for _, c := range v.children {
+		kids = append(kids, c)
+	}
+	v.mu.RUnlock()
+	sort.Slice(kids, func(i, j int) bool {
+		return strings.Join(kids[i].values, "\xff") < strings.Join(kids[j].values, "\xff")
+	})
+	for _, c := range kids {
+		f(formatLabels(v.labels, c.values), c.metric)
+	}
+}
+
+// ------------------------- REGISTRY -------------------------
+
+// metricType is the "# TYPE" of a family.
+type metricType string
+
+const (
+	typeCounter   metricType = "counter"
+	typeGauge     metricType = "gauge"
+	typeHistogram metricType = "histogram"
+)
+
+// family is one registered metric name with its help text and a function
+// that writes its samples.
+type family struct {
+	name  string
+	help  string
+	typ   metricType
+	write func(w io.Writer, name string)
+}
+
+// Registry holds every metric family of a program.
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+// ErrDuplicate is returned when a metric name is registered twice.
+var ErrDuplicate = errors.New("metric already registered")
+
+var (
+	metricNameRE = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNameRE  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{families: map[string]*family{}}
+}
+
+func (r *Registry) register(f *family, labels []string) error {
+	if !metricNameRE.MatchString(f.name) {
+		return fmt.Errorf("invalid metric name %q", f.name)
+	}
+	for _, l := range labels {
+		if !labelNameRE.MatchString(l) || strings.HasPrefix(l, "__") || l == "le" {
+			return fmt.Errorf("invalid label name %q for %s", l, f.name)
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.families[f.name]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicate, f.name)
+	}
+	r.families[f.name] = f
+	return nil
+}
+
+// NewCounterVec registers a labelled counter family.
+func (r *Registry) NewCounterVec(name, help string, labels ...string) (*Vec[*Counter], error) {
+	v := newVec(labels, func() *Counter { return new(Counter) })
+	f := &family{name: name, help: help, typ: typeCounter, write: func(w io.Writer, name string) {
+		v.each(func(labels string, c *Counter) {
+			fmt.Fprintf(w, "%s%s %d\n", name, labels, c.Value())
+		})
+	}}
+	return v, r.register(f, labels)
+}
+
+// NewGaugeVec registers a labelled gauge family.
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) (*Vec[*Gauge], error) {
+	v := newVec(labels, func() *Gauge { return new(Gauge) })
+	f := &family{name: name, help: help, typ: typeGauge, write: func(w io.Writer, name string) {
+		v.each(func(labels string, g *Gauge) {
+			fmt.Fprintf(w, "%s%s %s\n", name, labels, formatFloat(g.Value()))
+		})
+	}}
+	return v, r.register(f, labels)
+}
+
+// NewHistogramVec registers a labelled histogram family.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) (*Vec[*Histogram], error) {
+	if _, err := NewHistogram(buckets); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	v := newVec(labels, func() *Histogram {
+		h, _ := NewHistogram(buckets) // already validated above
+		return h
+	})
+	f := &family{name: name, help: help, typ: typeHistogram, write: func(w io.Writer, name string) {
+		v.each(func(labels string, h *Histogram) {
+			cumulative, sum, count := h.snapshot()
+			for i, n := range cumulative {
+				le := "+Inf"
+				if i < len(h.upper) {
+					le = formatFloat(h.upper[i])
+				}
+				fmt.Fprintf(w, "%s_bucket%s %d\n", name, withLabel(labels, "le", le), n)
+			}
+			fmt.Fprintf(w, "%s_sum%s %s\n", name, labels, formatFloat(sum))
+			fmt.Fprintf(w, "%s_count%s %d\n", name, labels, count)
+		})
+	}}
+	return v, r.register(f, labels)
+}
+
+// NewCounter registers a counter without labels.
+func (r *Registry) NewCounter(name, help string) (*Counter, error) {
+	v, err := r.NewCounterVec(name, help)
+	if err != nil {
+		return nil, err
+	}
+	return v.With(), nil
+}
+
+// WriteText writes every family in the Prometheus text exposition format:
+//
+//	# HELP payments_total Payments by gateway and outcome.
+//	# TYPE payments_total counter
+//	payments_total{gateway="stripe",outcome="success"} 3
+func (r *Registry) WriteText(w io.Writer) {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	r.mu.Unlock()
+	sort.Strings(names)
+
+	for _, name := range names {
+		r.mu.Lock()
+		f := r.families[name]
+		r.mu.Unlock()
+		fmt.Fprintf(w, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+		fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.typ)
+		f.write(w, f.name)
+	}
+}
+
+// ServeHTTP makes the registry an http.Handler for /metrics.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WriteText(w)
+}
+
+// ------------------------- TEXT FORMAT HELPERS -------------------------
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string { return helpEscaper.Replace(s) }
+
+// formatLabels renders {a="1",b="2"}, or "" when there are no labels.
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, n := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `%s="%s"`, n, labelEscaper.Replace(values[i]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// withLabel appends one more label to an already formatted label set.
+func withLabel(labels, name, value string) string {
+	pair := fmt.Sprintf(`%s="%s"`, name, labelEscaper.Replace(value))
+	if labels == "" {
+		return "{" + pair + "}"
+	}
+	return strings.TrimSuffix(labels, "}") + "," + pair + "}"
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// ------------------------- INSTRUMENTED PAYMENTS -------------------------
+
+// PaymentGateway is the interface from 17-interfaces, with an error result.
+type PaymentGateway interface {
+	Name() string
+	Pay(amount float32) error
+}
+
+// Stripe succeeds quickly.
+type Stripe struct{}
+
+func (Stripe) Name() string { return "stripe" }
+
+func (Stripe) Pay(amount float32) error {
+	time.Sleep(time.Duration(2+rand.Intn(8)) * time.Millisecond)
+	return nil
+}
+
+// Razorpay is slower and declines one payment in five.
+type Razorpay struct{}
+
+func (Razorpay) Name() string { return "razorpay" }
+
+func (Razorpay) Pay(amount float32) error {
+	time.Sleep(time.Duration(10+rand.Intn(40)) * time.Millisecond)
+	if rand.Intn(5) == 0 {
+		return errors.New("card declined")
+	}
+	return nil
+}
+
+// PaymentMetrics are the metrics recorded for every payment.
+type PaymentMetrics struct {
+	Total    *Vec[*Counter]   // payments_total{gateway, outcome}
+	Duration *Vec[*Histogram] // payment_duration_seconds{gateway}
+	Amount   *Vec[*Histogram] // payment_amount_rupees{gateway}
+}
+
+// NewPaymentMetrics registers the payment metrics in r.
+func NewPaymentMetrics(r *Registry) (*PaymentMetrics, error) {
+	var m PaymentMetrics
+	var err error
+	if m.Total, err = r.NewCounterVec("payments_total", "Payments by gateway and outcome.", "gateway", "outcome"); err != nil {
+		return nil, err
+	}
+	if m.Duration, err = r.NewHistogramVec("payment_duration_seconds", "Time spent in the gateway.", DefBuckets, "gateway"); err != nil {
+		return nil, err
+	}
+	if m.Amount, err = r.NewHistogramVec("payment_amount_rupees", "Payment amounts.", []float64{100, 500, 1000, 5000, 10000}, "gateway"); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// InstrumentedGateway wraps any PaymentGateway and records metrics around
+// each Pay call. The wrapped gateway does not know it is being measured.
+type InstrumentedGateway struct {
+	PaymentGateway
+	m *PaymentMetrics
+}
+
+// Pay forwards the payment and records its outcome, duration and amount.
+func (g InstrumentedGateway) Pay(amount float32) error {
+	start := time.Now()
+	err := g.PaymentGateway.Pay(amount)
+	name := g.Name()
+
+	g.m.Duration.With(name).ObserveSince(start)
+	g.m.Amount.With(name).Observe(float64(amount))
+	outcome := "success"
+	if err != nil {
+		outcome = "failure"
+	}
+	g.m.Total.With(name, outcome).Inc()
+	return err
+}
+
+// ------------------------- INSTRUMENTED ORDERS -------------------------
+
+// Order statuses, in the order an order moves through them.
+const (
+	Recieved  = "Recieved"
+	Confirmed = "Confirmed"
+	Prepared  = "Prepared"
+	Delivered = "Delivered"
+)
+
+var nextStatus = map[string]string{Recieved: Confirmed, Confirmed: Prepared, Prepared: Delivered}
+
+// order is the struct from 16-structs.
+type order struct {
+	id     string
+	amount float32
+	status string
+}
+
+// OrderMetrics track where orders are and how they move.
+type OrderMetrics struct {
+	Transitions *Vec[*Counter] // order_transitions_total{from, to}
+	InStatus    *Vec[*Gauge]   // orders_in_status{status}
+}
+
+// NewOrderMetrics registers the order metrics in r.
+func NewOrderMetrics(r *Registry) (*OrderMetrics, error) {
+	var m OrderMetrics
+	var err error
+	if m.Transitions, err = r.NewCounterVec("order_transitions_total", "Order status transitions.", "from", "to"); err != nil {
+		return nil, err
+	}
+	if m.InStatus, err = r.NewGaugeVec("orders_in_status", "Orders currently in each status.", "status"); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// received records a new order.
+func (m *OrderMetrics) received(o *order) {
+	o.status = Recieved
+	m.InStatus.With(Recieved).Inc()
+}
+
+// advance moves an order to its next status and records the transition.
+func (m *OrderMetrics) advance(o *order) bool {
+	next, ok := nextStatus[o.status]
+	if !ok {
+		return false
+	}
+	m.Transitions.With(o.status, next).Inc()
+	m.InStatus.With(o.status).Dec()
+	m.InStatus.With(next).Inc()
+	o.status = next
+	return true
+}
+
+// ------------------------- MAIN -------------------------
+
+func main() {
+	addr := flag.String("addr", "", "serve /metrics on this address, e.g. :9090")
+	flag.Parse()
+
+	// 1. counter(), now safe: 100 goroutines × 1000 increments each.
+	increment := counter()
+	var wg sync.WaitGroup
+	for range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 1000 {
+				increment()
+			}
+		}()
+	}
+	wg.Wait()
+	fmt.Println("After 100 × 1000 concurrent increments:", increment()-1) // Output: 100000
+	fmt.Println()
+
+	// 2. A registry with payment and order metrics.
+	reg := NewRegistry()
+	pm, err := NewPaymentMetrics(reg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	om, err := NewOrderMetrics(reg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	_, err = reg.NewCounterVec("payments_total", "registered twice")
+	fmt.Println("Registering payments_total again:", err)
+	_, err = reg.NewCounterVec("payment-errors", "bad name")
+	fmt.Println("Registering payment-errors:", err)
+	fmt.Println()
+
+	// 3. Traffic: 40 orders paid concurrently through both gateways; each
+	//    successful order then moves along Confirmed → Prepared → Delivered.
+	gateways := []PaymentGateway{
+		InstrumentedGateway{Stripe{}, pm},
+		InstrumentedGateway{Razorpay{}, pm},
+	}
+	for i := range 40 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			o := &order{id: strconv.Itoa(i + 1), amount: float32(50 + rand.Intn(12000))}
+			om.received(o)
+			if err := gateways[i%2].Pay(o.amount); err != nil {
+				return // stays in Recieved
+			}
+			for range rand.Intn(4) {
+				om.advance(o)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 4. The exposition, fetched over HTTP exactly as Prometheus would.
+	srv := httptest.NewServer(reg)
+	defer srv.Close()
+	resp, err := http.Get(srv.URL + "/metrics")
+	if err != nil {
+		log.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	fmt.Println("Content-Type:", resp.Header.Get("Content-Type"))
+	fmt.Print(string(body))
+
+	if *addr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", reg)
+		fmt.Printf("\nServing metrics on http://%s/metrics (Ctrl+C to stop)\n", *addr)
+		log.Fatal(http.ListenAndServe(*addr, mux))
+	}
+}
+
+// ---------------------------- SUMMARY ---------------------------------
+// 1. A closure over a plain int races across goroutines; an atomic counter
+//    keeps the closure style and makes it safe.
+// 2. Floats are made atomic by storing their bits and retrying CAS.
+// 3. Histograms keep per-bucket counts and report cumulative "le" buckets.
+// 4. Vec[M] is one generic labelled family for counters, gauges and
+//    histograms; lookups take a read lock, creation a write lock.
+// 5. The Registry validates names, rejects duplicates and serves the
+//    Prometheus text format, so instrumenting code is one line per event.
+// -----------------------------------------------------------------------
@@ -0,0 +1,470 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//
+// ------------------------- RATE LIMITERS -------------------------
+// counter() in 14-closures is a closure that remembers state between calls.
+// A rate limiter is the same idea with a clock attached: "has this customer
+// made more than 3 payment attempts in the last minute?"
+//
+// Two classic algorithms:
+//
+//  1. TOKEN BUCKET: a bucket holds up to `burst` tokens and refills at
+//     `rate` tokens per second. Each request takes one token. Allows short
+//     bursts, then a steady rate. Memory: two numbers per key.
+//
+//  2. SLIDING WINDOW LOG: remember the time of every accepted request and
+//     allow at most `limit` in any `window`. Exact, with no burst at window
+//     edges, but memory grows with `limit`.
+//
+// Around them:
+//   - Limiter: makes an algorithm safe for concurrent use and offers both
+//     Allow() (never blocks) and Wait(ctx) (blocks until allowed).
+//   - Keyed: one limiter per key (customer id, API key), with idle keys
+//     evicted so the map does not grow forever.
+//   - Clock: an interface, so the demo (and tests) can control time
+//     instead of sleeping.
+//   - Middleware: answers HTTP 429 Too Many Requests with Retry-After.
+// ------------------------------------------------------------------
+//
+
+// ------------------------- CLOCK -------------------------
+
+// Clock is the source of time. Production code uses RealClock; examples
+// use a FakeClock that only moves when told to.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// RealClock is the system clock.
+type RealClock struct{}
+
+func (RealClock) Now() time.Time                         { return time.Now() }
+func (RealClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// FakeClock is a manually advanced clock.
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+// NewFakeClock starts a fake clock at t.
+func NewFakeClock(t time.Time) *FakeClock { return &FakeClock{now: t} }
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeWaiter{c.now.Add(d), ch})
+	return ch
+}
+
+// Waiters returns how many After channels have not fired yet.
+func (c *FakeClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// Advance moves time forward and fires every After channel that is due.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = pending
+}
+
+// ------------------------- ALGORITHMS -------------------------
+
+// Algorithm decides whether one request at time `now` is allowed. If not,
+// retryAfter says how long until it would be. Algorithms are NOT safe for
+// concurrent use on their own; Limiter adds the lock.
+type Algorithm interface {
+	Take(now time.Time) (ok bool, retryAfter time.Duration)
+}
+
+// TokenBucket refills `rate` tokens per second up to `burst`.
+type TokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket creates a full bucket. burst must be at least 1: a
+// bucket that can never hold a whole token would never allow anything.
+func NewTokenBucket(ratePerSecond float64, burst int) (*TokenBucket, error) {
+	if burst < 1 {
+		return nil, fmt.Errorf("token bucket: burst must be at least 1, got %d", burst)
+	}
+	return &TokenBucket{rate: ratePerSecond, burst: float64(burst), tokens: float64(burst)}, nil
+}
+
+func (b *TokenBucket) Take(now time.Time) (bool, time.Duration) {
+	if !b.last.IsZero() && now.After(b.last) {
+		// Refill lazily: instead of a ticker adding tokens, compute how
+		// many would have been added since the last request.
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	if b.last.IsZero() || now.After(b.last) {
+		b.last = now
+	}
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	if b.rate <= 0 {
+		return false, time.Duration(math.MaxInt64)
+	}
+	missing := 1 - b.tokens
+	return false, time.Duration(math.Ceil(missing / b.rate * float64(time.Second)))
+}
+
+// SlidingLog allows at most `limit` requests in any `window`.
+type SlidingLog struct {
+	limit  int
+	window time.Duration
+	log    []time.Time // accepted request times, oldest first
+}
+
+// NewSlidingLog creates an empty log.
+func NewSlidingLog(limit int, window time.Duration) *SlidingLog {
+	return &SlidingLog{limit: limit, window: window}
+}
+
+func (s *SlidingLog) Take(now time.Time) (bool, time.Duration) {
+	// Forget requests that have slid out of the window.
+	cutoff := now.Add(-s.window)
+	i := sort.Search(len(s.log), func(i int) bool { return s.log[i].After(cutoff) })
+	s.log = s.log[i:]
+
+	if len(s.log) < s.limit {
+		s.log = append(s.log, now)
+		return true, 0
+	}
+	if s.limit <= 0 {
+		return false, time.Duration(math.MaxInt64)
+	}
+	// The oldest entry leaves the window at log[0] + window.
+	return false, s.log[0].Add(s.window).Sub(now)
+}
+
+// ------------------------- LIMITER -------------------------
+
+// ErrLimitExceeded is returned by Wait when the context ends before the
+// request could be allowed.
+var ErrLimitExceeded = errors.New("rate limit exceeded")
+
+// Limiter is a concurrency-safe wrapper around an Algorithm.
+type Limiter struct {
+	mu    sync.Mutex
+	alg   Algorithm
+	clock Clock
+}
+
+// NewLimiter creates a limiter. A nil clock means the real clock.
+func NewLimiter(alg Algorithm, clock Clock) *Limiter {
+	if clock == nil {
+		clock = RealClock{}
+	}
+	return &Limiter{alg: alg, clock: clock}
+}
+
+// Reserve tries to take a slot now. If it is not allowed, it reports
+// how long the caller should wait before trying again.
+func (l *Limiter) Reserve() (ok bool, retryAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.alg.Take(l.clock.Now())
+}
+
+// Allow reports whether a request is allowed right now. It never blocks.
+func (l *Limiter) Allow() bool {
+	ok, _ := l.Reserve()
+	return ok
+}
+
+// Wait blocks until a request is allowed or ctx is done. If ctx has a
+// deadline that is sooner than the wait, it fails at once instead of
+// sleeping for nothing. The deadline is always real time (context uses
+// time.Now), so it is compared with a duration, never with l.clock.Now:
+// with a fake clock the two would be unrelated instants.
+func (l *Limiter) Wait(ctx context.Context) error {
+	for {
+		ok, wait := l.Reserve()
+		if ok {
+			return nil
+		}
+		if deadline, has := ctx.Deadline(); has && time.Until(deadline) < wait {
+			return fmt.Errorf("%w: would need to wait %v", ErrLimitExceeded, wait)
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %v", ErrLimitExceeded, ctx.Err())
+		case <-l.clock.After(wait):
+			// Try again: another goroutine may have taken the slot first.
+		}
+	}
+}
+
+// ------------------------- KEYED LIMITERS -------------------------
+
+// Keyed keeps one Limiter per key, created on first use. Keys unused for
+// longer than idleTTL are evicted. Choose idleTTL at least as long as a
+// full refill (or one window), so that an evicted key would have been
+// back at its full allowance anyway.
+type Keyed[K comparable] struct {
+	newAlg  func() Algorithm
+	clock   Clock
+	idleTTL time.Duration
+
+	mu        sync.Mutex
+	limiters  map[K]*keyedEntry
+	lastSweep time.Time
+	evicted   atomic.Uint64
+}
+
+type keyedEntry struct {
+	limiter  *Limiter
+	lastUsed time.Time
+}
+
+// NewKeyed creates a keyed limiter. newAlg builds the algorithm for a new key.
+func NewKeyed[K comparable](newAlg func() Algorithm, idleTTL time.Duration, clock Clock) *Keyed[K] {
+	if clock == nil {
+		clock = RealClock{}
+	}
+	return &Keyed[K]{newAlg: newAlg, clock: clock, idleTTL: idleTTL, limiters: map[K]*keyedEntry{}}
+}
+
+// Get returns the limiter for key, creating it if needed.
+func (k *Keyed[K]) Get(key K) *Limiter {
+	now := k.clock.Now()
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	// Sweeping on access (at most once per idleTTL) means no background
+	// goroutine is needed, and the cost is spread over many calls.
+	if now.Sub(k.lastSweep) >= k.idleTTL {
+		k.sweep(now)
+	}
+	e, ok := k.limiters[key]
+	if !ok {
+		e = &keyedEntry{limiter: NewLimiter(k.newAlg(), k.clock)}
+		k.limiters[key] = e
+	}
+	e.lastUsed = now
+	return e.limiter
+}
+
+func (k *Keyed[K]) sweep(now time.Time) {
+	for key, e := range k.limiters {
+		if now.Sub(e.lastUsed) >= k.idleTTL {
+			delete(k.limiters, key)
+			k.evicted.Add(1)
+		}
+	}
+	k.lastSweep = now
+}
+
+// Allow reports whether a request for key is allowed now.
+func (k *Keyed[K]) Allow(key K) bool { return k.Get(key).Allow() }
+
+// Reserve is Limiter.Reserve for key.
+func (k *Keyed[K]) Reserve(key K) (bool, time.Duration) { return k.Get(key).Reserve() }
+
+// Wait blocks until a request for key is allowed or ctx is done.
+func (k *Keyed[K]) Wait(ctx context.Context, key K) error { return k.Get(key).Wait(ctx) }
+
+// Len returns the number of keys currently tracked.
+func (k *Keyed[K]) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return len(k.limiters)
+}
+
+// ------------------------- HTTP MIDDLEWARE -------------------------
+
+// Middleware rejects requests over the limit with 429 Too Many Requests
+// and a Retry-After header (whole seconds, rounded up, as HTTP requires).
+// keyOf picks the key: an API key header, a user id, or the client IP.
+func Middleware(limits *Keyed[string], keyOf func(*http.Request) string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ok, wait := limits.Reserve(keyOf(r))
+		if !ok {
+			seconds := max(1, int64(math.Ceil(wait.Seconds())))
+			w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// APIKeyOrIP uses the X-API-Key header, falling back to the client IP.
+func APIKeyOrIP(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return "key:" + key
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// ------------------------- MAIN -------------------------
+
+func main() {
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	at := func() string { return clock.Now().Sub(start).String() }
+	bucket := func(rate float64, burst int) Algorithm {
+		b, err := NewTokenBucket(rate, burst)
+		if err != nil {
+			panic(err) // the values below are constants
+		}
+		return b
+	}
+
+	// 1. Token bucket: burst of 3, then one request per 2 seconds.
+	fmt.Println("Token bucket (burst 3, 0.5/s):")
+	tb := NewLimiter(bucket(0.5, 3), clock)
+	for i := range 5 {
+		ok, wait := tb.Reserve()
+		fmt.Printf("  t=%-4s request %d allowed=%-5v retry after %v\n", at(), i+1, ok, wait)
+	}
+	clock.Advance(2 * time.Second)
+	fmt.Printf("  t=%-4s after 2s: allowed=%v\n", at(), tb.Allow()) // one token refilled
+	_, err := NewTokenBucket(10, 0)
+	fmt.Println("  Burst 0:", err)
+	fmt.Println()
+
+	// 2. Sliding window log: 3 payment attempts per customer per minute.
+	fmt.Println("Payment attempts (3 per minute per customer):")
+	attempts := NewKeyed[string](func() Algorithm { return NewSlidingLog(3, time.Minute) }, 2*time.Minute, clock)
+	start = clock.Now() // `at` is a closure, so it sees the new start
+	for _, step := range []struct {
+		after    time.Duration
+		customer string
+	}{
+		{0, "cust-1"}, {10 * time.Second, "cust-1"}, {10 * time.Second, "cust-1"},
+		{10 * time.Second, "cust-1"}, // 4th in 30s: rejected
+		{0, "cust-2"},                // other customers are independent
+		{31 * time.Second, "cust-1"}, // the first attempt (t=0) has left the window
+	} {
+		clock.Advance(step.after)
+		ok, wait := attempts.Reserve(step.customer)
+		fmt.Printf("  t=%-4s %s allowed=%-5v retry after %v\n", at(), step.customer, ok, wait)
+	}
+	fmt.Println("  Keys tracked:", attempts.Len())
+	clock.Advance(3 * time.Minute)
+	attempts.Allow("cust-3") // any access triggers the idle sweep
+	fmt.Println("  Keys after 3 idle minutes:", attempts.Len(), "evicted:", attempts.evicted.Load())
+	fmt.Println()
+
+	// 3. Concurrency: 50 goroutines race for a burst of 10 on the same key.
+	var allowed atomic.Int64
+	var wg sync.WaitGroup
+	shared := NewKeyed[string](func() Algorithm { return bucket(1, 10) }, time.Minute, clock)
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if shared.Allow("api-key-1") {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	fmt.Println("50 concurrent requests, burst 10 → allowed:", allowed.Load()) // Output: 10
+	fmt.Println()
+
+	// 4. Wait(ctx) blocks until a token is available (fake clock advanced
+	//    from another goroutine), or fails fast if the deadline is too close.
+	waiter := NewLimiter(bucket(1, 1), clock)
+	waiter.Allow() // empty the bucket
+	done := make(chan error)
+	go func() { done <- waiter.Wait(context.Background()) }()
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond) // until Wait is blocked on the clock
+	}
+	clock.Advance(time.Second)
+	fmt.Println("Wait after the clock moved 1s:", <-done) // <nil>
+
+	waiter.Allow()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	fmt.Println("Wait with a 100ms timeout:", waiter.Wait(ctx))
+	fmt.Println()
+
+	// 5. HTTP middleware: 2 requests per API key, then 429 + Retry-After.
+	api := NewKeyed[string](func() Algorithm { return bucket(0.2, 2) }, time.Minute, clock)
+	handler := Middleware(api, APIKeyOrIP, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "payment accepted")
+	}))
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	for _, key := range []string{"alice", "alice", "alice", "bob"} {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/pay", nil)
+		req.Header.Set("X-API-Key", key)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		resp.Body.Close()
+		fmt.Printf("  %-5s → %d %s Retry-After=%q\n", key, resp.StatusCode,
+			http.StatusText(resp.StatusCode), resp.Header.Get("Retry-After"))
+	}
+}
+
+// ---------------------------- SUMMARY ---------------------------------
+// 1. Token bucket = burst + steady rate, refilled lazily from elapsed time.
+// 2. Sliding window log = exact "N per window", at the cost of a timestamp
+//    per accepted request.
+// 3. The algorithms are plain stateful values; one mutex in Limiter makes
+//    them safe for any number of goroutines.
+// 4. Keyed limiters create state per key on demand and sweep idle keys.
+// 5. Clock is an interface, so time can be faked instead of slept.
+// 6. HTTP clients are told when to come back with 429 + Retry-After.
+// -----------------------------------------------------------------------
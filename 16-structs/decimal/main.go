@@ -0,0 +1,554 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing/quick"
+)
+
+// ----------------------------------------------------------
+// DECIMAL: EXACT MONEY ARITHMETIC
+// ----------------------------------------------------------
+// 16-structs creates orders like newOrder("5", 100.49, "Recieved") with
+// a float32 amount. Binary floats cannot hold most decimal fractions:
+//
+//	float32(100.49) is really 100.48999786376953125
+//	0.1 + 0.2       is 0.30000000000000004 in float64
+//
+// A Decimal stores an integer coefficient and a SCALE (digits after the
+// point): 100.49 is 10049 × 10⁻². Addition, subtraction and multiplication
+// are then exact. Division usually is not (1/3), so it takes a Context
+// that says how many digits to keep and how to round — the same as
+// NUMERIC(12,2) in a database.
+// ----------------------------------------------------------
+
+// ------ THE TYPE ------
+
+// Decimal is coef × 10^-scale. The coefficient is a big.Int, so there is
+// no limit on size. The zero value is 0.
+//
+// Decimals are values: every operation returns a new Decimal and never
+// modifies its operands, so they can be copied and shared freely.
+type Decimal struct {
+	coef  *big.Int // nil means 0
+	scale int32    // digits after the decimal point, >= 0
+}
+
+var (
+	ErrSyntax    = errors.New("decimal: invalid syntax")
+	ErrDivByZero = errors.New("decimal: division by zero")
+	ErrOverflow  = errors.New("decimal: value does not fit precision")
+	ErrScale     = errors.New("decimal: negative scale")
+)
+
+// New returns coef × 10^-scale: New(10049, 2) is 100.49.
+func New(coef int64, scale int32) Decimal {
+	if scale < 0 {
+		panic("decimal: negative scale")
+	}
+	return Decimal{coef: big.NewInt(coef), scale: scale}
+}
+
+// MustParse is Parse for constants in code; it panics on bad input.
+func MustParse(s string) Decimal {
+	d, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// maxExponent bounds the e in "1e…": 1e999999999 would be a billion-digit
+// number, so a hostile input could exhaust memory.
+const maxExponent = 10_000
+
+// Parse reads "-123.4500" or "1.5e3". Trailing zeros are kept — "100.40"
+// has scale 2 — so String gives back exactly what was parsed.
+func Parse(s string) (Decimal, error) {
+	orig := s
+	exp := 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.Atoi(s[i+1:])
+		if err != nil || e < -maxExponent || e > maxExponent {
+			return Decimal{}, fmt.Errorf("%w: %q", ErrSyntax, orig)
+		}
+		exp, s = e, s[:i]
+	}
+	neg := false
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		neg, s = s[0] == '-', s[1:]
+	}
+	intPart, frac, _ := strings.Cut(s, ".")
+	digits := intPart + frac
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrSyntax, orig)
+	}
+	coef, _ := new(big.Int).SetString(digits, 10)
+	if neg {
+		coef.Neg(coef)
+	}
+	scale := len(frac) - exp
+	if scale < 0 { // 1.5e3: fold the exponent into the coefficient
+		coef.Mul(coef, pow10(-scale))
+		scale = 0
+	}
+	return Decimal{coef: coef, scale: int32(scale)}, nil
+}
+
+// String formats d in plain notation with exactly Scale() decimals.
+func (d Decimal) String() string {
+	digits := d.int().String()
+	neg := strings.HasPrefix(digits, "-")
+	digits = strings.TrimPrefix(digits, "-")
+	if s := int(d.scale); s > 0 {
+		if len(digits) <= s {
+			digits = strings.Repeat("0", s-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-s] + "." + digits[len(digits)-s:]
+	}
+	if neg {
+		return "-" + digits
+	}
+	return digits
+}
+
+// int returns the coefficient, never nil. Callers must not modify it.
+func (d Decimal) int() *big.Int {
+	if d.coef == nil {
+		return new(big.Int)
+	}
+	return d.coef
+}
+
+// Scale is the number of digits after the point.
+func (d Decimal) Scale() int32 { return d.scale }
+
+// Sign returns -1, 0 or +1.
+func (d Decimal) Sign() int { return d.int().Sign() }
+
+// Neg returns -d.
+func (d Decimal) Neg() Decimal { return Decimal{new(big.Int).Neg(d.int()), d.scale} }
+
+// Rat returns d as an exact fraction.
+func (d Decimal) Rat() *big.Rat { return new(big.Rat).SetFrac(d.int(), pow10(int(d.scale))) }
+
+// Cmp compares by value: 1.5 and 1.50 are equal.
+func (d Decimal) Cmp(e Decimal) int {
+	a, b := align(d, e)
+	return a.Cmp(b)
+}
+
+// Equal reports whether d and e have the same value.
+func (d Decimal) Equal(e Decimal) bool { return d.Cmp(e) == 0 }
+
+// pow10 returns 10^n.
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// align returns both coefficients at the larger of the two scales.
+func align(d, e Decimal) (*big.Int, *big.Int) {
+	a, b := d.int(), e.int()
+	switch {
+	case d.scale < e.scale:
+		a = new(big.Int).Mul(a, pow10(int(e.scale-d.scale)))
+	case e.scale < d.scale:
+		b = new(big.Int).Mul(b, pow10(int(d.scale-e.scale)))
+	}
+	return a, b
+}
+
+// ------ EXACT ARITHMETIC ------
+
+// Add returns d + e. The scale is the larger of the two.
+func (d Decimal) Add(e Decimal) Decimal {
+	a, b := align(d, e)
+	return Decimal{new(big.Int).Add(a, b), max(d.scale, e.scale)}
+}
+
+// Sub returns d - e.
+func (d Decimal) Sub(e Decimal) Decimal { return d.Add(e.Neg()) }
+
+// Mul returns d × e. The scales add up: 1.25 × 0.18 = 0.2250.
+func (d Decimal) Mul(e Decimal) Decimal {
+	return Decimal{new(big.Int).Mul(d.int(), e.int()), d.scale + e.scale}
+}
+
+// ------ ROUNDING ------
+
+// Rounding says what to do with the digits that do not fit.
+type Rounding int
+
+const (
+	// HalfEven rounds ties to the even neighbour: 0.125 → 0.12, 0.135 → 0.14.
+	// "Banker's rounding": over many values the ties cancel out, so sums
+	// do not drift upward.
+	HalfEven Rounding = iota
+	// HalfUp rounds ties away from zero: 0.125 → 0.13. What people learn
+	// at school, and what many tax rules require.
+	HalfUp
+	// Down truncates toward zero: 0.129 → 0.12.
+	Down
+)
+
+func (r Rounding) String() string {
+	switch r {
+	case HalfEven:
+		return "HalfEven"
+	case HalfUp:
+		return "HalfUp"
+	case Down:
+		return "Down"
+	}
+	return fmt.Sprintf("Rounding(%d)", int(r))
+}
+
+// Round returns d with the given scale. Extra digits are dropped using
+// mode; a larger scale just appends zeros. Like New, it panics on a
+// negative scale: rounding to tens or hundreds is not supported.
+func (d Decimal) Round(scale int32, mode Rounding) Decimal {
+	if scale < 0 {
+		panic("decimal: negative scale")
+	}
+	if scale >= d.scale {
+		return Decimal{new(big.Int).Mul(d.int(), pow10(int(scale-d.scale))), scale}
+	}
+	q, r := new(big.Int).QuoRem(d.int(), pow10(int(d.scale-scale)), new(big.Int))
+	return Decimal{roundQuotient(q, r, pow10(int(d.scale-scale)), mode), scale}
+}
+
+// roundQuotient adjusts the truncated quotient q of a division by div
+// with remainder r (which has the sign of the dividend).
+func roundQuotient(q, r, div *big.Int, mode Rounding) *big.Int {
+	if r.Sign() == 0 || mode == Down {
+		return q
+	}
+	// Compare the remainder with half the divisor: 2|r| vs |div|.
+	twice := new(big.Int).Abs(r)
+	twice.Lsh(twice, 1)
+	half := twice.CmpAbs(div)
+	away := half > 0 || half == 0 && (mode == HalfUp || q.Bit(0) == 1)
+	if away {
+		sign := r.Sign() // the dividend's sign; q may be 0
+		if div.Sign() < 0 {
+			sign = -sign
+		}
+		q.Add(q, big.NewInt(int64(sign)))
+	}
+	return q
+}
+
+// ------ CONTEXT ------
+
+// Context fixes precision and scale like a database NUMERIC(p, s):
+// Scale digits after the point, Precision digits in total.
+type Context struct {
+	Precision int // total significant digits; 0 = unlimited
+	Scale     int32
+	Rounding  Rounding
+}
+
+// Money is the context for rupee amounts: NUMERIC(12,2), banker's rounding.
+var Money = Context{Precision: 12, Scale: 2, Rounding: HalfEven}
+
+// Round fits d into the context, or returns ErrOverflow if its integer
+// part has too many digits — that is never silently rounded away.
+// A context with a negative Scale returns ErrScale.
+func (c Context) Round(d Decimal) (Decimal, error) {
+	if c.Scale < 0 {
+		return Decimal{}, fmt.Errorf("%w: NUMERIC(%d,%d)", ErrScale, c.Precision, c.Scale)
+	}
+	r := d.Round(c.Scale, c.Rounding)
+	if c.Precision > 0 {
+		if n := len(new(big.Int).Abs(r.int()).String()); n > c.Precision {
+			return Decimal{}, fmt.Errorf("%w: %s needs %d digits, NUMERIC(%d,%d)", ErrOverflow, d, n, c.Precision, c.Scale)
+		}
+	}
+	return r, nil
+}
+
+// Div returns d ÷ e rounded to the context.
+func (c Context) Div(d, e Decimal) (Decimal, error) {
+	if c.Scale < 0 {
+		return Decimal{}, fmt.Errorf("%w: NUMERIC(%d,%d)", ErrScale, c.Precision, c.Scale)
+	}
+	if e.Sign() == 0 {
+		return Decimal{}, ErrDivByZero
+	}
+	// d/e at scale s is (d.coef × 10^(s + e.scale - d.scale)) / e.coef.
+	num, den := new(big.Int).Set(d.int()), new(big.Int).Set(e.int())
+	if shift := int(c.Scale) + int(e.scale) - int(d.scale); shift >= 0 {
+		num.Mul(num, pow10(shift))
+	} else {
+		den.Mul(den, pow10(-shift))
+	}
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	return c.Round(Decimal{roundQuotient(q, r, den, c.Rounding), c.Scale})
+}
+
+// ------ JSON AND TEXT ------
+
+// MarshalJSON writes a JSON number with every digit: 100.49, not
+// 100.48999786376953. (A float64 on the reading side may still lose
+// digits; APIs that care send amounts as strings, which
+// UnmarshalJSON also accepts.)
+func (d Decimal) MarshalJSON() ([]byte, error) { return []byte(d.String()), nil }
+
+// UnmarshalJSON accepts 100.49 and "100.49". null leaves d unchanged,
+// as encoding/json does for built-in types.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unq, err := strconv.Unquote(s); err == nil {
+		s = unq
+	}
+	v, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// MarshalText and UnmarshalText make Decimal work anywhere text is
+// decoded generically: flag.TextVar, XML, map keys in JSON, and the CSV
+// import below.
+func (d Decimal) MarshalText() ([]byte, error) { return []byte(d.String()), nil }
+
+func (d *Decimal) UnmarshalText(text []byte) error {
+	v, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// ------ CSV IMPORT ------
+
+// order is the 16-structs order with a Decimal amount.
+type order struct {
+	ID     string  `json:"id"`
+	Amount Decimal `json:"amount"`
+	Status string  `json:"status"`
+}
+
+// readOrders imports orders from CSV with a header "id,amount,status".
+// Errors name the line, since a bad amount in row 50 000 must be findable.
+func readOrders(r io.Reader) ([]order, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 3
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("orders csv: %w", err)
+	}
+	if strings.Join(header, ",") != "id,amount,status" {
+		return nil, fmt.Errorf("orders csv: unexpected header %q", header)
+	}
+	var orders []order
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return orders, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("orders csv: %w", err)
+		}
+		o := order{ID: rec[0], Status: rec[2]}
+		if err := o.Amount.UnmarshalText([]byte(rec[1])); err != nil {
+			line, _ := cr.FieldPos(1)
+			return nil, fmt.Errorf("orders csv: line %d: amount: %w", line, err)
+		}
+		orders = append(orders, o)
+	}
+}
+
+// ----------------------------------------------------------
+// PROPERTY CHECKS AGAINST big.Rat
+// ----------------------------------------------------------
+// big.Rat is exact rational arithmetic — slow but obviously right — so
+// it is the reference. testing/quick feeds each property random inputs
+// and reports the first counterexample.
+
+// anyDecimal implements quick.Generator: random decimals with scales
+// 0–8 and coefficients from tiny to far beyond int64.
+type anyDecimal struct{ D Decimal }
+
+func (anyDecimal) Generate(r *rand.Rand, _ int) reflect.Value {
+	coef := big.NewInt(r.Int63n(1_000_000) - 500_000)
+	if r.Intn(4) == 0 {
+		coef.Mul(coef, big.NewInt(r.Int63()))
+	}
+	return reflect.ValueOf(anyDecimal{Decimal{coef, int32(r.Intn(9))}})
+}
+
+// roundRat is the reference rounding, written separately with fractions:
+// truncate x at the given scale, then compare the dropped part with ½.
+func roundRat(x *big.Rat, scale int32, mode Rounding) *big.Rat {
+	unit := new(big.Rat).SetInt(pow10(int(scale)))
+	scaled := new(big.Rat).Mul(x, unit)
+	trunc := new(big.Int).Quo(scaled.Num(), scaled.Denom()) // toward zero
+	dropped := new(big.Rat).Sub(scaled, new(big.Rat).SetInt(trunc))
+	vsHalf := dropped.Abs(dropped).Cmp(big.NewRat(1, 2))
+	var away bool
+	switch mode {
+	case HalfUp:
+		away = vsHalf >= 0
+	case HalfEven:
+		away = vsHalf > 0 || vsHalf == 0 && trunc.Bit(0) == 1
+	}
+	if away && dropped.Sign() != 0 {
+		trunc.Add(trunc, big.NewInt(int64(scaled.Sign())))
+	}
+	return new(big.Rat).Quo(new(big.Rat).SetInt(trunc), unit)
+}
+
+func runPropertyChecks() {
+	cfg := &quick.Config{MaxCount: 2000, Rand: rand.New(rand.NewSource(1))}
+	ratOp := func(op func(z, x, y *big.Rat) *big.Rat, x, y Decimal) *big.Rat {
+		return op(new(big.Rat), x.Rat(), y.Rat())
+	}
+	checks := []struct {
+		name string
+		prop any
+	}{
+		{"Add matches big.Rat", func(x, y anyDecimal) bool {
+			return x.D.Add(y.D).Rat().Cmp(ratOp((*big.Rat).Add, x.D, y.D)) == 0
+		}},
+		{"Sub matches big.Rat", func(x, y anyDecimal) bool {
+			return x.D.Sub(y.D).Rat().Cmp(ratOp((*big.Rat).Sub, x.D, y.D)) == 0
+		}},
+		{"Mul matches big.Rat", func(x, y anyDecimal) bool {
+			return x.D.Mul(y.D).Rat().Cmp(ratOp((*big.Rat).Mul, x.D, y.D)) == 0
+		}},
+		{"Div rounds the exact quotient", func(x, y anyDecimal, scale uint8, mode uint8) bool {
+			if y.D.Sign() == 0 {
+				return true
+			}
+			c := Context{Scale: int32(scale % 12), Rounding: Rounding(mode % 3)}
+			q, err := c.Div(x.D, y.D)
+			want := roundRat(ratOp((*big.Rat).Quo, x.D, y.D), c.Scale, c.Rounding)
+			return err == nil && q.Rat().Cmp(want) == 0 && q.Scale() == c.Scale
+		}},
+		{"Round matches big.Rat", func(x anyDecimal, scale uint8, mode uint8) bool {
+			s, m := int32(scale%10), Rounding(mode%3)
+			return x.D.Round(s, m).Rat().Cmp(roundRat(x.D.Rat(), s, m)) == 0
+		}},
+		{"Parse(String) round-trips", func(x anyDecimal) bool {
+			y, err := Parse(x.D.String())
+			return err == nil && y.String() == x.D.String() && y.Scale() == x.D.Scale()
+		}},
+		{"JSON round-trips", func(x anyDecimal) bool {
+			data, err := json.Marshal(x.D)
+			var y Decimal
+			return err == nil && json.Unmarshal(data, &y) == nil && y.String() == x.D.String()
+		}},
+	}
+	for _, c := range checks {
+		if err := quick.Check(c.prop, cfg); err != nil {
+			fmt.Printf("  FAIL %-30s %v\n", c.name, err)
+			continue
+		}
+		fmt.Printf("  ok   %s (%d cases)\n", c.name, cfg.MaxCount)
+	}
+}
+
+// ----------------------------------------------------------
+// MAIN
+// ----------------------------------------------------------
+
+func main() {
+	// 1. Why: floats cannot hold 100.49.
+	fmt.Printf("float32(100.49) = %.20f\n", float32(100.49))
+	fmt.Printf("Decimal 100.49  = %s\n", MustParse("100.49"))
+	f := 0.0
+	d := Decimal{}
+	for range 10 {
+		f += 0.1
+		d = d.Add(MustParse("0.1"))
+	}
+	fmt.Printf("0.1 added ten times: float64 %v, Decimal %s\n", f, d)
+	fmt.Println()
+
+	// 2. Exact arithmetic and rounding. 18% GST on ₹100.49.
+	amount, rate := MustParse("100.49"), MustParse("0.18")
+	gst := amount.Mul(rate)
+	fmt.Printf("GST: %s × %s = %s exactly\n", amount, rate, gst)
+	fmt.Printf("  rounded HalfUp: %s, HalfEven: %s, Down: %s\n",
+		gst.Round(2, HalfUp), gst.Round(2, HalfEven), gst.Round(2, Down))
+	for _, s := range []string{"0.125", "0.135", "-0.125"} {
+		x := MustParse(s)
+		fmt.Printf("  %-6s → HalfUp %-5s HalfEven %s\n", s, x.Round(2, HalfUp), x.Round(2, HalfEven))
+	}
+	fmt.Println()
+
+	// 3. Division needs a context. Split ₹100 three ways.
+	hundred := New(100, 0)
+	share, _ := Money.Div(hundred, New(3, 0))
+	remainder := hundred.Sub(share.Mul(New(3, 0)))
+	fmt.Printf("₹100 ÷ 3 = %s each, %s left over to assign\n", share, remainder)
+	_, err := Money.Div(hundred, Decimal{})
+	fmt.Println("₹100 ÷ 0:", err)
+	small := Context{Precision: 5, Scale: 2, Rounding: HalfEven}
+	_, err = small.Round(MustParse("1234.567"))
+	fmt.Println("NUMERIC(5,2) of 1234.567:", err)
+	_, err = Context{Scale: -2}.Div(New(12345, 0), New(1, 0))
+	fmt.Println("Scale -2:", err, "| Rounding(5) is", Rounding(5))
+	fmt.Println()
+
+	// 4. Parsing round-trips exactly, trailing zeros included.
+	for _, s := range []string{"100.40", "-0.001", "1.5e3", "2.50e-1", "12x"} {
+		if v, err := Parse(s); err != nil {
+			fmt.Printf("Parse(%q): %v\n", s, err)
+		} else {
+			fmt.Printf("Parse(%q) = %s (scale %d)\n", s, v, v.Scale())
+		}
+	}
+	fmt.Println()
+
+	// 5. JSON and CSV.
+	data, _ := json.Marshal(order{ID: "5", Amount: MustParse("100.49"), Status: "Recieved"})
+	fmt.Println("JSON:", string(data))
+	var o order
+	err = json.Unmarshal([]byte(`{"id":"6","amount":"249.90","status":"Confirmed"}`), &o)
+	fmt.Printf("From JSON: %+v %v\n", o, err)
+
+	orders, err := readOrders(strings.NewReader("id,amount,status\n1,500000,Recieved\n5,100.49,Recieved\n7,0.1,Confirmed\n8,0.2,Confirmed\n"))
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	total := Decimal{}
+	for _, o := range orders {
+		total = total.Add(o.Amount)
+	}
+	fmt.Printf("CSV: %d orders, total ₹%s\n", len(orders), total)
+	_, err = readOrders(strings.NewReader("id,amount,status\n1,500,Recieved\n2,12.3.4,Recieved\n"))
+	fmt.Println("CSV with a bad amount:", err)
+	fmt.Println()
+
+	// 6. Property checks.
+	fmt.Println("Property checks against big.Rat:")
+	runPropertyChecks()
+}
+
+// ----------------------------------------------------------
+// SUMMARY
+// ----------------------------------------------------------
+// 1. Decimal = big.Int coefficient + scale; value semantics.
+// 2. Add, Sub, Mul are exact. Div and Round need a rounding mode.
+// 3. HalfEven (banker's) avoids upward drift; HalfUp is the school rule.
+// 4. A Context is NUMERIC(p, s): too many integer digits is an error.
+// 5. JSON and text (un)marshalers plug it into encoders and importers.
+// 6. testing/quick checks every operation against big.Rat.
+// ----------------------------------------------------------
@@ -0,0 +1,634 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unsafe"
+)
+
+// ----------------------------------------------------------
+// DEEP STRUCTURAL DIFF
+// ----------------------------------------------------------
+// slices.Equal, maps.Equal and reflect.DeepEqual answer one question:
+// "are these the same?" When an order snapshot changes we want to know
+// WHAT changed:
+//
+//	customer.mobile: "+91 7493957674" → "+44 7700900123"
+//	items[1]: removed
+//
+// Diff walks two values of the same type with reflection — structs
+// (including embedded ones), pointers, interfaces, slices, arrays and
+// maps — and records a Change for every leaf that differs. The result
+// prints as text or as a JSON Patch (RFC 6902).
+// ----------------------------------------------------------
+
+// ------ PATHS ------
+
+// segment is one step into a value: a field, an index or a map key.
+type segment struct {
+	field string
+	index int
+	key   reflect.Value // valid only for map keys
+}
+
+// Path locates a change inside the compared values.
+type Path []segment
+
+// String renders the path the Go way: customer.mobile, items[2], tags["vip"].
+func (p Path) String() string {
+	var b strings.Builder
+	for _, s := range p {
+		switch {
+		case s.key.IsValid():
+			if s.key.Kind() == reflect.String {
+				fmt.Fprintf(&b, "[%q]", s.key.String())
+			} else {
+				fmt.Fprintf(&b, "[%v]", s.key)
+			}
+		case s.field != "":
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(s.field)
+		default:
+			fmt.Fprintf(&b, "[%d]", s.index)
+		}
+	}
+	return b.String()
+}
+
+// Pointer renders the path as a JSON Pointer (RFC 6901): /customer/mobile.
+func (p Path) Pointer() string {
+	var b strings.Builder
+	for _, s := range p {
+		var token string
+		switch {
+		case s.key.IsValid():
+			token = fmt.Sprint(s.key)
+		case s.field != "":
+			token = s.field
+		default:
+			token = strconv.Itoa(s.index)
+		}
+		// "~" and "/" are escaped as ~0 and ~1, in that order.
+		token = strings.ReplaceAll(token, "~", "~0")
+		token = strings.ReplaceAll(token, "/", "~1")
+		b.WriteString("/" + token)
+	}
+	return b.String()
+}
+
+// with returns a copy of p with one more segment. Copying matters: paths
+// are stored in Changes, and appending to a shared backing array would
+// let a later sibling overwrite an earlier change's path.
+func (p Path) with(s segment) Path {
+	return append(slices.Clip(p), s)
+}
+
+// ------ CHANGES ------
+
+// Op is the kind of change, named after the JSON Patch operations.
+type Op string
+
+const (
+	Add     Op = "add"
+	Remove  Op = "remove"
+	Replace Op = "replace"
+)
+
+// Change is one difference. From is invalid for Add, To for Remove.
+type Change struct {
+	Op       Op
+	Path     Path
+	From, To reflect.Value
+}
+
+// String renders the change as one line of text.
+func (c Change) String() string {
+	switch c.Op {
+	case Add:
+		return fmt.Sprintf("%s: added %s", c.Path, format(c.To))
+	case Remove:
+		return fmt.Sprintf("%s: removed %s", c.Path, format(c.From))
+	}
+	return fmt.Sprintf("%s: %s → %s", c.Path, format(c.From), format(c.To))
+}
+
+// format prints a value compactly: strings quoted, times in RFC 3339,
+// nil pointers as nil and structs with their field names.
+func format(v reflect.Value) string {
+	switch {
+	case !v.IsValid():
+		return "<invalid>"
+	case v.Kind() == reflect.String:
+		return strconv.Quote(v.String())
+	case v.Type() == timeType:
+		return v.Interface().(time.Time).Format(time.RFC3339)
+	case (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil():
+		return "nil"
+	case v.Kind() == reflect.Pointer:
+		return "&" + format(v.Elem())
+	}
+	return fmt.Sprintf("%+v", v)
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// ------ OPTIONS ------
+
+// Options tune the comparison.
+type Options struct {
+	// Ignore lists paths to skip. An entry with a dot or bracket must
+	// match the whole path ("customer.mobile"); a bare name matches that
+	// field at any depth ("createdAt").
+	Ignore []string
+	// FloatTolerance treats floats within this distance as equal, so
+	// 100.49 stored as float32 and as float64 do not show up as a change.
+	FloatTolerance float64
+}
+
+// ------ DIFF ------
+
+// differ holds the state of one Diff call.
+type differ struct {
+	opts    Options
+	changes []Change
+	// visited holds the pointer pairs on the current walk, from the root
+	// down. Meeting a pair again means the data has a cycle (a → b → a);
+	// walking it again would never end, and the differences along it are
+	// already being reported. A pair is dropped once its walk finishes, so
+	// data that is merely shared (two fields pointing at one customer) is
+	// still compared at every path.
+	visited map[visit]bool
+}
+
+type visit struct {
+	a, b unsafe.Pointer
+	typ  reflect.Type
+}
+
+// Diff compares a and b, which must have the same type, and returns the
+// changes that turn a into b. Unexported fields are compared too.
+func Diff(a, b any, opts Options) ([]Change, error) {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if !va.IsValid() || !vb.IsValid() {
+		return nil, fmt.Errorf("diff: nil value")
+	}
+	if va.Type() != vb.Type() {
+		return nil, fmt.Errorf("diff: type mismatch: %s vs %s", va.Type(), vb.Type())
+	}
+	d := &differ{opts: opts, visited: map[visit]bool{}}
+	d.diff(nil, addressable(va), addressable(vb))
+	return d.changes, nil
+}
+
+func (d *differ) add(op Op, path Path, from, to reflect.Value) {
+	d.changes = append(d.changes, Change{Op: op, Path: path, From: from, To: to})
+}
+
+func (d *differ) ignored(path Path) bool {
+	full := path.String()
+	last := ""
+	if len(path) > 0 {
+		last = path[len(path)-1].field
+	}
+	for _, ig := range d.opts.Ignore {
+		if ig == full || (!strings.ContainsAny(ig, ".[") && ig == last) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *differ) diff(path Path, a, b reflect.Value) {
+	if d.ignored(path) {
+		return
+	}
+	// A type with an Equal method knows best; time.Time is the classic
+	// case — two equal instants may differ in location or monotonic clock.
+	if eq, ok := equalMethod(a, b); ok {
+		if !eq {
+			d.add(Replace, path, a, b)
+		}
+		return
+	}
+
+	switch a.Kind() {
+	case reflect.Struct:
+		t := a.Type()
+		for i := range t.NumField() {
+			d.diff(path.with(segment{field: t.Field(i).Name}), exported(a.Field(i)), exported(b.Field(i)))
+		}
+
+	case reflect.Pointer:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				d.add(Replace, path, a, b)
+			}
+			return
+		}
+		if !d.enter(a, b) {
+			return
+		}
+		d.diff(path, a.Elem(), b.Elem())
+		d.leave(a, b)
+
+	case reflect.Interface:
+		if a.IsNil() || b.IsNil() || a.Elem().Type() != b.Elem().Type() {
+			if a.IsNil() != b.IsNil() || !a.IsNil() && a.Elem().Type() != b.Elem().Type() {
+				d.add(Replace, path, a, b)
+			}
+			return
+		}
+		d.diff(path, addressable(a.Elem()), addressable(b.Elem()))
+
+	case reflect.Slice, reflect.Array:
+		if a.Kind() == reflect.Slice {
+			if a.IsNil() != b.IsNil() && (a.Len() != 0 || b.Len() != 0) {
+				d.add(Replace, path, a, b)
+				return
+			}
+		}
+		n := min(a.Len(), b.Len())
+		for i := range n {
+			d.diff(path.with(segment{index: i}), a.Index(i), b.Index(i))
+		}
+		for i := n; i < b.Len(); i++ {
+			d.add(Add, path.with(segment{index: i}), reflect.Value{}, b.Index(i))
+		}
+		// Removals go from the end, so applying them one by one (as a
+		// JSON Patch does) never shifts an index still to be removed.
+		for i := a.Len() - 1; i >= n; i-- {
+			d.add(Remove, path.with(segment{index: i}), a.Index(i), reflect.Value{})
+		}
+
+	case reflect.Map:
+		if a.IsNil() != b.IsNil() && (a.Len() != 0 || b.Len() != 0) {
+			d.add(Replace, path, a, b)
+			return
+		}
+		if !d.enter(a, b) {
+			return
+		}
+		defer d.leave(a, b)
+		for _, k := range sortedKeys(a, b) {
+			va, vb := a.MapIndex(k), b.MapIndex(k)
+			p := path.with(segment{key: k})
+			switch {
+			case !vb.IsValid():
+				d.add(Remove, p, va, reflect.Value{})
+			case !va.IsValid():
+				d.add(Add, p, reflect.Value{}, vb)
+			default:
+				d.diff(p, addressable(va), addressable(vb))
+			}
+		}
+
+	case reflect.Float32, reflect.Float64:
+		if x, y := a.Float(), b.Float(); x != y && !(math.Abs(x-y) <= d.opts.FloatTolerance) {
+			d.add(Replace, path, a, b)
+		}
+
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		// Only identity can be compared.
+		if a.Pointer() != b.Pointer() {
+			d.add(Replace, path, a, b)
+		}
+
+	default: // bool, ints, uints, complex, string
+		if !a.Equal(b) {
+			d.add(Replace, path, a, b)
+		}
+	}
+}
+
+// enter marks the pair of references as being compared, reporting false
+// if it already is (a cycle).
+func (d *differ) enter(a, b reflect.Value) bool {
+	v := visit{a.UnsafePointer(), b.UnsafePointer(), a.Type()}
+	if d.visited[v] {
+		return false
+	}
+	d.visited[v] = true
+	return true
+}
+
+// leave unmarks the pair once its walk is done.
+func (d *differ) leave(a, b reflect.Value) {
+	delete(d.visited, visit{a.UnsafePointer(), b.UnsafePointer(), a.Type()})
+}
+
+// sortedKeys returns the union of both maps' keys in a stable order, so
+// the same inputs always give the same change list.
+func sortedKeys(a, b reflect.Value) []reflect.Value {
+	keys := a.MapKeys()
+	for _, k := range b.MapKeys() {
+		if !a.MapIndex(k).IsValid() {
+			keys = append(keys, k)
+		}
+	}
+	slices.SortFunc(keys, func(x, y reflect.Value) int {
+		return cmp.Compare(fmt.Sprint(x), fmt.Sprint(y))
+	})
+	return keys
+}
+
+// equalMethod calls a.Equal(b) if the type has a method
+// "func (T) Equal(T) bool", reporting whether it did.
+func equalMethod(a, b reflect.Value) (equal, ok bool) {
+	m := a.MethodByName("Equal")
+	if !m.IsValid() {
+		return false, false
+	}
+	mt := m.Type()
+	if mt.NumIn() != 1 || mt.In(0) != a.Type() || mt.NumOut() != 1 || mt.Out(0).Kind() != reflect.Bool {
+		return false, false
+	}
+	return m.Call([]reflect.Value{b})[0].Bool(), true
+}
+
+// ------ READING UNEXPORTED FIELDS ------
+// Every field in this repo is unexported (order.id, customer.mobile).
+// reflect lets us READ them through typed getters (String, Int, ...) but
+// refuses Interface() and method calls, which we need for Equal and for
+// JSON output. A debugging tool may see through that: for an addressable
+// field, unsafe gives a view of the same memory without the read-only
+// flag. We never write through it.
+
+// addressable returns v itself if it is addressable, or an addressable copy.
+func addressable(v reflect.Value) reflect.Value {
+	if v.CanAddr() {
+		return v
+	}
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+	return c
+}
+
+// exported returns a view of the struct field v that allows Interface().
+// Struct fields reached from an addressable struct are addressable.
+func exported(v reflect.Value) reflect.Value {
+	if v.CanInterface() {
+		return v
+	}
+	return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+}
+
+// ------ OUTPUT ------
+
+// Text renders one change per line.
+func Text(changes []Change) string {
+	var b strings.Builder
+	for _, c := range changes {
+		b.WriteString(c.String() + "\n")
+	}
+	return b.String()
+}
+
+// patchOp is one JSON Patch operation. Value is raw so a replace with
+// null is still written ("value": null) while a remove has no value.
+type patchOp struct {
+	Op    Op              `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// JSONPatch renders the changes as an RFC 6902 document.
+func JSONPatch(changes []Change) ([]byte, error) {
+	ops := make([]patchOp, 0, len(changes))
+	for _, c := range changes {
+		op := patchOp{Op: c.Op, Path: c.Path.Pointer()}
+		if c.Op != Remove {
+			val, err := jsonValue(c.To)
+			if err == nil {
+				op.Value, err = json.Marshal(val)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("diff: %s: %w", c.Path, err)
+			}
+		}
+		ops = append(ops, op)
+	}
+	return json.MarshalIndent(ops, "", "  ")
+}
+
+// errCycle is returned for values that point back into themselves: JSON
+// is a tree, so a ring of orders has no JSON form.
+var errCycle = errors.New("value contains a cycle")
+
+// jsonValue converts v into something encoding/json can marshal. json
+// skips unexported fields, which would turn every struct here into {},
+// so structs are converted field by field.
+func jsonValue(v reflect.Value) (any, error) {
+	e := &jsonEncoder{visited: map[visit]bool{}}
+	return e.value(v)
+}
+
+// jsonEncoder tracks the pointers on the current path, like differ does,
+// so a cycle is reported instead of recursing forever.
+type jsonEncoder struct {
+	visited map[visit]bool
+}
+
+func (e *jsonEncoder) value(v reflect.Value) (any, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+	v = addressable(v)
+	if m, ok := v.Interface().(json.Marshaler); ok && v.Kind() != reflect.Pointer {
+		return m, nil
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+		if v.Kind() == reflect.Interface {
+			return e.value(v.Elem())
+		}
+		if !e.enter(v) {
+			return nil, errCycle
+		}
+		defer e.leave(v)
+		return e.value(v.Elem())
+	case reflect.Struct:
+		out := map[string]any{}
+		for i := range v.NumField() {
+			f, err := e.value(exported(v.Field(i)))
+			if err != nil {
+				return nil, err
+			}
+			out[v.Type().Field(i).Name] = f
+		}
+		return out, nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+		out := make([]any, v.Len())
+		for i := range out {
+			x, err := e.value(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			out[i] = x
+		}
+		return out, nil
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, nil
+		}
+		if !e.enter(v) {
+			return nil, errCycle
+		}
+		defer e.leave(v)
+		out := map[string]any{}
+		for it := v.MapRange(); it.Next(); {
+			x, err := e.value(it.Value())
+			if err != nil {
+				return nil, err
+			}
+			out[fmt.Sprint(it.Key())] = x
+		}
+		return out, nil
+	}
+	return v.Interface(), nil
+}
+
+func (e *jsonEncoder) enter(v reflect.Value) bool {
+	k := visit{a: v.UnsafePointer(), typ: v.Type()}
+	if e.visited[k] {
+		return false
+	}
+	e.visited[k] = true
+	return true
+}
+
+func (e *jsonEncoder) leave(v reflect.Value) {
+	delete(e.visited, visit{a: v.UnsafePointer(), typ: v.Type()})
+}
+
+// ----------------------------------------------------------
+// MAIN
+// ----------------------------------------------------------
+
+// customer and order are the structs from 16-structs/struct-embedding,
+// with a few more fields to exercise slices, maps and pointers.
+type customer struct {
+	name   string
+	mobile string
+}
+
+type item struct {
+	sku string
+	qty int
+}
+
+type order struct {
+	id        string
+	amount    float32
+	status    string
+	createdAt time.Time
+	customer
+	items  []item
+	tags   map[string]string
+	coupon *string
+	next   *order // orders in a batch form a ring
+}
+
+func main() {
+	created := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	coupon := "NEWYEAR"
+	before := order{
+		id: "1", amount: 100.49, status: "Recieved", createdAt: created,
+		customer: customer{name: "Jhon", mobile: "+91 7493957674"},
+		items:    []item{{"SKU-1", 1}, {"SKU-2", 2}, {"SKU-3", 1}},
+		tags:     map[string]string{"channel": "web", "priority": "normal"},
+	}
+	after := before
+	after.status = "Confirmed"
+	after.createdAt = created.In(time.FixedZone("IST", 5*3600+1800)) // same instant
+	after.customer.mobile = "+44 7700900123"
+	after.items = []item{{"SKU-1", 3}, {"SKU-2", 2}}
+	after.tags = map[string]string{"channel": "web", "priority": "high", "gift": "yes"}
+	after.coupon = &coupon
+
+	// 1. The yes/no answer.
+	fmt.Println("reflect.DeepEqual:", reflect.DeepEqual(before, after))
+	fmt.Println()
+
+	// 2. What changed. createdAt is not listed: time.Time.Equal says the
+	//    two values are the same instant in different zones.
+	changes, err := Diff(before, after, Options{})
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	fmt.Print("Changes:\n", Text(changes))
+	fmt.Println()
+
+	// 3. Options: ignore fields, tolerate float noise. Another service
+	//    rebuilt amount from ten equal instalments and picked up rounding.
+	after.amount = 0
+	for range 10 {
+		after.amount += before.amount / 10
+	}
+	changes, _ = Diff(before, after, Options{Ignore: []string{"status", "customer", "items", "tags", "coupon"}})
+	fmt.Print("Without tolerance: ", Text(changes))
+	changes, _ = Diff(before, after, Options{
+		Ignore:         []string{"customer.mobile", "tags"},
+		FloatTolerance: 0.001,
+	})
+	fmt.Print("Ignoring customer.mobile and tags, tolerance 0.001:\n", Text(changes))
+	fmt.Println()
+
+	// 4. JSON Patch.
+	patch, err := JSONPatch(changes)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	fmt.Println("JSON Patch:")
+	fmt.Println(string(patch))
+	fmt.Println()
+
+	// 5. Cycles: two rings of orders, a → b → a. Without cycle detection
+	//    the walk would never end.
+	a1, a2 := &order{id: "A1", status: "Recieved"}, &order{id: "A2", status: "Recieved"}
+	a1.next, a2.next = a2, a1
+	b1, b2 := &order{id: "A1", status: "Recieved"}, &order{id: "A2", status: "Shipped"}
+	b1.next, b2.next = b2, b1
+	changes, _ = Diff(a1, b1, Options{})
+	fmt.Print("Cyclic batch:\n", Text(changes))
+
+	// A JSON Patch cannot hold the ring itself: linking a lone order into
+	// the batch replaces next with a value that points back into itself.
+	changes, _ = Diff(&order{id: "A1", status: "Recieved"}, b1, Options{})
+	_, err = JSONPatch(changes)
+	fmt.Println("JSON Patch of a ring:", err)
+
+	// 6. Mismatched types are an error, not a diff.
+	_, err = Diff(before, before.customer, Options{})
+	fmt.Println("Error:", err)
+}
+
+// ----------------------------------------------------------
+// SUMMARY
+// ----------------------------------------------------------
+// 1. reflect walks any value; Kind() says how (struct, slice, map, ...).
+// 2. Each change carries a Path that prints as Go (customer.mobile) or as
+//    a JSON Pointer (/customer/mobile).
+// 3. Types with an Equal method (time.Time) decide equality themselves.
+// 4. Pointer pairs on the current walk stop infinite loops on cycles,
+//    in the diff and in the JSON output.
+// 5. Unexported fields need Interface() for Equal and JSON; an addressable
+//    copy plus reflect.NewAt gives a read-only view of them.
+// ----------------------------------------------------------
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+//
+// ------------------ PROVIDER FEES AND SETTLEMENT FORECASTS ------------------
+// When we call Pay(1000) on Stripe, the merchant does NOT receive 1000.
+// The provider keeps a fee (e.g. 2% + ₹3), the government charges tax
+// (GST) on that fee, and the remaining "net" amount reaches our bank
+// account a few days later (the settlement cycle, e.g. T+2).
+//
+// Finance wants two answers:
+//   1. For every Pay call: gross, fee, tax on fee and net.
+//   2. A forecast: how much money will land from each provider, and when.
+//
+// The gateways themselves stay untouched. Fees are described by data
+// (a FeeSchedule per provider) and applied around the PaymentGateway call.
+// ---------------------------------------------------------------------------
+//
+
+// PaymentGateway is the interface from with_interface. Name() lets us look
+// up the fee schedule and settlement cycle for a provider.
+type PaymentGateway interface {
+	Name() string
+	Pay(amount float32) error
+}
+
+// Stripe and Razorpay are the same toy providers as in with_interface.
+type Stripe struct{}
+
+func (Stripe) Name() string { return "stripe" }
+
+func (Stripe) Pay(amount float32) error {
+	fmt.Println("Making payment using Stripe:", amount)
+	return nil
+}
+
+type Razorpay struct{}
+
+func (Razorpay) Name() string { return "razorpay" }
+
+func (Razorpay) Pay(amount float32) error {
+	fmt.Println("Making payment using Razorpay:", amount)
+	return nil
+}
+
+// ------------------------- FEE SCHEDULES -------------------------
+
+// Method is how the customer paid. Providers charge differently per method.
+type Method string
+
+const (
+	Card       Method = "card"
+	UPI        Method = "upi"
+	NetBanking Method = "netbanking"
+)
+
+// FeeRule is "percentage plus fixed". Percentages are kept in basis points
+// (1 bp = 0.01%) so that 2.36% is the integer 236 and nothing is lost
+// to float rounding.
+type FeeRule struct {
+	PercentBps int64 // e.g. 200 = 2.00%
+	FixedPaise int64 // e.g. 300 = ₹3.00
+}
+
+// FeeSchedule describes everything one provider charges.
+type FeeSchedule struct {
+	Default   FeeRule            // used when the method has no special rule
+	PerMethod map[Method]FeeRule // overrides for specific methods
+	TaxBps    int64              // tax charged on the fee, e.g. 1800 = 18% GST
+}
+
+// rule picks the FeeRule for a payment method.
+func (s FeeSchedule) rule(m Method) FeeRule {
+	if r, ok := s.PerMethod[m]; ok {
+		return r
+	}
+	return s.Default
+}
+
+// Fees is the breakdown recorded on every payment result.
+type Fees struct {
+	Gross int64 // what the customer paid
+	Fee   int64 // what the provider keeps
+	Tax   int64 // tax on the provider fee
+	Net   int64 // what reaches our bank account
+}
+
+// divRound divides and rounds half away from zero — how providers round paise.
+func divRound(n, d int64) int64 {
+	if n >= 0 {
+		return (n + d/2) / d
+	}
+	return (n - d/2) / d
+}
+
+// Compute applies the schedule to an amount (in paise).
+func (s FeeSchedule) Compute(gross int64, m Method) Fees {
+	r := s.rule(m)
+	fee := divRound(gross*r.PercentBps, 10000) + r.FixedPaise
+	tax := divRound(fee*s.TaxBps, 10000)
+	if fee+tax > gross {
+		// A provider never keeps more than it collected: the fixed part can
+		// exceed a tiny payment, so split gross between fee and tax instead.
+		fee = divRound(gross*10000, 10000+s.TaxBps)
+		tax = gross - fee
+	}
+	return Fees{Gross: gross, Fee: fee, Tax: tax, Net: gross - fee - tax}
+}
+
+// ------------------------- SETTLEMENT CYCLES -------------------------
+
+// SettlementCycle says when a provider pays out: T+Days after the payment.
+// Payments after CutoffHour count as the next day. When BusinessDays is
+// true, Saturdays and Sundays are skipped (banks do not settle on them).
+type SettlementCycle struct {
+	Days         int
+	CutoffHour   int // 1-23; 0 means no cut-off (a midnight cut-off would move every payment)
+	BusinessDays bool
+}
+
+// isWeekend uses the same check as the switch example in 07-switch.
+func isWeekend(t time.Time) bool {
+	switch t.Weekday() {
+	case time.Saturday, time.Sunday:
+		return true
+	}
+	return false
+}
+
+// SettlementDate works out the day the money arrives for a payment made at paidAt.
+func (c SettlementCycle) SettlementDate(paidAt time.Time) time.Time {
+	day := time.Date(paidAt.Year(), paidAt.Month(), paidAt.Day(), 0, 0, 0, 0, paidAt.Location())
+	if c.CutoffHour > 0 && paidAt.Hour() >= c.CutoffHour {
+		day = day.AddDate(0, 0, 1)
+	}
+	for added := 0; added < c.Days; {
+		day = day.AddDate(0, 0, 1)
+		if c.BusinessDays && isWeekend(day) {
+			continue
+		}
+		added++
+	}
+	// A settlement can never land on a weekend when business days are used.
+	for c.BusinessDays && isWeekend(day) {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day
+}
+
+// ProviderConfig groups the fee schedule and cycle of one provider.
+type ProviderConfig struct {
+	Fees  FeeSchedule
+	Cycle SettlementCycle
+}
+
+// ------------------------- PAYING WITH FEES -------------------------
+
+// PaymentResult is what every Pay call now returns: who, how, when and
+// the full fee breakdown, plus the expected settlement date.
+type PaymentResult struct {
+	Provider  string
+	Method    Method
+	PaidAt    time.Time
+	Fees      Fees
+	SettlesOn time.Time
+}
+
+// Payment wraps a gateway like in with_interface, but knows the provider
+// configuration so it can compute fees on every call.
+type Payment struct {
+	Gateway   PaymentGateway
+	Providers map[string]ProviderConfig
+	Now       func() time.Time // injectable so examples are deterministic
+}
+
+// MakePayment pays through the gateway and records fees on the result.
+func (p Payment) MakePayment(amount float32, method Method) (PaymentResult, error) {
+	cfg, ok := p.Providers[p.Gateway.Name()]
+	if !ok {
+		return PaymentResult{}, fmt.Errorf("no fee schedule for provider %q", p.Gateway.Name())
+	}
+	if err := p.Gateway.Pay(amount); err != nil {
+		return PaymentResult{}, err
+	}
+	now := p.Now()
+	gross := int64(math.Round(float64(amount) * 100))
+	return PaymentResult{
+		Provider:  p.Gateway.Name(),
+		Method:    method,
+		PaidAt:    now,
+		Fees:      cfg.Fees.Compute(gross, method),
+		SettlesOn: cfg.Cycle.SettlementDate(now),
+	}, nil
+}
+
+// ------------------------- FORECASTING -------------------------
+
+// ForecastLine is the expected payout of one provider on one date.
+type ForecastLine struct {
+	Provider string
+	Date     time.Time
+	Payments int
+	Gross    int64
+	Fees     int64 // fee + tax
+	Net      int64
+}
+
+// Forecast groups payment results by provider and settlement date.
+func Forecast(results []PaymentResult) []ForecastLine {
+	type key struct {
+		provider string
+		date     time.Time
+	}
+	lines := map[key]*ForecastLine{}
+	for _, r := range results {
+		k := key{r.Provider, r.SettlesOn}
+		line, ok := lines[k]
+		if !ok {
+			line = &ForecastLine{Provider: r.Provider, Date: r.SettlesOn}
+			lines[k] = line
+		}
+		line.Payments++
+		line.Gross += r.Fees.Gross
+		line.Fees += r.Fees.Fee + r.Fees.Tax
+		line.Net += r.Fees.Net
+	}
+
+	out := make([]ForecastLine, 0, len(lines))
+	for _, l := range lines {
+		out = append(out, *l)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].Date.Equal(out[j].Date) {
+			return out[i].Date.Before(out[j].Date)
+		}
+		return out[i].Provider < out[j].Provider
+	})
+	return out
+}
+
+// rupees formats paise as a rupee string, e.g. 123456 → "1234.56" and
+// -18 → "-0.18". The sign is written first: -18/100 is 0 in Go, so it
+// would otherwise be lost (and -18%100 is -18, not 18).
+func rupees(p int64) string {
+	sign := ""
+	if p < 0 {
+		sign, p = "-", -p
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, p/100, p%100)
+}
+
+// ----------------------------- MAIN -----------------------------------
+func main() {
+	providers := map[string]ProviderConfig{
+		"stripe": {
+			Fees: FeeSchedule{
+				Default: FeeRule{PercentBps: 290, FixedPaise: 300}, // 2.9% + ₹3
+				PerMethod: map[Method]FeeRule{
+					UPI: {PercentBps: 0, FixedPaise: 0}, // UPI is free
+				},
+				TaxBps: 1800, // 18% GST on the fee
+			},
+			Cycle: SettlementCycle{Days: 2, CutoffHour: 18, BusinessDays: true}, // T+2
+		},
+		"razorpay": {
+			Fees: FeeSchedule{
+				Default: FeeRule{PercentBps: 200}, // 2%
+				PerMethod: map[Method]FeeRule{
+					NetBanking: {PercentBps: 190, FixedPaise: 500},
+				},
+				TaxBps: 1800,
+			},
+			Cycle: SettlementCycle{Days: 3, CutoffHour: 23, BusinessDays: false}, // T+3 calendar days
+		},
+	}
+
+	// A fixed clock: Thursday 2 January 2025, moving forward 5 hours per payment.
+	clock := time.Date(2025, time.January, 2, 9, 0, 0, 0, time.UTC)
+	now := func() time.Time {
+		t := clock
+		clock = clock.Add(5 * time.Hour)
+		return t
+	}
+
+	stripePayment := Payment{Gateway: Stripe{}, Providers: providers, Now: now}
+	razorpayPayment := Payment{Gateway: Razorpay{}, Providers: providers, Now: now}
+
+	var results []PaymentResult
+	record := func(r PaymentResult, err error) {
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		results = append(results, r)
+	}
+	record(stripePayment.MakePayment(1000, Card))         // Thu 09:00 → T+2 business days
+	record(razorpayPayment.MakePayment(1000, Card))       // Thu 14:00 → T+3 calendar days
+	record(stripePayment.MakePayment(799.99, Card))       // Thu 19:00, after the 18:00 cut-off → counts as Friday
+	record(razorpayPayment.MakePayment(4000, NetBanking)) // Fri 00:00
+	record(stripePayment.MakePayment(2500, UPI))          // Fri 05:00, UPI has no fee
+	record(stripePayment.MakePayment(2, Card))            // Fri 10:00, ₹3 fixed fee > ₹2 paid: net is 0
+	fmt.Println()
+
+	// 1. Per-payment fees.
+	fmt.Println("Provider  Method      Paid at           Gross      Fee    Tax      Net  Settles")
+	for _, r := range results {
+		fmt.Printf("%-9s %-10s  %s %8s %8s %6s %8s  %s\n",
+			r.Provider, r.Method, r.PaidAt.Format("Mon 02 Jan 15:04"),
+			rupees(r.Fees.Gross), rupees(r.Fees.Fee), rupees(r.Fees.Tax), rupees(r.Fees.Net),
+			r.SettlesOn.Format("Mon 02 Jan"))
+	}
+	fmt.Println()
+
+	// 2. Settlement forecast per provider and date.
+	fmt.Println("Forecast:")
+	for _, l := range Forecast(results) {
+		fmt.Printf("  %s  %-9s %d payment(s)  gross %9s  fees %7s  net %9s\n",
+			l.Date.Format("Mon 02 Jan"), l.Provider, l.Payments,
+			rupees(l.Gross), rupees(l.Fees), rupees(l.Net))
+	}
+	fmt.Println()
+
+	// 3. A cycle without a cut-off: even a payment at 23:30 counts as today.
+	late := time.Date(2025, time.January, 2, 23, 30, 0, 0, time.UTC)
+	fmt.Println("T+1, no cut-off, paid Thu 23:30 → settles",
+		SettlementCycle{Days: 1}.SettlementDate(late).Format("Mon 02 Jan")) // Fri 03 Jan
+}
+
+//
+// ---------------------------- SUMMARY ---------------------------------
+// 1. Fee rules are data (percentage in basis points + fixed paise), chosen
+//    per provider and per payment method, with tax applied to the fee.
+// 2. Payment.MakePayment records gross, fee, tax and net on every result,
+//    without changing any gateway implementation.
+// 3. SettlementCycle (T+N, cut-off hour, business days) predicts when the
+//    money arrives; Forecast groups results by provider and date.
+// -----------------------------------------------------------------------
+//
@@ -0,0 +1,511 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"time"
+)
+
+//
+// ------------------------ GATEWAY HEALTH CHECKS ------------------------
+// With an interface we can swap Stripe for Razorpay at runtime — but how do
+// we know which one is actually reachable right now?
+//
+// We add three pieces:
+//   1. HealthChecker: an OPTIONAL interface a gateway may implement.
+//      Gateways that do not implement it still work; they are "unknown".
+//   2. Prober: a background goroutine that calls HealthCheck periodically
+//      and remembers latency and success of the recent probes.
+//   3. An HTTP endpoint that reports every gateway's status as JSON, and a
+//      Router that uses the same status to choose where to send payments.
+// ------------------------------------------------------------------------
+//
+
+// PaymentGateway is the interface from with_interface, now with a name and
+// an error result so that routing can react to failures.
+type PaymentGateway interface {
+	Name() string
+	Pay(amount float32) error
+}
+
+// HealthChecker is implemented by gateways that can tell us whether they
+// are reachable. The context carries the probe timeout.
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// ------------------------- SIMULATED GATEWAYS -------------------------
+
+// Stripe is healthy and fast.
+type Stripe struct{}
+
+func (Stripe) Name() string { return "stripe" }
+
+func (Stripe) Pay(amount float32) error {
+	fmt.Println("Making payment using Stripe:", amount)
+	return nil
+}
+
+func (Stripe) HealthCheck(ctx context.Context) error {
+	return sleep(ctx, 5*time.Millisecond)
+}
+
+// Razorpay becomes unreachable after SetOutage(true) — a stand-in for a
+// real network failure.
+type Razorpay struct {
+	mu     sync.Mutex
+	outage bool
+}
+
+func (r *Razorpay) Name() string { return "razorpay" }
+
+func (r *Razorpay) SetOutage(down bool) {
+	r.mu.Lock()
+	r.outage = down
+	r.mu.Unlock()
+}
+
+func (r *Razorpay) down() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.outage
+}
+
+func (r *Razorpay) Pay(amount float32) error {
+	if r.down() {
+		return errors.New("razorpay: connection refused")
+	}
+	fmt.Println("Making payment using Razorpay:", amount)
+	return nil
+}
+
+func (r *Razorpay) HealthCheck(ctx context.Context) error {
+	if r.down() {
+		// Simulate a hanging connection: only the probe timeout stops us.
+		return sleep(ctx, time.Second)
+	}
+	return sleep(ctx, 10*time.Millisecond)
+}
+
+// Paypal does NOT implement HealthChecker; its status will be "unknown".
+type Paypal struct{}
+
+func (Paypal) Name() string { return "paypal" }
+
+func (Paypal) Pay(amount float32) error {
+	fmt.Println("Making payment using Paypal:", amount)
+	return nil
+}
+
+// sleep waits for d, or returns the context error if the context ends first.
+func sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ------------------------- PROBE RESULTS -------------------------
+
+// State is the summarised health of a gateway.
+type State string
+
+const (
+	StateUnknown  State = "unknown"  // no probes yet, or no HealthChecker
+	StateHealthy  State = "healthy"  // recent probes succeed
+	StateDegraded State = "degraded" // some recent probes fail
+	StateDown     State = "down"     // the last few probes all failed
+)
+
+// Sample is the result of one probe.
+type Sample struct {
+	At      time.Time
+	Latency time.Duration
+	Err     error
+}
+
+// GatewayStatus is what the endpoint reports and the router consumes.
+type GatewayStatus struct {
+	Name          string    `json:"name"`
+	State         State     `json:"state"`
+	Checks        int       `json:"checks"`
+	ErrorRate     float64   `json:"error_rate"`
+	AvgLatencyMs  float64   `json:"avg_latency_ms"`
+	LastLatencyMs float64   `json:"last_latency_ms"`
+	LastError     string    `json:"last_error,omitempty"`
+	LastChecked   time.Time `json:"last_checked"`
+}
+
+// history keeps the most recent samples of one gateway in a fixed-size ring.
+type history struct {
+	samples []Sample
+	next    int
+	full    bool
+}
+
+func (h *history) add(s Sample) {
+	h.samples[h.next] = s
+	h.next = (h.next + 1) % len(h.samples)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// recent returns the stored samples, oldest first.
+func (h *history) recent() []Sample {
+	if !h.full {
+		return append([]Sample(nil), h.samples[:h.next]...)
+	}
+	return append(append([]Sample(nil), h.samples[h.next:]...), h.samples[:h.next]...)
+}
+
+// ------------------------- PROBER -------------------------
+
+// Prober checks every gateway on an interval in the background.
+type Prober struct {
+	Interval  time.Duration // how often to probe; <= 0 means defaultInterval
+	Timeout   time.Duration // how long a single probe may take; <= 0 means defaultTimeout
+	Window    int           // how many recent samples to keep per gateway; < 1 means defaultWindow
+	DownAfter int           // consecutive failures that mean "down"; < 1 means defaultDownAfter
+	Degraded  float64       // error rate (0..1] at or above which we say "degraded"; <= 0 means defaultDegraded
+	gateways  []PaymentGateway
+	mu        sync.RWMutex
+	histories map[string]*history
+}
+
+// The defaults used when a field is left at zero (or set to nonsense), so
+// a Prober{} literal behaves like one from NewProber.
+const (
+	defaultInterval  = time.Second
+	defaultTimeout   = 200 * time.Millisecond
+	defaultWindow    = 20
+	defaultDownAfter = 3
+	defaultDegraded  = 0.2
+)
+
+// NewProber creates a prober with sensible defaults. Health is reported
+// per gateway name, so every gateway must have a different one: two
+// "stripe" accounts sharing one history would hide an outage of either.
+func NewProber(gateways ...PaymentGateway) (*Prober, error) {
+	seen := make(map[string]bool, len(gateways))
+	for _, g := range gateways {
+		if seen[g.Name()] {
+			return nil, fmt.Errorf("prober: duplicate gateway name %q", g.Name())
+		}
+		seen[g.Name()] = true
+	}
+	return &Prober{
+		Interval:  defaultInterval,
+		Timeout:   defaultTimeout,
+		Window:    defaultWindow,
+		DownAfter: defaultDownAfter,
+		Degraded:  defaultDegraded,
+		gateways:  gateways,
+		histories: make(map[string]*history),
+	}, nil
+}
+
+// Run probes until ctx is cancelled. Start it with `go prober.Run(ctx)`.
+func (p *Prober) Run(ctx context.Context) {
+	interval := p.Interval
+	if interval <= 0 {
+		interval = defaultInterval // time.NewTicker panics on 0
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		p.ProbeAll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProbeAll checks every gateway once, in parallel, and records the results.
+func (p *Prober) ProbeAll(ctx context.Context) {
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout // a zero timeout would fail every probe
+	}
+	var wg sync.WaitGroup
+	for _, g := range p.gateways {
+		checker, ok := g.(HealthChecker)
+		if !ok {
+			continue // nothing we can probe
+		}
+		wg.Add(1)
+		go func(name string, checker HealthChecker) {
+			defer wg.Done()
+			probeCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			start := time.Now()
+			err := checker.HealthCheck(probeCtx)
+			p.record(name, Sample{At: start, Latency: time.Since(start), Err: err})
+		}(g.Name(), checker)
+	}
+	wg.Wait()
+}
+
+func (p *Prober) record(name string, s Sample) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	h, ok := p.histories[name]
+	if !ok {
+		window := p.Window
+		if window < 1 {
+			window = defaultWindow // a ring of 0 samples cannot hold the latest one
+		}
+		h = &history{samples: make([]Sample, window)}
+		p.histories[name] = h
+	}
+	h.add(s)
+}
+
+// Status summarises the recent samples of one gateway.
+func (p *Prober) Status(name string) GatewayStatus {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	st := GatewayStatus{Name: name, State: StateUnknown}
+	h, ok := p.histories[name]
+	if !ok {
+		return st
+	}
+	samples := h.recent()
+	if len(samples) == 0 {
+		return st
+	}
+
+	var failures, trailingFailures int
+	var total time.Duration
+	for _, s := range samples {
+		total += s.Latency
+		if s.Err != nil {
+			failures++
+			trailingFailures++
+		} else {
+			trailingFailures = 0
+		}
+	}
+	last := samples[len(samples)-1]
+
+	st.Checks = len(samples)
+	st.ErrorRate = float64(failures) / float64(len(samples))
+	st.AvgLatencyMs = float64(total.Microseconds()) / 1000 / float64(len(samples))
+	st.LastLatencyMs = float64(last.Latency.Microseconds()) / 1000
+	st.LastChecked = last.At
+	if last.Err != nil {
+		st.LastError = last.Err.Error()
+	}
+
+	downAfter, degraded := p.DownAfter, p.Degraded
+	if downAfter < 1 {
+		downAfter = defaultDownAfter // 0 would call a single success "down"
+	}
+	if degraded <= 0 {
+		degraded = defaultDegraded
+	}
+	switch {
+	case trailingFailures >= downAfter:
+		st.State = StateDown
+	case st.ErrorRate >= degraded || trailingFailures > 0:
+		st.State = StateDegraded
+	default:
+		st.State = StateHealthy
+	}
+	return st
+}
+
+// Snapshot returns the status of every gateway, sorted by name.
+func (p *Prober) Snapshot() []GatewayStatus {
+	out := make([]GatewayStatus, 0, len(p.gateways))
+	for _, g := range p.gateways {
+		out = append(out, p.Status(g.Name()))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// ServeHTTP makes the prober itself an http.Handler (yet another interface!)
+// that reports the snapshot as JSON.
+func (p *Prober) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"generated_at": time.Now().UTC(),
+		"gateways":     p.Snapshot(),
+	})
+}
+
+// ------------------------- HEALTH-AWARE ROUTING -------------------------
+
+// StatusSource is all the router needs from the prober. Depending on this
+// small interface (not on *Prober) keeps the router easy to test.
+type StatusSource interface {
+	Status(name string) GatewayStatus
+}
+
+// Router is itself a PaymentGateway: it forwards Pay to the best gateway.
+// Preference order: healthy, then unknown, then degraded. "down" gateways
+// are skipped. Within one state, the order of Gateways is kept.
+type Router struct {
+	Gateways []PaymentGateway
+	Health   StatusSource
+}
+
+func (r Router) Name() string { return "router" }
+
+// rank orders states from most to least preferred; -1 means "never use".
+func rank(s State) int {
+	switch s {
+	case StateHealthy:
+		return 0
+	case StateUnknown:
+		return 1
+	case StateDegraded:
+		return 2
+	}
+	return -1
+}
+
+// candidates returns the gateways to try, best first.
+func (r Router) candidates() []PaymentGateway {
+	type scored struct {
+		g    PaymentGateway
+		rank int
+	}
+	var list []scored
+	for _, g := range r.Gateways {
+		if rk := rank(r.Health.Status(g.Name()).State); rk >= 0 {
+			list = append(list, scored{g, rk})
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].rank < list[j].rank })
+	out := make([]PaymentGateway, len(list))
+	for i, s := range list {
+		out[i] = s.g
+	}
+	return out
+}
+
+// Pay tries candidates in order and fails over on errors.
+func (r Router) Pay(amount float32) error {
+	var errs []error
+	for _, g := range r.candidates() {
+		err := g.Pay(amount)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return errors.New("router: no available gateway")
+	}
+	return errors.Join(errs...)
+}
+
+// Payment is unchanged from with_interface: Router is just another gateway.
+type Payment struct {
+	Gateway PaymentGateway
+}
+
+func (p Payment) MakePayment(amount float32) error {
+	return p.Gateway.Pay(amount)
+}
+
+// ----------------------------- MAIN -----------------------------------
+
+// printEndpoint calls the JSON endpoint in-process and prints its response.
+func printEndpoint(handler http.Handler) {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health/gateways", nil))
+	var body struct {
+		Gateways []GatewayStatus `json:"gateways"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	for _, g := range body.Gateways {
+		fmt.Printf("  %-9s %-9s checks=%-2d error_rate=%.2f last_error=%q\n",
+			g.Name, g.State, g.Checks, g.ErrorRate, g.LastError)
+	}
+}
+
+func main() {
+	addr := flag.String("addr", "", "serve the health endpoint on this address (e.g. :8080) after the demo")
+	flag.Parse()
+
+	razorpay := &Razorpay{}
+	gateways := []PaymentGateway{razorpay, Stripe{}, Paypal{}}
+
+	prober, err := NewProber(gateways...)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	prober.Interval = 50 * time.Millisecond
+	prober.Timeout = 30 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go prober.Run(ctx)
+
+	mux := http.NewServeMux()
+	mux.Handle("/health/gateways", prober)
+
+	// Router prefers healthy gateways, in the order given (Razorpay first).
+	payment := Payment{Gateway: Router{Gateways: gateways, Health: prober}}
+
+	// 1. Everything is up.
+	time.Sleep(200 * time.Millisecond)
+	fmt.Println("All gateways up:")
+	printEndpoint(mux)
+	payment.MakePayment(1000) // Output: Making payment using Razorpay: 1000
+	fmt.Println()
+
+	// 2. Razorpay goes down: probes time out, status becomes degraded, then down,
+	//    and the router moves payments to Stripe.
+	razorpay.SetOutage(true)
+	time.Sleep(300 * time.Millisecond)
+	fmt.Println("Razorpay outage:")
+	printEndpoint(mux)
+	payment.MakePayment(2000) // Output: Making payment using Stripe: 2000
+	fmt.Println()
+
+	// 3. Health is tracked per name, so two gateways may not share one.
+	_, err = NewProber(Stripe{}, Stripe{})
+	fmt.Println("Duplicate names:", err)
+
+	// 4. Zero settings fall back to the defaults instead of calling every
+	//    gateway down (DownAfter 0) or panicking in time.NewTicker (Interval 0).
+	zeroed, _ := NewProber(Stripe{})
+	zeroed.Interval, zeroed.Timeout, zeroed.DownAfter, zeroed.Degraded = 0, 0, 0, 0
+	zeroed.ProbeAll(ctx)
+	fmt.Println("Zero settings, stripe:", zeroed.Status("stripe").State)
+
+	if *addr != "" {
+		fmt.Println()
+		fmt.Println("Serving http://" + *addr + "/health/gateways")
+		log.Fatal(http.ListenAndServe(*addr, mux))
+	}
+}
+
+//
+// ---------------------------- SUMMARY ---------------------------------
+// 1. HealthChecker is optional; we detect it with g.(HealthChecker).
+// 2. The Prober runs in a goroutine, keeps a ring of recent samples per
+//    gateway and turns them into healthy / degraded / down / unknown.
+// 3. Prober implements http.Handler, so the JSON endpoint is one line.
+// 4. Router depends on the small StatusSource interface and is itself a
+//    PaymentGateway, so Payment does not know routing exists.
+// -----------------------------------------------------------------------
+//
@@ -0,0 +1,545 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//
+// -------------------- OUT-OF-PROCESS GATEWAY PLUGINS --------------------
+// without_interface showed the Open/Closed problem: adding a provider meant
+// editing and recompiling the program. with_interface fixed half of it:
+// new providers no longer change Payment, but they still have to be
+// compiled INTO the program.
+//
+// Here a provider can be a completely separate executable (a plugin).
+// The host starts it, talks JSON-RPC 2.0 to it over stdin/stdout, and wraps
+// it in PluginGateway — an ordinary PaymentGateway. Payment cannot tell the
+// difference between Stripe (in-process) and Paypal (a child process).
+//
+// Protocol (version 1), one JSON object per line:
+//   initialize {protocol_versions:[1]} → {name, protocol_version, capabilities}
+//   pay        {amount}                → {receipt_id}
+//   refund     {receipt_id}            → {refunded}
+//   shutdown   {}                      → {}
+//
+// Run from this directory:
+//   go run main.go
+//   go run main.go -plugin "/path/to/any/plugin/binary"
+//
+// Without -plugin the host first builds ../paypal_plugin with `go build`
+// and runs the binary. It must not start it with `go run`: killing a hung
+// plugin would then kill only the go tool, and the real plugin would keep
+// running (and keep charging) as an orphan.
+// -------------------------------------------------------------------------
+//
+
+// PaymentGateway is the interface every provider satisfies, in or out of process.
+type PaymentGateway interface {
+	Name() string
+	Pay(amount float32) (Receipt, error)
+}
+
+// Refunder is an optional capability.
+type Refunder interface {
+	Refund(receipt Receipt) error
+}
+
+// Receipt identifies one successful charge.
+type Receipt struct {
+	ID     string
+	Amount float32
+}
+
+// Stripe is an ordinary in-process gateway, for comparison.
+type Stripe struct{}
+
+func (Stripe) Name() string { return "stripe" }
+
+func (Stripe) Pay(amount float32) (Receipt, error) {
+	fmt.Println("Making payment using Stripe:", amount)
+	return Receipt{ID: "stripe_1", Amount: amount}, nil
+}
+
+// Payment is unchanged from with_interface.
+type Payment struct {
+	Gateway PaymentGateway
+}
+
+func (p Payment) MakePayment(amount float32) (Receipt, error) {
+	return p.Gateway.Pay(amount)
+}
+
+// ------------------------- JSON-RPC MESSAGES -------------------------
+
+// ProtocolVersions are the protocol versions this host understands.
+var ProtocolVersions = []int{1}
+
+type rpcRequest struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      int64  `json:"id"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+type rpcResponse struct {
+	ID     int64           `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *RPCError       `json:"error"`
+}
+
+// RPCError is an error reported BY the plugin (e.g. a declined payment).
+// The plugin itself is fine; only this call failed.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("plugin error %d: %s", e.Code, e.Message)
+}
+
+// Errors about the plugin PROCESS rather than a single call.
+var (
+	ErrPluginCrashed   = errors.New("plugin: process exited during the call")
+	ErrTooManyRestarts = errors.New("plugin: restart limit reached")
+	ErrCallTimeout     = errors.New("plugin: call timed out")
+	ErrUnsupported     = errors.New("plugin: capability not supported")
+	ErrIncompatible    = errors.New("plugin: incompatible protocol")
+)
+
+// ------------------------- ONE PLUGIN PROCESS -------------------------
+
+// process is one running copy of the plugin. When it dies we throw it away
+// and start a new one; we never try to reuse a dead process.
+type process struct {
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	writeMu sync.Mutex // one writer at a time, so lines never interleave
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]chan rpcResponse // calls waiting for an answer
+
+	done    chan struct{} // closed when the process has exited
+	dead    atomic.Bool   // set at once by kill, before the OS reaps the process
+	started time.Time
+	ended   time.Time // guarded by mu; when it exited or was killed
+}
+
+// startProcess launches the plugin and starts reading its stdout.
+func startProcess(command []string) (*process, error) {
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stderr = os.Stderr // plugin logs go straight to our stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("plugin: start %q: %w", strings.Join(command, " "), err)
+	}
+
+	p := &process{
+		cmd:     cmd,
+		stdin:   stdin,
+		pending: make(map[int64]chan rpcResponse),
+		done:    make(chan struct{}),
+		started: time.Now(),
+	}
+	go p.readLoop(stdout)
+	return p, nil
+}
+
+// readLoop delivers every response line to the call waiting for its id.
+// When stdout closes (the plugin exited or crashed), it wakes everyone up.
+func (p *process) readLoop(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		var resp rpcResponse
+		if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+			fmt.Fprintln(os.Stderr, "host: ignoring malformed plugin output:", err)
+			continue
+		}
+		p.mu.Lock()
+		ch, ok := p.pending[resp.ID]
+		delete(p.pending, resp.ID)
+		p.mu.Unlock()
+		if ok {
+			ch <- resp // buffered, never blocks
+		}
+	}
+	p.cmd.Wait()
+	p.markEnded()
+	close(p.done)
+}
+
+// markEnded records when the process ended; the first call wins.
+func (p *process) markEnded() {
+	p.mu.Lock()
+	if p.ended.IsZero() {
+		p.ended = time.Now()
+	}
+	p.mu.Unlock()
+}
+
+// uptime is how long the process ran (so far, if it is still running).
+func (p *process) uptime() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.ended.IsZero() {
+		return time.Since(p.started)
+	}
+	return p.ended.Sub(p.started)
+}
+
+// kill ends a process that stopped answering. It counts as dead at once,
+// so the next call starts a fresh copy instead of queueing behind a hung
+// one. Closing stdin as well lets a plugin that is merely slow exit on EOF.
+func (p *process) kill() {
+	p.dead.Store(true)
+	p.markEnded()
+	p.stdin.Close()
+	p.cmd.Process.Kill()
+}
+
+// alive reports whether the process is still running.
+func (p *process) alive() bool {
+	if p.dead.Load() {
+		return false
+	}
+	select {
+	case <-p.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// call sends one request and waits for its response, the timeout, or the
+// process exiting — whichever happens first. A process that times out is
+// killed: it may still be working on the request, and a later answer
+// could not be matched to anything.
+func (p *process) call(method string, params any, result any, timeout time.Duration) error {
+	p.mu.Lock()
+	p.nextID++
+	id := p.nextID
+	ch := make(chan rpcResponse, 1)
+	p.pending[id] = ch
+	p.mu.Unlock()
+
+	forget := func() {
+		p.mu.Lock()
+		delete(p.pending, id)
+		p.mu.Unlock()
+	}
+
+	line, err := json.Marshal(rpcRequest{JSONRPC: "2.0", ID: id, Method: method, Params: params})
+	if err != nil {
+		forget()
+		return err
+	}
+	p.writeMu.Lock()
+	_, err = p.stdin.Write(append(line, '\n'))
+	p.writeMu.Unlock()
+	if err != nil {
+		forget()
+		return ErrPluginCrashed
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result != nil {
+			return json.Unmarshal(resp.Result, result)
+		}
+		return nil
+	case <-p.done:
+		forget()
+		return ErrPluginCrashed
+	case <-timer.C:
+		forget()
+		p.kill()
+		return ErrCallTimeout
+	}
+}
+
+// stop asks the plugin to shut down politely, then kills it if needed.
+func (p *process) stop(timeout time.Duration) {
+	if !p.alive() {
+		return
+	}
+	p.call("shutdown", map[string]any{}, nil, timeout)
+	p.stdin.Close()
+	select {
+	case <-p.done:
+	case <-time.After(timeout):
+		p.cmd.Process.Kill()
+		<-p.done
+	}
+}
+
+// ------------------------- THE GATEWAY WRAPPER -------------------------
+
+// PluginInfo is what the plugin told us during the initialize handshake.
+type PluginInfo struct {
+	Name            string   `json:"name"`
+	ProtocolVersion int      `json:"protocol_version"`
+	Capabilities    []string `json:"capabilities"`
+}
+
+// PluginGateway runs an external plugin and makes it look like any other
+// PaymentGateway. It starts the process lazily, restarts it after a crash
+// or a timeout (up to MaxRestarts times, with a growing delay) and
+// negotiates the protocol version and capabilities every time it starts.
+// A process that stayed up for HealthyAfter gives back the whole restart
+// budget, so a plugin that crashes once a week is not retired after a month.
+type PluginGateway struct {
+	Command      []string
+	MaxRestarts  int
+	CallTimeout  time.Duration
+	Backoff      time.Duration
+	HealthyAfter time.Duration
+
+	mu     sync.Mutex
+	proc   *process
+	info   PluginInfo
+	starts int
+}
+
+// NewPluginGateway starts the plugin once, so that a broken plugin is
+// reported at startup instead of during the first payment.
+func NewPluginGateway(command []string) (*PluginGateway, error) {
+	g := &PluginGateway{
+		Command:      command,
+		MaxRestarts:  3,
+		CallTimeout:  30 * time.Second,
+		Backoff:      100 * time.Millisecond,
+		HealthyAfter: time.Minute,
+	}
+	if _, err := g.process(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// process returns a running, initialised plugin process, (re)starting it if needed.
+func (g *PluginGateway) process() (*process, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.proc != nil && g.proc.alive() {
+		return g.proc, nil
+	}
+	if g.proc != nil && g.proc.uptime() >= g.HealthyAfter {
+		g.starts = 1 // only the start of that healthy process counts
+	}
+	if g.starts > g.MaxRestarts {
+		return nil, ErrTooManyRestarts
+	}
+	if g.starts > 0 {
+		// Wait a little longer after every crash so a broken plugin
+		// does not make us spin.
+		time.Sleep(g.Backoff * time.Duration(g.starts))
+		fmt.Fprintf(os.Stderr, "host: restarting plugin (restart %d of %d)\n", g.starts, g.MaxRestarts)
+	}
+	g.starts++
+
+	proc, err := startProcess(g.Command)
+	if err != nil {
+		return nil, err
+	}
+	var info PluginInfo
+	err = proc.call("initialize", map[string]any{"protocol_versions": ProtocolVersions}, &info, g.CallTimeout)
+	if err == nil && !slices.Contains(ProtocolVersions, info.ProtocolVersion) {
+		err = fmt.Errorf("%w: plugin chose version %d", ErrIncompatible, info.ProtocolVersion)
+	}
+	if err == nil && !slices.Contains(info.Capabilities, "pay") {
+		err = fmt.Errorf("%w: plugin cannot pay", ErrIncompatible)
+	}
+	if err != nil {
+		proc.stop(time.Second)
+		return nil, fmt.Errorf("plugin handshake: %w", err)
+	}
+	g.proc, g.info = proc, info
+	return proc, nil
+}
+
+// Name returns the name the plugin announced during the handshake.
+func (g *PluginGateway) Name() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.info.Name
+}
+
+// Info returns the negotiated version and capabilities.
+func (g *PluginGateway) Info() PluginInfo {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.info
+}
+
+// Pay sends the payment to the plugin.
+//
+// NOTE: if the plugin crashes DURING a payment we return ErrPluginCrashed
+// and do NOT retry automatically: we cannot know whether the money moved,
+// and paying twice is worse than failing once. The next call restarts the plugin.
+func (g *PluginGateway) Pay(amount float32) (Receipt, error) {
+	proc, err := g.process()
+	if err != nil {
+		return Receipt{}, err
+	}
+	var out struct {
+		ReceiptID string `json:"receipt_id"`
+	}
+	if err := proc.call("pay", map[string]any{"amount": amount}, &out, g.CallTimeout); err != nil {
+		return Receipt{}, err
+	}
+	return Receipt{ID: out.ReceiptID, Amount: amount}, nil
+}
+
+// Refund is only available when the plugin announced the "refund" capability.
+func (g *PluginGateway) Refund(r Receipt) error {
+	proc, err := g.process()
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(g.Info().Capabilities, "refund") {
+		return ErrUnsupported
+	}
+	return proc.call("refund", map[string]any{"receipt_id": r.ID}, nil, g.CallTimeout)
+}
+
+// Close shuts the plugin down.
+func (g *PluginGateway) Close() {
+	g.mu.Lock()
+	proc := g.proc
+	g.proc = nil
+	g.mu.Unlock()
+	if proc != nil {
+		proc.stop(2 * time.Second)
+	}
+}
+
+// buildPlugin compiles the plugin in srcDir into outDir and returns the
+// path of the binary.
+func buildPlugin(srcDir, outDir string) (string, error) {
+	bin := filepath.Join(outDir, "paypal_plugin")
+	if runtime.GOOS == "windows" {
+		bin += ".exe"
+	}
+	cmd := exec.Command("go", "build", "-o", bin, "main.go")
+	cmd.Dir = srcDir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("go build %s: %w\n%s", srcDir, err, out)
+	}
+	return bin, nil
+}
+
+// ----------------------------- MAIN -----------------------------------
+func main() {
+	pluginCmd := flag.String("plugin", "", "command that starts the plugin (default: build ../paypal_plugin)")
+	flag.Parse()
+
+	if err := run(strings.Fields(*pluginCmd)); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+// run is main without os.Exit, so the deferred cleanup always happens.
+func run(command []string) error {
+	if len(command) == 0 {
+		dir, err := os.MkdirTemp("", "paypal_plugin")
+		if err != nil {
+			return fmt.Errorf("could not build plugin: %w", err)
+		}
+		defer os.RemoveAll(dir)
+		bin, err := buildPlugin("../paypal_plugin", dir)
+		if err != nil {
+			return fmt.Errorf("could not build plugin: %w", err)
+		}
+		command = []string{bin}
+	}
+
+	paypal, err := NewPluginGateway(command)
+	if err != nil {
+		return fmt.Errorf("could not load plugin: %w", err)
+	}
+	defer paypal.Close()
+
+	info := paypal.Info()
+	fmt.Printf("Loaded plugin %q (protocol v%d, capabilities %v)\n", info.Name, info.ProtocolVersion, info.Capabilities)
+	fmt.Println()
+
+	// Both gateways are used in exactly the same way.
+	for _, gateway := range []PaymentGateway{Stripe{}, paypal} {
+		payment := Payment{Gateway: gateway}
+		receipt, err := payment.MakePayment(1000)
+		fmt.Printf("%s → receipt=%q err=%v\n", gateway.Name(), receipt.ID, err)
+
+		// Optional capability, discovered at runtime.
+		if r, ok := gateway.(Refunder); ok {
+			fmt.Println("Refund error:", r.Refund(receipt))
+		}
+	}
+	fmt.Println()
+
+	payment := Payment{Gateway: paypal}
+
+	// A declined payment is an ordinary error returned by the plugin.
+	_, err = payment.MakePayment(9000)
+	var rpcErr *RPCError
+	fmt.Println("Declined:", err, "| plugin error?", errors.As(err, &rpcErr))
+
+	// Amount 13 makes the sample plugin crash. The call fails, but the host survives...
+	_, err = payment.MakePayment(13)
+	fmt.Println("Crash:", err)
+
+	// ...and the next call transparently restarts the plugin (new pid in the receipt).
+	receipt, err := payment.MakePayment(500)
+	fmt.Printf("After restart → receipt=%q err=%v\n", receipt.ID, err)
+
+	// Amount 42 makes the sample plugin hang. The call times out, the hung
+	// process is killed, and the next call gets a fresh one.
+	paypal.CallTimeout = time.Second
+	_, err = payment.MakePayment(42)
+	fmt.Println("Hang:", err)
+	receipt, err = payment.MakePayment(500)
+	fmt.Printf("After timeout → receipt=%q err=%v\n", receipt.ID, err)
+	return nil
+}
+
+//
+// ---------------------------- SUMMARY ---------------------------------
+// 1. A plugin is any executable that speaks line-delimited JSON-RPC 2.0
+//    on stdin/stdout; no recompilation of the host is needed.
+// 2. PluginGateway satisfies PaymentGateway (and Refunder), so the rest of
+//    the program cannot tell in-process and out-of-process gateways apart.
+// 3. initialize negotiates the protocol version and capabilities; a plugin
+//    without "pay" or without a common version is rejected at startup.
+// 4. Crashes and timeouts fail only the in-flight call (no blind retries
+//    of payments); a hung process is killed, and the next call restarts
+//    it, with backoff and a limit that resets after a healthy period.
+// -----------------------------------------------------------------------
+//
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+//
+// ------------------------- A GATEWAY PLUGIN -------------------------
+// This program is a payment gateway that lives OUTSIDE the host program.
+// The host starts it as a child process and talks to it over stdin/stdout:
+//
+//   host  --(one JSON request per line on stdin)-->   plugin
+//   host  <--(one JSON response per line on stdout)--  plugin
+//
+// The messages follow JSON-RPC 2.0 (id, method, params / result, error).
+// Anything meant for humans must go to stderr, because stdout belongs to
+// the protocol.
+//
+// Run it through the host (see ../host), or by hand:
+//   echo '{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocol_versions":[1]}}' | go run main.go
+// ---------------------------------------------------------------------
+//
+
+// protocolVersions lists the protocol versions this plugin can speak.
+var protocolVersions = []int{1}
+
+// request and response are the JSON-RPC 2.0 envelopes.
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      int64           `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+type response struct {
+	JSONRPC string    `json:"jsonrpc"`
+	ID      int64     `json:"id"`
+	Result  any       `json:"result,omitempty"`
+	Error   *rpcError `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Standard JSON-RPC error codes, plus one of our own for declined payments.
+const (
+	codeInvalidParams  = -32602
+	codeMethodNotFound = -32601
+	codeDeclined       = 1001
+)
+
+// paypal is the actual gateway logic. Because the plugin is its own program,
+// it keeps its own state (here just a receipt counter).
+type paypal struct {
+	next int
+}
+
+func (p *paypal) initialize(params json.RawMessage) (any, *rpcError) {
+	var in struct {
+		ProtocolVersions []int `json:"protocol_versions"`
+	}
+	if err := json.Unmarshal(params, &in); err != nil {
+		return nil, &rpcError{codeInvalidParams, err.Error()}
+	}
+	// Pick the highest version both sides support.
+	chosen := 0
+	for _, theirs := range in.ProtocolVersions {
+		for _, ours := range protocolVersions {
+			if theirs == ours && ours > chosen {
+				chosen = ours
+			}
+		}
+	}
+	if chosen == 0 {
+		return nil, &rpcError{codeInvalidParams, fmt.Sprintf("no common protocol version (plugin speaks %v)", protocolVersions)}
+	}
+	return map[string]any{
+		"name":             "paypal",
+		"protocol_version": chosen,
+		"capabilities":     []string{"pay", "refund"},
+	}, nil
+}
+
+func (p *paypal) pay(params json.RawMessage) (any, *rpcError) {
+	var in struct {
+		Amount float32 `json:"amount"`
+	}
+	if err := json.Unmarshal(params, &in); err != nil {
+		return nil, &rpcError{codeInvalidParams, err.Error()}
+	}
+	switch {
+	case in.Amount <= 0:
+		return nil, &rpcError{codeInvalidParams, "amount must be positive"}
+	case in.Amount == 13:
+		// A deliberate crash so the host's restart logic can be demonstrated.
+		fmt.Fprintln(os.Stderr, "paypal plugin: simulating a crash")
+		os.Exit(2)
+	case in.Amount == 42:
+		// A deliberate hang so the host's call timeout can be demonstrated.
+		fmt.Fprintln(os.Stderr, "paypal plugin: simulating a hang")
+		time.Sleep(time.Minute)
+	case in.Amount > 5000:
+		return nil, &rpcError{codeDeclined, fmt.Sprintf("amount %.2f exceeds paypal limit", in.Amount)}
+	}
+	p.next++
+	fmt.Fprintln(os.Stderr, "Making payment using Paypal plugin:", in.Amount)
+	return map[string]any{"receipt_id": fmt.Sprintf("pp_%d_%d", os.Getpid(), p.next)}, nil
+}
+
+func (p *paypal) refund(params json.RawMessage) (any, *rpcError) {
+	var in struct {
+		ReceiptID string `json:"receipt_id"`
+	}
+	if err := json.Unmarshal(params, &in); err != nil || in.ReceiptID == "" {
+		return nil, &rpcError{codeInvalidParams, "receipt_id is required"}
+	}
+	fmt.Fprintln(os.Stderr, "Refunding Paypal payment:", in.ReceiptID)
+	return map[string]any{"refunded": true}, nil
+}
+
+func main() {
+	gateway := &paypal{}
+	in := bufio.NewScanner(os.Stdin)
+	out := json.NewEncoder(os.Stdout) // Encode writes one JSON value per line
+
+	// The loop ends when the host closes our stdin (EOF) or asks us to shut down.
+	for in.Scan() {
+		var req request
+		if err := json.Unmarshal(in.Bytes(), &req); err != nil {
+			fmt.Fprintln(os.Stderr, "paypal plugin: bad request:", err)
+			continue
+		}
+
+		var result any
+		var rpcErr *rpcError
+		switch req.Method {
+		case "initialize":
+			result, rpcErr = gateway.initialize(req.Params)
+		case "pay":
+			result, rpcErr = gateway.pay(req.Params)
+		case "refund":
+			result, rpcErr = gateway.refund(req.Params)
+		case "shutdown":
+			out.Encode(response{JSONRPC: "2.0", ID: req.ID, Result: map[string]any{}})
+			return
+		default:
+			rpcErr = &rpcError{codeMethodNotFound, "unknown method " + req.Method}
+		}
+		out.Encode(response{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rpcErr})
+	}
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+//
+// ---------------------- SPLIT PAYMENTS (MULTIPLE TENDERS) ----------------------
+// In with_interface, a Payment holds ONE gateway and pays ONE amount.
+// Real checkouts are messier: a customer may pay part of the order with a
+// gift card, part with a wallet balance and the rest with a card.
+//
+// Every one of those "tenders" is still just a PaymentGateway, so the
+// interface keeps doing its job. What we add on top is an orchestrator that:
+//   1. Divides the order total across several gateways.
+//   2. Pays them one after another (in sequence).
+//   3. If any leg fails, undoes the legs that already succeeded
+//      (void if the gateway supports it, otherwise refund).
+//   4. Records the final per-tender breakdown on the order.
+// --------------------------------------------------------------------------------
+//
+
+// PaymentGateway is the same idea as in with_interface, but Pay now reports
+// what happened: a receipt for a successful charge or an error.
+type PaymentGateway interface {
+	Pay(amount float32) (Receipt, error)
+}
+
+// Voider is an OPTIONAL capability. A gateway that can cancel an
+// authorisation before it settles implements Void.
+// Small interfaces like this are checked with a type assertion at runtime.
+type Voider interface {
+	Void(receipt Receipt) error
+}
+
+// Refunder is another optional capability: give money back after a charge.
+type Refunder interface {
+	Refund(receipt Receipt) error
+}
+
+// Receipt identifies one successful charge on one gateway.
+type Receipt struct {
+	ID     string
+	Amount float32
+}
+
+// ------------------------- CONCRETE GATEWAYS -------------------------
+
+// Stripe charges cards. It supports both Void and Refund.
+type Stripe struct {
+	next int
+}
+
+func (s *Stripe) Pay(amount float32) (Receipt, error) {
+	s.next++
+	fmt.Println("Making payment using Stripe:", amount)
+	return Receipt{ID: fmt.Sprintf("stripe_%d", s.next), Amount: amount}, nil
+}
+
+func (s *Stripe) Void(r Receipt) error {
+	fmt.Println("Voiding Stripe charge:", r.ID)
+	return nil
+}
+
+func (s *Stripe) Refund(r Receipt) error {
+	fmt.Println("Refunding Stripe charge:", r.ID)
+	return nil
+}
+
+// Razorpay charges cards/UPI. It can only Refund (no Void).
+// FailAbove lets us simulate a decline for large amounts.
+type Razorpay struct {
+	FailAbove float32
+	next      int
+}
+
+func (r *Razorpay) Pay(amount float32) (Receipt, error) {
+	if r.FailAbove > 0 && amount > r.FailAbove {
+		return Receipt{}, fmt.Errorf("razorpay: amount %.2f declined", amount)
+	}
+	r.next++
+	fmt.Println("Making payment using Razorpay:", amount)
+	return Receipt{ID: fmt.Sprintf("rzp_%d", r.next), Amount: amount}, nil
+}
+
+func (r *Razorpay) Refund(rc Receipt) error {
+	fmt.Println("Refunding Razorpay payment:", rc.ID)
+	return nil
+}
+
+// GiftCard pays from a fixed balance. Voiding simply restores the balance.
+type GiftCard struct {
+	Code    string
+	Balance float32
+}
+
+func (g *GiftCard) Pay(amount float32) (Receipt, error) {
+	if amount > g.Balance {
+		return Receipt{}, fmt.Errorf("gift card %s: insufficient balance %.2f", g.Code, g.Balance)
+	}
+	g.Balance -= amount
+	fmt.Println("Making payment using Gift Card:", amount)
+	return Receipt{ID: "gc_" + g.Code, Amount: amount}, nil
+}
+
+func (g *GiftCard) Void(r Receipt) error {
+	g.Balance += r.Amount
+	fmt.Println("Restoring gift card", g.Code, "balance to:", g.Balance)
+	return nil
+}
+
+// ------------------------- TENDERS AND ORDERS -------------------------
+
+// Tender is one "leg" of a split payment: which gateway pays how much.
+// Amount == 0 means "whatever is left" (only one tender may do that).
+type Tender struct {
+	Name    string
+	Gateway PaymentGateway
+	Amount  float32
+}
+
+// TenderStatus describes what finally happened to one leg.
+type TenderStatus string
+
+const (
+	TenderPaid     TenderStatus = "paid"
+	TenderFailed   TenderStatus = "failed"
+	TenderVoided   TenderStatus = "voided"
+	TenderRefunded TenderStatus = "refunded"
+	TenderSkipped  TenderStatus = "skipped"
+	// TenderRollbackFailed means we could not undo the leg; a human must look at it.
+	TenderRollbackFailed TenderStatus = "rollback_failed"
+)
+
+// TenderResult is one line of the per-tender breakdown stored on the order.
+type TenderResult struct {
+	Name      string
+	Amount    float32
+	Status    TenderStatus
+	ReceiptID string
+	Err       error
+}
+
+// order mirrors the order struct from 16-structs, plus the tender breakdown.
+type order struct {
+	id      string
+	amount  float32
+	status  string
+	tenders []TenderResult
+}
+
+// ------------------------- THE ORCHESTRATOR -------------------------
+
+// ErrSplitMismatch is returned when the tenders do not add up to the order total.
+var ErrSplitMismatch = errors.New("split payment: tenders do not add up to order total")
+
+// SplitPayment pays one order total across several gateways.
+type SplitPayment struct {
+	Tenders []Tender
+}
+
+// toPaise converts a float amount to whole paise (1/100 of a rupee) so that
+// adding and subtracting the legs does not accumulate float rounding errors.
+func toPaise(amount float32) int64 {
+	return int64(math.Round(float64(amount) * 100))
+}
+
+func fromPaise(p int64) float32 {
+	return float32(p) / 100
+}
+
+// plan works out the exact amount for every leg. It fills the single
+// "remainder" tender (Amount == 0) and checks the total matches.
+func (s SplitPayment) plan(total float32) ([]int64, error) {
+	amounts := make([]int64, len(s.Tenders))
+	remainder := -1
+	left := toPaise(total)
+
+	for i, t := range s.Tenders {
+		if t.Amount < 0 {
+			return nil, fmt.Errorf("split payment: tender %q has negative amount", t.Name)
+		}
+		if t.Amount == 0 {
+			if remainder != -1 {
+				return nil, fmt.Errorf("split payment: tenders %q and %q both take the remainder",
+					s.Tenders[remainder].Name, t.Name)
+			}
+			remainder = i
+			continue
+		}
+		amounts[i] = toPaise(t.Amount)
+		left -= amounts[i]
+	}
+
+	switch {
+	case left < 0:
+		return nil, fmt.Errorf("%w: over by %.2f", ErrSplitMismatch, fromPaise(-left))
+	case left > 0 && remainder == -1:
+		return nil, fmt.Errorf("%w: short by %.2f", ErrSplitMismatch, fromPaise(left))
+	case remainder != -1:
+		amounts[remainder] = left
+	}
+	return amounts, nil
+}
+
+// Pay charges every tender in order. On the first failure it rolls back the
+// legs that already succeeded, newest first, and returns the error.
+// Either way, the per-tender breakdown is written to o.tenders.
+func (s SplitPayment) Pay(o *order) error {
+	amounts, err := s.plan(o.amount)
+	if err != nil {
+		return err
+	}
+
+	results := make([]TenderResult, len(s.Tenders))
+	receipts := make([]Receipt, len(s.Tenders))
+	for i, t := range s.Tenders {
+		results[i] = TenderResult{Name: t.Name, Amount: fromPaise(amounts[i]), Status: TenderSkipped}
+	}
+	defer func() { o.tenders = results }()
+
+	for i, t := range s.Tenders {
+		if amounts[i] == 0 {
+			continue // nothing left for this leg
+		}
+		receipt, err := t.Gateway.Pay(results[i].Amount)
+		if err != nil {
+			results[i].Status = TenderFailed
+			results[i].Err = err
+			s.rollback(results[:i], receipts[:i])
+			o.status = "Payment Failed"
+			return fmt.Errorf("split payment: tender %q: %w", t.Name, err)
+		}
+		receipts[i] = receipt
+		results[i].Status = TenderPaid
+		results[i].ReceiptID = receipt.ID
+	}
+
+	o.status = "Paid"
+	return nil
+}
+
+// rollback undoes the successful legs in reverse order (last paid, first undone).
+func (s SplitPayment) rollback(results []TenderResult, receipts []Receipt) {
+	for i := len(results) - 1; i >= 0; i-- {
+		if results[i].Status != TenderPaid {
+			continue
+		}
+		gateway := s.Tenders[i].Gateway
+
+		// Prefer Void (cheaper, nothing settles); fall back to Refund.
+		if v, ok := gateway.(Voider); ok {
+			err := v.Void(receipts[i])
+			if err == nil {
+				results[i].Status = TenderVoided
+				continue
+			}
+			results[i].Err = err
+		}
+		if r, ok := gateway.(Refunder); ok {
+			err := r.Refund(receipts[i])
+			if err == nil {
+				results[i].Status = TenderRefunded
+				results[i].Err = nil
+				continue
+			}
+			results[i].Err = err
+		}
+		if results[i].Err == nil {
+			results[i].Err = errors.New("gateway supports neither void nor refund")
+		}
+		results[i].Status = TenderRollbackFailed
+	}
+}
+
+// printBreakdown shows the per-tender breakdown stored on the order.
+func printBreakdown(o *order) {
+	fmt.Printf("Order %s (%.2f) -> %s\n", o.id, o.amount, o.status)
+	for _, t := range o.tenders {
+		line := fmt.Sprintf("  %-10s %8.2f  %-8s", t.Name, t.Amount, t.Status)
+		if t.ReceiptID != "" {
+			line += "  " + t.ReceiptID
+		}
+		if t.Err != nil {
+			line += "  (" + t.Err.Error() + ")"
+		}
+		fmt.Println(line)
+	}
+}
+
+// ----------------------------- MAIN -----------------------------------
+func main() {
+	// 1. Successful split: gift card + wallet-like Razorpay + card for the rest.
+	giftCard := &GiftCard{Code: "BDAY500", Balance: 500}
+	order1 := &order{id: "1", amount: 1500.75, status: "Recieved"}
+
+	split := SplitPayment{Tenders: []Tender{
+		{Name: "giftcard", Gateway: giftCard, Amount: 500},
+		{Name: "razorpay", Gateway: &Razorpay{}, Amount: 400.25},
+		{Name: "card", Gateway: &Stripe{}}, // Amount 0 → pays the remainder (600.50)
+	}}
+	if err := split.Pay(order1); err != nil {
+		fmt.Println("Error:", err)
+	}
+	printBreakdown(order1)
+	fmt.Println()
+
+	// 2. Failing split: the last leg is declined, so earlier legs are rolled back.
+	//    Gift card gets voided (balance restored), Stripe gets voided too.
+	giftCard2 := &GiftCard{Code: "WELCOME", Balance: 300}
+	order2 := &order{id: "2", amount: 2000, status: "Recieved"}
+
+	split2 := SplitPayment{Tenders: []Tender{
+		{Name: "giftcard", Gateway: giftCard2, Amount: 300},
+		{Name: "card", Gateway: &Stripe{}, Amount: 200},
+		{Name: "razorpay", Gateway: &Razorpay{FailAbove: 1000}}, // remainder 1500 → declined
+	}}
+	if err := split2.Pay(order2); err != nil {
+		fmt.Println("Error:", err)
+	}
+	printBreakdown(order2)
+	fmt.Println("Gift card balance after rollback:", giftCard2.Balance) // Output: 300
+	fmt.Println()
+
+	// 3. Tenders that do not add up are rejected before any money moves.
+	order3 := &order{id: "3", amount: 100, status: "Recieved"}
+	split3 := SplitPayment{Tenders: []Tender{
+		{Name: "card", Gateway: &Stripe{}, Amount: 60},
+		{Name: "razorpay", Gateway: &Razorpay{}, Amount: 60},
+	}}
+	if err := split3.Pay(order3); err != nil {
+		fmt.Println("Error:", err) // Output: ... over by 20.00
+	}
+}
+
+//
+// ---------------------------- SUMMARY ---------------------------------
+// 1. Each tender is just a PaymentGateway, so new tender types need no
+//    changes to the orchestrator (Open/Closed Principle again).
+// 2. Optional capabilities (Voider, Refunder) are small interfaces that we
+//    discover with type assertions: gateway.(Voider).
+// 3. Amounts are split in whole paise so the legs always add up exactly.
+// 4. On failure, successful legs are undone newest-first; anything we
+//    cannot undo is marked rollback_failed on the order for follow-up.
+// -----------------------------------------------------------------------
+//
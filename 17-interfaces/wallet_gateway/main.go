@@ -0,0 +1,460 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+//
+// ---------------------- STORED-VALUE WALLET GATEWAY ----------------------
+// Stripe and Razorpay are third-party gateways. A wallet is our OWN gateway:
+// customers top up a prepaid balance and later pay from it.
+//
+// Because a wallet satisfies PaymentGateway, the Payment struct from
+// with_interface can use it without any change — that is the whole point
+// of depending on an interface.
+//
+// Things a wallet must get right:
+//   - Balances must stay correct when many payments run at the same time
+//     (we protect every account with a sync.Mutex).
+//   - No overdrafts: a debit larger than the available balance is refused.
+//   - Holds: money can be reserved (e.g. while an order is being prepared)
+//     and later captured or released.
+//   - Every change is written to an append-only history (a ledger), and the
+//     balance can always be rebuilt from that history for auditing.
+// --------------------------------------------------------------------------
+//
+
+// PaymentGateway is the interface from with_interface, with a result added.
+type PaymentGateway interface {
+	Pay(amount float32) (Receipt, error)
+}
+
+// Receipt identifies one successful charge.
+type Receipt struct {
+	ID     string
+	Amount float32
+}
+
+// Payment is the struct from with_interface, now passing the Receipt and
+// error back to its caller. It still only knows the interface.
+type Payment struct {
+	Gateway PaymentGateway
+}
+
+func (p Payment) MakePayment(amount float32) (Receipt, error) {
+	return p.Gateway.Pay(amount)
+}
+
+// ------------------------- LEDGER ENTRIES -------------------------
+
+// EntryKind says what kind of change a ledger entry records.
+type EntryKind string
+
+const (
+	EntryTopUp   EntryKind = "topup"   // money in
+	EntryDebit   EntryKind = "debit"   // money out
+	EntryHold    EntryKind = "hold"    // money reserved (not spendable)
+	EntryRelease EntryKind = "release" // reservation cancelled
+	EntryCapture EntryKind = "capture" // reservation turned into a debit
+)
+
+// Entry is one line of a customer's transaction history.
+// Amounts are stored in paise (int64) so arithmetic is exact.
+type Entry struct {
+	Seq    int
+	Kind   EntryKind
+	Amount int64
+	HoldID string
+	Note   string
+	At     time.Time
+}
+
+// Errors returned by the wallet. Callers can check them with errors.Is.
+var (
+	ErrInsufficientFunds = errors.New("wallet: insufficient funds")
+	ErrInvalidAmount     = errors.New("wallet: amount must be positive")
+	ErrUnknownHold       = errors.New("wallet: unknown or already closed hold")
+	ErrUnknownAccount    = errors.New("wallet: unknown customer")
+)
+
+// ------------------------- ACCOUNT -------------------------
+
+// account holds one customer's state. The mutex guards everything below it.
+type account struct {
+	mu      sync.Mutex
+	balance int64            // total money in the wallet
+	held    int64            // part of balance reserved by open holds
+	holds   map[string]int64 // open holds: id → amount
+	history []Entry
+}
+
+// available is what the customer can still spend right now.
+func (a *account) available() int64 {
+	return a.balance - a.held
+}
+
+// record appends an entry to the history. Caller must hold a.mu.
+func (a *account) record(kind EntryKind, amount int64, holdID, note string, now time.Time) Entry {
+	e := Entry{Seq: len(a.history) + 1, Kind: kind, Amount: amount, HoldID: holdID, Note: note, At: now}
+	a.history = append(a.history, e)
+	return e
+}
+
+// ------------------------- WALLET -------------------------
+
+// Wallet manages the accounts of all customers.
+// The outer mutex only guards the accounts map; each account has its own
+// lock, so payments for different customers never wait for each other.
+type Wallet struct {
+	mu       sync.Mutex
+	accounts map[string]*account
+	nextHold int
+	now      func() time.Time
+}
+
+// NewWallet creates an empty wallet.
+func NewWallet() *Wallet {
+	return &Wallet{accounts: make(map[string]*account), now: time.Now}
+}
+
+// open returns the account for a customer, creating it if needed. Only
+// TopUp calls it: a wallet exists once money has been put into it.
+func (w *Wallet) open(customerID string) *account {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	a, ok := w.accounts[customerID]
+	if !ok {
+		a = &account{holds: make(map[string]int64)}
+		w.accounts[customerID] = a
+	}
+	return a
+}
+
+// account returns an existing account. Looking a customer up never creates
+// one, so a typo in Balance("jhno") cannot add an empty wallet.
+func (w *Wallet) account(customerID string) (*account, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	a, ok := w.accounts[customerID]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownAccount, customerID)
+	}
+	return a, nil
+}
+
+func (w *Wallet) newHoldID() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.nextHold++
+	return fmt.Sprintf("hold_%d", w.nextHold)
+}
+
+// toPaise converts a rupee amount into whole paise.
+func toPaise(amount float32) int64 {
+	return int64(math.Round(float64(amount) * 100))
+}
+
+func fromPaise(p int64) float32 {
+	return float32(p) / 100
+}
+
+// TopUp adds money to a customer's wallet, opening it on the first top-up.
+func (w *Wallet) TopUp(customerID string, amount float32) error {
+	p := toPaise(amount)
+	if p <= 0 {
+		return ErrInvalidAmount
+	}
+	a := w.open(customerID)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.balance += p
+	a.record(EntryTopUp, p, "", "", w.now())
+	return nil
+}
+
+// Debit takes money out of the wallet. It never lets the available balance
+// go below zero (overdraft protection).
+func (w *Wallet) Debit(customerID string, amount float32, note string) (Entry, error) {
+	p := toPaise(amount)
+	if p <= 0 {
+		return Entry{}, ErrInvalidAmount
+	}
+	a, err := w.account(customerID)
+	if err != nil {
+		return Entry{}, err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if p > a.available() {
+		return Entry{}, fmt.Errorf("%w: need %.2f, available %.2f",
+			ErrInsufficientFunds, amount, fromPaise(a.available()))
+	}
+	a.balance -= p
+	return a.record(EntryDebit, p, "", note, w.now()), nil
+}
+
+// Hold reserves money without spending it yet. The returned id is used to
+// Capture or Release the hold later.
+func (w *Wallet) Hold(customerID string, amount float32) (string, error) {
+	p := toPaise(amount)
+	if p <= 0 {
+		return "", ErrInvalidAmount
+	}
+	a, err := w.account(customerID)
+	if err != nil {
+		return "", err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if p > a.available() {
+		return "", fmt.Errorf("%w: cannot hold %.2f, available %.2f",
+			ErrInsufficientFunds, amount, fromPaise(a.available()))
+	}
+	id := w.newHoldID() // only now, so refused holds do not use up ids
+	a.held += p
+	a.holds[id] = p
+	a.record(EntryHold, p, id, "", w.now())
+	return id, nil
+}
+
+// Release cancels a hold; the money becomes spendable again.
+func (w *Wallet) Release(customerID, holdID string) error {
+	a, err := w.account(customerID)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	p, ok := a.holds[holdID]
+	if !ok {
+		return ErrUnknownHold
+	}
+	delete(a.holds, holdID)
+	a.held -= p
+	a.record(EntryRelease, p, holdID, "", w.now())
+	return nil
+}
+
+// Capture turns a hold into a real debit.
+func (w *Wallet) Capture(customerID, holdID string) error {
+	a, err := w.account(customerID)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	p, ok := a.holds[holdID]
+	if !ok {
+		return ErrUnknownHold
+	}
+	delete(a.holds, holdID)
+	a.held -= p
+	a.balance -= p
+	a.record(EntryCapture, p, holdID, "", w.now())
+	return nil
+}
+
+// Balance returns the total balance and the part of it that is held.
+func (w *Wallet) Balance(customerID string) (balance, held float32, err error) {
+	a, err := w.account(customerID)
+	if err != nil {
+		return 0, 0, err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return fromPaise(a.balance), fromPaise(a.held), nil
+}
+
+// History returns a copy of the customer's transaction history.
+// We copy so that callers cannot modify the ledger behind our back.
+func (w *Wallet) History(customerID string) ([]Entry, error) {
+	a, err := w.account(customerID)
+	if err != nil {
+		return nil, err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]Entry(nil), a.history...), nil
+}
+
+// Replay rebuilds balance and held amount purely from a history.
+// This is what an auditor would do: trust the ledger, not the cached numbers.
+func Replay(history []Entry) (balance, held int64) {
+	for _, e := range history {
+		switch e.Kind {
+		case EntryTopUp:
+			balance += e.Amount
+		case EntryDebit:
+			balance -= e.Amount
+		case EntryHold:
+			held += e.Amount
+		case EntryRelease:
+			held -= e.Amount
+		case EntryCapture:
+			held -= e.Amount
+			balance -= e.Amount
+		}
+	}
+	return balance, held
+}
+
+// Audit checks that the cached balance of every customer matches the
+// balance derived from their history. It returns one error per mismatch.
+func (w *Wallet) Audit() []error {
+	w.mu.Lock()
+	accounts := make(map[string]*account, len(w.accounts))
+	for id, a := range w.accounts {
+		accounts[id] = a
+	}
+	w.mu.Unlock()
+
+	var problems []error
+	for id, a := range accounts {
+		a.mu.Lock()
+		balance, held := Replay(a.history)
+		if balance != a.balance || held != a.held {
+			problems = append(problems, fmt.Errorf("customer %s: ledger says %d/%d, account says %d/%d",
+				id, balance, held, a.balance, a.held))
+		}
+		a.mu.Unlock()
+	}
+	return problems
+}
+
+// ------------------------- GATEWAY ADAPTER -------------------------
+
+// CustomerWallet is the PaymentGateway for one customer's wallet.
+// Pay(amount) has no customer parameter, so we bind the customer here.
+type CustomerWallet struct {
+	wallet     *Wallet
+	customerID string
+}
+
+// For returns a PaymentGateway that pays from the given customer's balance.
+func (w *Wallet) For(customerID string) CustomerWallet {
+	return CustomerWallet{wallet: w, customerID: customerID}
+}
+
+// Pay debits the wallet. CustomerWallet now satisfies PaymentGateway.
+func (c CustomerWallet) Pay(amount float32) (Receipt, error) {
+	e, err := c.wallet.Debit(c.customerID, amount, "payment")
+	if err != nil {
+		return Receipt{}, err
+	}
+	fmt.Println("Making payment using Wallet:", amount)
+	return Receipt{ID: fmt.Sprintf("wallet_%s_%d", c.customerID, e.Seq), Amount: amount}, nil
+}
+
+// ----------------------------- MAIN -----------------------------------
+func main() {
+	wallet := NewWallet()
+
+	// 1. Top up and pay through the ordinary Payment struct.
+	if err := wallet.TopUp("jhon", 1000); err != nil {
+		fmt.Println("Top-up failed:", err)
+		return
+	}
+	walletPayment := Payment{Gateway: wallet.For("jhon")} // Inject the wallet
+	receipt, err := walletPayment.MakePayment(250.50)
+	fmt.Println("Receipt:", receipt.ID, "Error:", err)
+
+	// 2. Overdraft protection: paying more than the balance is refused.
+	_, err = walletPayment.MakePayment(5000)
+	fmt.Println("Error:", err)
+	fmt.Println("Is insufficient funds?", errors.Is(err, ErrInsufficientFunds)) // Output: true
+	fmt.Println()
+
+	// 3. Holds: reserve 500 while the order is prepared, then capture it.
+	//    A refused hold uses up no id, so the one that succeeds is hold_1.
+	_, err = wallet.Hold("jhon", 5000)
+	fmt.Println("Hold 5000:", err)
+	holdID, err := wallet.Hold("jhon", 500)
+	if err != nil {
+		fmt.Println("Hold failed:", err)
+		return
+	}
+	balance, held, _ := wallet.Balance("jhon")
+	fmt.Println("After hold    → balance:", balance, "held:", held) // 749.5, 500
+
+	// Only 249.50 is spendable now, so this payment fails.
+	_, err = walletPayment.MakePayment(300)
+	fmt.Println("Error:", err)
+
+	if err := wallet.Capture("jhon", holdID); err != nil {
+		fmt.Println("Capture failed:", err)
+		return
+	}
+	balance, held, _ = wallet.Balance("jhon")
+	fmt.Println("After capture → balance:", balance, "held:", held) // 249.5, 0
+
+	// Capturing the same hold twice is refused.
+	err = wallet.Capture("jhon", holdID)
+	fmt.Println("Capture again:", err)
+	fmt.Println()
+
+	// 4. Unknown customers: looking up a wallet never opens one, so a typo
+	//    is an error instead of a new empty account.
+	_, _, err = wallet.Balance("jhno")
+	fmt.Println("Balance(jhno):", err, "| unknown account?", errors.Is(err, ErrUnknownAccount)) // true
+	_, err = wallet.Hold("jhno", 10)
+	fmt.Println("Hold(jhno):   ", err)
+	_, err = Payment{Gateway: wallet.For("jhno")}.MakePayment(10)
+	fmt.Println("Pay(jhno):    ", err)
+	fmt.Println()
+
+	// 5. Concurrency: 100 goroutines each try to pay 10 from a balance of 500.
+	//    Exactly 50 must succeed and the balance must end at exactly 0.
+	if err := wallet.TopUp("priya", 500); err != nil {
+		fmt.Println("Top-up failed:", err)
+		return
+	}
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := wallet.Debit("priya", 10, "concurrent"); err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	balance, _, _ = wallet.Balance("priya")
+	fmt.Println("Concurrent payments succeeded:", succeeded, "final balance:", balance) // 50, 0
+	fmt.Println()
+
+	// 6. History and audit: the balance can always be derived from the ledger.
+	history, err := wallet.History("jhon")
+	if err != nil {
+		fmt.Println("History failed:", err)
+		return
+	}
+	for _, e := range history {
+		fmt.Printf("  #%d %-8s %8.2f %s\n", e.Seq, e.Kind, fromPaise(e.Amount), e.HoldID)
+	}
+	derived, _ := Replay(history)
+	fmt.Println("Balance derived from history:", fromPaise(derived)) // 249.5
+	fmt.Println("Audit problems:", len(wallet.Audit()))              // 0
+}
+
+//
+// ---------------------------- SUMMARY ---------------------------------
+// 1. Wallet.For(customer) returns a value that satisfies PaymentGateway,
+//    so existing Payment code works with a wallet unchanged.
+// 2. Each account has its own mutex: concurrent payments cannot corrupt
+//    the balance, and different customers do not block each other.
+// 3. Available balance = balance - held; debits and holds never exceed it.
+// 4. Every change is appended to a history; Replay/Audit rebuild balances
+//    from that history so they can be verified independently.
+// 5. Only TopUp opens an account; every other call on an unknown customer
+//    returns ErrUnknownAccount instead of creating an empty wallet.
+// -----------------------------------------------------------------------
+//
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// ---------------------- ENUMS AS FLAGS AND CONFIG FIELDS ----------------------
+// Printing an enum nicely is only half the story. Values also come IN from
+// the outside world: command-line flags and configuration files.
+//
+// The code generated by ../enumgen gives OrderStatus three small interfaces:
+//
+//	flag.Value               → Set(string) + String()  → works with flag.Var
+//	encoding.TextUnmarshaler → UnmarshalText([]byte)   → works with any text decoder
+//	json.Unmarshaler         → UnmarshalJSON([]byte)   → works in config structs
+//
+// All of them parse case-insensitively ("confirmed" == "Confirmed") and
+// reject unknown values with the list of valid options. Numbers are still
+// accepted in JSON for old payloads, but only if they are real statuses:
+// {"status": 42} is an error, not a silent OrderStatus(42).
+//
+// Run it with (both files are needed):
+//
+//	go run main.go orderstatus_enum.go
+//	go run main.go orderstatus_enum.go --status=Confirmed
+//	go run main.go orderstatus_enum.go --status=shipped    # error + valid options
+//
+// -----------------------------------------------------------------------------
+
+//go:generate go run ../enumgen/main.go -type=OrderStatus
+
+// OrderStatus is the enum from 18-enums.
+type OrderStatus int
+
+const (
+	Recieved  OrderStatus = iota // enum:"Received"
+	Confirmed                    // 1
+	Prepared                     // 2
+	Delivered                    // 3
+)
+
+// ------------------------- CONFIGURATION LOADER -------------------------
+
+// Config is the shape of config.json. The enum fields need no special code:
+// encoding/json finds UnmarshalJSON on OrderStatus by itself.
+type Config struct {
+	Store    string        `json:"store"`
+	Status   OrderStatus   `json:"status"`
+	NotifyOn []OrderStatus `json:"notify_on"`
+}
+
+// decodeConfig parses configuration JSON strictly: unknown keys are errors
+// too, so a typo like "stauts" does not go unnoticed.
+func decodeConfig(data []byte) (Config, error) {
+	var cfg Config
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// loadConfig reads and decodes a configuration file.
+func loadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg, err := decodeConfig(data)
+	if err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+func main() {
+	// 1. Config file first, flags override it (the usual precedence).
+	configPath := flag.String("config", "config.json", "path to the configuration file")
+	status := Recieved
+	flag.Var(&status, "status", "order status: "+fmt.Sprint(status.Values()))
+	flag.Parse()
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Println("Config error:", err)
+		os.Exit(1)
+	}
+
+	// flag.Visit only visits flags that were actually given on the command line.
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "status" {
+			cfg.Status = status
+		}
+	})
+	fmt.Println("Store:", cfg.Store)
+	fmt.Println("Status:", cfg.Status)      // Prepared, or the --status value
+	fmt.Println("Notify on:", cfg.NotifyOn) // [Confirmed Delivered]
+	fmt.Println()
+
+	// 2. Bad flag values are rejected by the flag package itself, with our message.
+	fs := flag.NewFlagSet("demo", flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+	var fromFlag OrderStatus
+	fs.Var(&fromFlag, "status", "order status")
+	fmt.Println("Parsing --status=Cancelled:")
+	fs.Parse([]string{"--status=Cancelled"})
+	fmt.Println()
+
+	// 3. JSON config values: names in any case, valid numbers, and rejects.
+	for _, doc := range []string{
+		`{"status": "DELIVERED"}`,
+		`{"status": 1}`,
+		`{"status": 42}`,
+		`{"status": "shipped"}`,
+		`{"status": true}`,
+	} {
+		cfg, err := decodeConfig([]byte(doc))
+		if err != nil {
+			fmt.Printf("%-24s → error: %v\n", doc, err)
+			continue
+		}
+		fmt.Printf("%-24s → %s\n", doc, cfg.Status)
+	}
+}
@@ -0,0 +1,150 @@
+// Code generated by enumgen -type=OrderStatus; DO NOT EDIT.
+
+package main
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// _OrderStatusNames maps every valid OrderStatus to its display name.
+var _OrderStatusNames = map[OrderStatus]string{
+	Recieved:  "Received",
+	Confirmed: "Confirmed",
+	Prepared:  "Prepared",
+	Delivered: "Delivered",
+}
+
+// _OrderStatusValues lists the valid OrderStatus values in declaration order.
+var _OrderStatusValues = []OrderStatus{
+	Recieved,
+	Confirmed,
+	Prepared,
+	Delivered,
+}
+
+// String returns the display name, or OrderStatus(n) for an unknown value.
+func (e OrderStatus) String() string {
+	if name, ok := _OrderStatusNames[e]; ok {
+		return name
+	}
+	return "OrderStatus(" + strconv.FormatInt(int64(e), 10) + ")"
+}
+
+// IsValid reports whether e is one of the declared constants.
+func (e OrderStatus) IsValid() bool {
+	_, ok := _OrderStatusNames[e]
+	return ok
+}
+
+// Values returns every valid OrderStatus in declaration order.
+func (OrderStatus) Values() []OrderStatus {
+	return append([]OrderStatus(nil), _OrderStatusValues...)
+}
+
+// _OrderStatusLookup maps lower-cased display and Go names to values.
+var _OrderStatusLookup = map[string]OrderStatus{
+	"received":  Recieved,
+	"recieved":  Recieved,
+	"confirmed": Confirmed,
+	"prepared":  Prepared,
+	"delivered": Delivered,
+}
+
+// ParseOrderStatus converts a display name (or the Go constant name) back to
+// OrderStatus. Case and surrounding spaces are ignored.
+func ParseOrderStatus(s string) (OrderStatus, error) {
+	if v, ok := _OrderStatusLookup[strings.ToLower(strings.TrimSpace(s))]; ok {
+		return v, nil
+	}
+	return 0, fmt.Errorf("invalid OrderStatus %q (valid: %s)", s, "Received, Confirmed, Prepared, Delivered")
+}
+
+// Set implements flag.Value, so a OrderStatus can be used with flag.Var.
+func (e *OrderStatus) Set(s string) error {
+	v, err := ParseOrderStatus(s)
+	if err != nil {
+		return err
+	}
+	*e = v
+	return nil
+}
+
+// _OrderStatusInvalid is the error for a number that is not a declared constant.
+func _OrderStatusInvalid(n int64) error {
+	return fmt.Errorf("invalid OrderStatus %d (valid: %s)", n, "Received, Confirmed, Prepared, Delivered")
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (e OrderStatus) MarshalText() ([]byte, error) {
+	if !e.IsValid() {
+		return nil, _OrderStatusInvalid(int64(e))
+	}
+	return []byte(e.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (e *OrderStatus) UnmarshalText(text []byte) error {
+	return e.Set(string(text))
+}
+
+// MarshalJSON encodes the value as its display name.
+func (e OrderStatus) MarshalJSON() ([]byte, error) {
+	text, err := e.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(text))
+}
+
+// UnmarshalJSON decodes a display name, or a number for older payloads.
+// Numbers must be declared constants: 42 is rejected, not stored.
+func (e *OrderStatus) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte{'"'}) {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		return e.Set(s)
+	}
+	var n int64
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("OrderStatus must be a name or an integer, got %s", data)
+	}
+	if !OrderStatus(n).IsValid() {
+		return _OrderStatusInvalid(n)
+	}
+	*e = OrderStatus(n)
+	return nil
+}
+
+// Value implements driver.Valuer: the database stores the display name.
+func (e OrderStatus) Value() (driver.Value, error) {
+	if !e.IsValid() {
+		return nil, _OrderStatusInvalid(int64(e))
+	}
+	return e.String(), nil
+}
+
+// Scan implements sql.Scanner. It accepts names (string or []byte) and numbers.
+func (e *OrderStatus) Scan(src any) error {
+	switch v := src.(type) {
+	case string:
+		return e.UnmarshalText([]byte(v))
+	case []byte:
+		return e.UnmarshalText(v)
+	case int64:
+		if !OrderStatus(v).IsValid() {
+			return _OrderStatusInvalid(v)
+		}
+		*e = OrderStatus(v)
+		return nil
+	case nil:
+		return fmt.Errorf("cannot scan NULL into OrderStatus")
+	}
+	return fmt.Errorf("cannot scan %T into OrderStatus", src)
+}
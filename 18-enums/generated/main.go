@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ---------------------- GENERATED ENUM METHODS ----------------------
+// This is the OrderStatus enum from 18-enums, unchanged except for the
+// go:generate line and the enum:"..." comments.
+//
+// `go generate main.go` runs ../enumgen, which writes orderstatus_enum.go
+// with String(), ParseOrderStatus(), Values(), IsValid(), text/JSON
+// marshalling and SQL Scan/Value. We never edit that file by hand: when a
+// status is added here, we simply run go generate again.
+//
+// Because the program now lives in two files, run it with:
+//
+//	go run main.go orderstatus_enum.go
+//
+// ---------------------------------------------------------------------
+
+//go:generate go run ../enumgen/main.go -type=OrderStatus
+
+// OrderStatus is the same custom type as in 18-enums.
+type OrderStatus int
+
+// The enum:"..." comment gives a constant a display name. Recieved keeps
+// its (misspelt) Go name so existing code still compiles, but it prints
+// and parses as "Received".
+const (
+	Recieved  OrderStatus = iota // enum:"Received"
+	Confirmed                    // 1
+	Prepared                     // 2
+	Delivered                    // 3
+)
+
+// changeOrderStatus is the function from 18-enums. Because OrderStatus now
+// has a String() method, fmt prints the name instead of the number.
+func changeOrderStatus(status OrderStatus) {
+	fmt.Println("Change Order Status to:", status)
+}
+
+// order shows the enum inside a struct that is converted to and from JSON.
+type order struct {
+	ID     string      `json:"id"`
+	Status OrderStatus `json:"status"`
+}
+
+func main() {
+	// 1. Printing: names instead of numbers.
+	changeOrderStatus(Recieved)  // Output: Change Order Status to: Received
+	changeOrderStatus(Delivered) // Output: Change Order Status to: Delivered
+	fmt.Println(OrderStatus(42)) // Output: OrderStatus(42)
+	fmt.Println()
+
+	// 2. Listing and validating.
+	fmt.Println("All statuses:", Recieved.Values()) // [Received Confirmed Prepared Delivered]
+	fmt.Println("Is 2 valid?", OrderStatus(2).IsValid())
+	fmt.Println("Is 9 valid?", OrderStatus(9).IsValid())
+	fmt.Println()
+
+	// 3. Parsing text back into the enum.
+	status, err := ParseOrderStatus("Prepared")
+	fmt.Println("Parsed:", status, int(status), err) // Prepared 2 <nil>
+	_, err = ParseOrderStatus("Cancelled")
+	fmt.Println("Error:", err) // invalid OrderStatus "Cancelled"
+	fmt.Println()
+
+	// 4. JSON uses the display name in both directions.
+	data, _ := json.Marshal(order{ID: "1", Status: Confirmed})
+	fmt.Println(string(data)) // {"id":"1","status":"Confirmed"}
+
+	var o order
+	err = json.Unmarshal([]byte(`{"id":"2","status":"Delivered"}`), &o)
+	fmt.Println(o.ID, o.Status, err) // 2 Delivered <nil>
+	fmt.Println()
+
+	// 5. SQL: Value is what would be written to the database, Scan reads it back.
+	stored, _ := Prepared.Value()
+	var fromDB OrderStatus
+	fromDB.Scan([]byte("Received"))
+	fmt.Println("Stored in DB:", stored, "| Scanned from DB:", fromDB)
+}